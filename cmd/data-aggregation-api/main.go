@@ -13,10 +13,16 @@ import (
 	"github.com/criteo/data-aggregation-api/internal/api/auth"
 	"github.com/criteo/data-aggregation-api/internal/api/router"
 	"github.com/criteo/data-aggregation-api/internal/app"
+	"github.com/criteo/data-aggregation-api/internal/apply"
 	"github.com/criteo/data-aggregation-api/internal/config"
 	"github.com/criteo/data-aggregation-api/internal/convertor/device"
+	"github.com/criteo/data-aggregation-api/internal/drift"
+	"github.com/criteo/data-aggregation-api/internal/guard"
 	"github.com/criteo/data-aggregation-api/internal/job"
+	"github.com/criteo/data-aggregation-api/internal/lint"
+	"github.com/criteo/data-aggregation-api/internal/metrics"
 	"github.com/criteo/data-aggregation-api/internal/report"
+	"github.com/criteo/data-aggregation-api/internal/rollout"
 )
 
 func configureLogging(logLevel string, pretty bool) error {
@@ -78,14 +84,32 @@ func run() error {
 		auth.SetLDAPDefaultTimeout(config.Cfg.Authentication.LDAP.Timeout)
 	}
 
-	deviceRepo := device.NewSafeRepository()
+	if err := lint.LoadConfigRules(config.Cfg.Lint.Rules); err != nil {
+		return fmt.Errorf("invalid lint rules: %w", err)
+	}
+
+	deviceRepo := device.NewSafeRepository(config.Cfg.Build.KeepGenerations)
+	go deviceRepo.RunOverlayExpiry(ctx)
 	reports := report.NewRepository()
 
+	driftDetector := drift.NewDetector(&deviceRepo, config.Cfg.Drift.ManagedPaths, metrics.NewDriftRegistry())
+	if config.Cfg.Drift.GNMI.Enabled {
+		go drift.NewCollector(driftDetector, &deviceRepo, config.Cfg.Drift.GNMI).Run(ctx)
+	}
+
+	applyTracker := apply.NewTracker(&deviceRepo, metrics.NewApplyRegistry())
+	// devices may lag behind each new configuration served
+	deviceRepo.OnChange(applyTracker.UpdateMetrics)
+	go deviceRepo.RunChangeNotifier(ctx)
+	rolloutManager := rollout.NewManager(&deviceRepo, applyTracker, config.Cfg.Rollout)
+	go rolloutManager.Run(ctx)
+	gate := guard.NewGate(rolloutManager, &deviceRepo, &reports, config.Cfg.BlastRadius)
+
 	newBuildRequest := make(chan struct{})
 	triggerNewBuild := dispatchSingleRequest(newBuildRequest)
 
-	go job.StartBuildLoop(&deviceRepo, &reports, triggerNewBuild)
-	if err := router.NewManager(&deviceRepo, &reports, newBuildRequest).ListenAndServe(ctx, config.Cfg.API.ListenAddress, config.Cfg.API.ListenPort, config.Cfg.Debug.Pprof.Enabled); err != nil {
+	go job.StartBuildLoop(gate, &deviceRepo, &reports, triggerNewBuild)
+	if err := router.NewManager(&deviceRepo, driftDetector, applyTracker, gate, rolloutManager, &reports, newBuildRequest).ListenAndServe(ctx, config.Cfg.API.ListenAddress, config.Cfg.API.ListenPort, config.Cfg.Debug.Pprof.Enabled); err != nil {
 		return fmt.Errorf("webserver error: %w", err)
 	}
 
@@ -13,6 +13,7 @@ import (
 
 const (
 	unauthorizedResponse = `{"auth": "unauthorized"}`
+	forbiddenResponse    = `{"auth": "authentication is required but disabled"}`
 	wwwAuthenticate      = "WWW-Authenticate"
 	realm                = `Basic realm="restricted"`
 )
@@ -87,6 +88,17 @@ func (b *BasicAuth) Wrap(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// Require wraps the handlers of the endpoints changing what is served to the devices.
+// Unlike Wrap, they are refused when the authentication is disabled.
+func (b *BasicAuth) Require(next http.HandlerFunc) http.HandlerFunc {
+	if b.mode == noAuth {
+		return func(w http.ResponseWriter, _ *http.Request) {
+			http.Error(w, forbiddenResponse, http.StatusForbidden)
+		}
+	}
+	return b.Wrap(next)
+}
+
 // BasicAuthLDAP is a middleware wrapping the target HTTP HandlerFunc.
 // It retrieves BasicAuth credentials and authenticate against LDAP.
 func BasicAuthLDAP(ldapAuth *LDAPAuth, next http.HandlerFunc) http.HandlerFunc {
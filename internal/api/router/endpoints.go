@@ -1,20 +1,51 @@
 package router
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/criteo/data-aggregation-api/internal/app"
 	"github.com/criteo/data-aggregation-api/internal/convertor/device"
+	"github.com/criteo/data-aggregation-api/internal/drift"
+	"github.com/criteo/data-aggregation-api/internal/evaluator"
+	"github.com/criteo/data-aggregation-api/internal/guard"
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/bgp"
+	"github.com/criteo/data-aggregation-api/internal/rollout"
+	"github.com/criteo/data-aggregation-api/internal/search"
+	"github.com/criteo/data-aggregation-api/internal/topology"
 )
 
 const contentType = "Content-Type"
 const applicationJSON = "application/json"
 const hostnameKey = "hostname"
 const wildcard = "*"
+const policyNameKey = "name"
+const buildIDKey = "build_id"
+const maxRunningConfigSize = 64 << 20
+const maxApplyReportSize = 1 << 20
+const maxRouteSize = 64 << 10
+const maxPinRequestSize = 1 << 10
+const maxOverlaySize = 1 << 20
+const overlayIDKey = "id"
+
+// PinRequest is the build to pin a device to.
+type PinRequest struct {
+	BuildID string `json:"build_id"`
+}
+
+// headers identifying the configuration served to a device, to be reported back once applied
+const buildIDHeader = "X-Build-Id"
+const configHashHeader = "X-Config-Hash"
+
+const textVndGraphviz = "text/vnd.graphviz"
+const applicationGraphML = "application/graphml+xml"
 
 func getVersion(w http.ResponseWriter, _ *http.Request) {
 	w.Header().Set(contentType, applicationJSON)
@@ -98,6 +129,7 @@ func (m *Manager) getDeviceOpenConfig(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	m.setBuildHeaders(w, hostname)
 	_, _ = w.Write(cfg)
 }
 
@@ -123,6 +155,7 @@ func (m *Manager) getDeviceIETFConfig(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	m.setBuildHeaders(w, hostname)
 	_, _ = w.Write(cfg)
 }
 
@@ -148,9 +181,407 @@ func (m *Manager) getDeviceConfig(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	m.setBuildHeaders(w, hostname)
 	_, _ = w.Write(cfg)
 }
 
+// setBuildHeaders sets the build ID and the configuration hash served to a device, when known.
+func (m *Manager) setBuildHeaders(w http.ResponseWriter, hostname string) {
+	buildID, configHash, err := m.devices.GetBuildInfo(hostname)
+	if err != nil {
+		return
+	}
+	w.Header().Set(buildIDHeader, buildID)
+	w.Header().Set(configHashHeader, configHash)
+}
+
+// getDeviceGeneration endpoint returns the build generation served to one or all devices.
+func (m *Manager) getDeviceGeneration(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(contentType, applicationJSON)
+	hostname := r.PathValue(hostnameKey)
+
+	var out []byte
+	var err error
+	if hostname == wildcard {
+		out, err = m.devices.GetAllDevicesGenerationJSON()
+	} else {
+		out, err = m.devices.GetDeviceGenerationJSON(hostname)
+	}
+
+	switch {
+	case errors.Is(err, device.ErrNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case err != nil:
+		log.Error().Err(err).Send()
+		writeError(w, http.StatusInternalServerError, err)
+	default:
+		_, _ = w.Write(out)
+	}
+}
+
+// OverlayRequest is an overlay to attach to a device, its author is the authenticated user.
+type OverlayRequest struct {
+	Model     device.OverlayModel `json:"model"`
+	Fragment  json.RawMessage     `json:"fragment"`
+	Reason    string              `json:"reason"`
+	ExpiresAt time.Time           `json:"expires_at"`
+}
+
+// postOverlay endpoint attaches an overlay to a device.
+func (m *Manager) postOverlay(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(contentType, applicationJSON)
+
+	var request OverlayRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxOverlaySize)).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid overlay request: %w", err))
+		return
+	}
+
+	username, _, _ := r.BasicAuth()
+	overlay := &device.Overlay{
+		Hostname:  r.PathValue(hostnameKey),
+		Model:     request.Model,
+		Fragment:  request.Fragment,
+		Author:    username,
+		Reason:    request.Reason,
+		ExpiresAt: request.ExpiresAt,
+	}
+
+	err := m.devices.AddOverlay(overlay)
+	switch {
+	case errors.Is(err, device.ErrNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, device.ErrBuidFailed):
+		writeError(w, http.StatusConflict, err)
+	case errors.Is(err, device.ErrInvalidOverlay):
+		writeError(w, http.StatusBadRequest, err)
+	case err != nil:
+		log.Error().Err(err).Send()
+		writeError(w, http.StatusInternalServerError, err)
+	default:
+		log.Warn().Str("hostname", overlay.Hostname).Str("overlay", overlay.ID).Str("author", overlay.Author).Msg("overlay attached")
+		out, err := json.Marshal(overlay)
+		if err != nil {
+			log.Error().Err(err).Send()
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(out)
+	}
+}
+
+// getOverlays endpoint returns the active overlays of one or all devices.
+func (m *Manager) getOverlays(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(contentType, applicationJSON)
+
+	out, err := m.devices.ListOverlaysJSON(r.PathValue(hostnameKey))
+	if err != nil {
+		log.Error().Err(err).Send()
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	_, _ = w.Write(out)
+}
+
+// deleteOverlay endpoint removes an overlay before its expiry.
+func (m *Manager) deleteOverlay(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(contentType, applicationJSON)
+	hostname := r.PathValue(hostnameKey)
+	id := r.PathValue(overlayIDKey)
+
+	if err := m.devices.RemoveOverlay(hostname, id); err != nil {
+		if errors.Is(err, device.ErrOverlayNotFound) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		log.Error().Err(err).Send()
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	username, _, _ := r.BasicAuth()
+	log.Warn().Str("hostname", hostname).Str("overlay", id).Str("user", username).Msg("overlay removed")
+	_, _ = fmt.Fprintf(w, `{"message": "overlay %s removed"}`, id)
+}
+
+// pinDevice endpoint serves a kept build to a device, or to the whole fleet, until unpinned.
+func (m *Manager) pinDevice(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(contentType, applicationJSON)
+	hostname := r.PathValue(hostnameKey)
+
+	var request PinRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPinRequestSize)).Decode(&request); err != nil || request.BuildID == "" {
+		writeError(w, http.StatusBadRequest, errors.New("invalid pin request, build_id is required"))
+		return
+	}
+
+	err := m.devices.Pin(hostname, request.BuildID)
+	switch {
+	case errors.Is(err, device.ErrNotFound), errors.Is(err, device.ErrGenerationNotKept):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, device.ErrBuidFailed):
+		writeError(w, http.StatusConflict, err)
+	case err != nil:
+		log.Error().Err(err).Send()
+		writeError(w, http.StatusInternalServerError, err)
+	default:
+		username, _, _ := r.BasicAuth()
+		log.Warn().Str("hostname", hostname).Str("build_id", request.BuildID).Str("user", username).Msg("device pinned")
+		_, _ = fmt.Fprintf(w, `{"message": "%s pinned to build %s"}`, hostname, request.BuildID)
+	}
+}
+
+// unpinDevice endpoint serves the current build to a pinned device, or to the whole fleet, again.
+func (m *Manager) unpinDevice(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(contentType, applicationJSON)
+	hostname := r.PathValue(hostnameKey)
+
+	if err := m.devices.Unpin(hostname); err != nil {
+		if errors.Is(err, device.ErrNotPinned) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		log.Error().Err(err).Send()
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	username, _, _ := r.BasicAuth()
+	log.Warn().Str("hostname", hostname).Str("user", username).Msg("device unpinned")
+	_, _ = fmt.Fprintf(w, `{"message": "%s unpinned"}`, hostname)
+}
+
+// listPins endpoint returns the build ID pinned by hostname.
+func (m *Manager) listPins(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set(contentType, applicationJSON)
+
+	out, err := json.Marshal(m.devices.ListPins())
+	if err != nil {
+		log.Error().Err(err).Send()
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	_, _ = w.Write(out)
+}
+
+// evaluatePolicy endpoint simulates the route given in the request body against a route-policy of a device.
+func (m *Manager) evaluatePolicy(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(contentType, applicationJSON)
+
+	var route evaluator.Route
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRouteSize)).Decode(&route); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid route: %w", err))
+		return
+	}
+
+	out, err := m.devices.EvaluatePolicyJSON(r.PathValue(hostnameKey), r.PathValue(policyNameKey), &route)
+	switch {
+	case errors.Is(err, device.ErrNotFound), errors.Is(err, evaluator.ErrPolicyNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, evaluator.ErrInvalidRoute):
+		writeError(w, http.StatusBadRequest, err)
+	case err != nil:
+		log.Error().Err(err).Send()
+		writeError(w, http.StatusInternalServerError, err)
+	default:
+		_, _ = w.Write(out)
+	}
+}
+
+// postRunningConfig endpoint checks the running configuration of a device, pushed as RFC7951 JSON, for drift.
+func (m *Manager) postRunningConfig(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(contentType, applicationJSON)
+
+	running, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRunningConfigSize))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid running configuration: %w", err))
+		return
+	}
+
+	out, err := m.drift.CheckRunningJSON(r.PathValue(hostnameKey), running)
+	switch {
+	case errors.Is(err, device.ErrNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, device.ErrBuidFailed):
+		writeError(w, http.StatusConflict, err)
+	case err != nil:
+		writeError(w, http.StatusBadRequest, err)
+	default:
+		_, _ = w.Write(out)
+	}
+}
+
+// getDrift endpoint returns the last drift check of one or all devices.
+func (m *Manager) getDrift(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(contentType, applicationJSON)
+	hostname := r.PathValue(hostnameKey)
+
+	var out []byte
+	var err error
+	if hostname == wildcard {
+		out, err = m.drift.GetAllDriftJSON()
+	} else {
+		out, err = m.drift.GetDriftJSON(hostname)
+	}
+
+	switch {
+	case errors.Is(err, drift.ErrNotChecked):
+		writeError(w, http.StatusNotFound, err)
+	case err != nil:
+		log.Error().Err(err).Send()
+		writeError(w, http.StatusInternalServerError, err)
+	default:
+		_, _ = w.Write(out)
+	}
+}
+
+// postApplyStatus endpoint records the result of a configuration apply reported by AFK.
+func (m *Manager) postApplyStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(contentType, applicationJSON)
+
+	report, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxApplyReportSize))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid apply report: %w", err))
+		return
+	}
+
+	out, err := m.applies.ReportJSON(r.PathValue(hostnameKey), report)
+	switch {
+	case errors.Is(err, device.ErrNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case err != nil:
+		writeError(w, http.StatusBadRequest, err)
+	default:
+		_, _ = w.Write(out)
+	}
+}
+
+// getApplyStatus endpoint returns the apply status of one or all AFK enabled devices.
+func (m *Manager) getApplyStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(contentType, applicationJSON)
+	hostname := r.PathValue(hostnameKey)
+
+	var out []byte
+	var err error
+	if hostname == wildcard {
+		out, err = m.applies.GetAllStatusJSON()
+	} else {
+		out, err = m.applies.GetStatusJSON(hostname)
+	}
+
+	switch {
+	case errors.Is(err, device.ErrNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case err != nil:
+		log.Error().Err(err).Send()
+		writeError(w, http.StatusInternalServerError, err)
+	default:
+		_, _ = w.Write(out)
+	}
+}
+
+// getLaggingDevices endpoint returns the AFK enabled devices which did not apply the current build.
+func (m *Manager) getLaggingDevices(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set(contentType, applicationJSON)
+
+	out, err := m.applies.GetLaggingJSON()
+	if err != nil {
+		log.Error().Err(err).Send()
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	_, _ = w.Write(out)
+}
+
+// search endpoint returns the devices and paths referencing the values given as query parameters.
+func (m *Manager) search(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(contentType, applicationJSON)
+	params := r.URL.Query()
+
+	covering, err := parseOptionalBool(params.Get("covering"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid covering parameter: %w", err))
+		return
+	}
+
+	out, err := m.devices.SearchJSON(search.Query{
+		Prefix:    params.Get("prefix"),
+		Covering:  covering,
+		Community: params.Get("community"),
+		ASN:       params.Get("asn"),
+		Policy:    params.Get("policy"),
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	_, _ = w.Write(out)
+}
+
+// getBGPTopology endpoint returns the BGP peering graph as JSON, Graphviz DOT or GraphML.
+func (m *Manager) getBGPTopology(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	filter := topology.Filter{
+		Devices:       params["device"],
+		AddressFamily: bgp.AfiSafiChoice(params.Get("afi_safi")),
+	}
+	if asn := params.Get("asn"); asn != "" {
+		number, err := strconv.ParseUint(asn, 10, 32)
+		if err != nil {
+			w.Header().Set(contentType, applicationJSON)
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid asn parameter: %w", err))
+			return
+		}
+		filterASN := uint32(number)
+		filter.ASN = &filterASN
+	}
+
+	graph := m.devices.GetBGPTopology(filter)
+
+	var out []byte
+	var err error
+	switch format := params.Get("format"); format {
+	case "", "json":
+		w.Header().Set(contentType, applicationJSON)
+		out, err = json.Marshal(graph)
+	case "dot":
+		w.Header().Set(contentType, textVndGraphviz)
+		out = graph.MarshalDOT()
+	case "graphml":
+		w.Header().Set(contentType, applicationGraphML)
+		out, err = graph.MarshalGraphML()
+	default:
+		w.Header().Set(contentType, applicationJSON)
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unsupported format: %s", format))
+		return
+	}
+
+	if err != nil {
+		log.Error().Err(err).Send()
+		w.Header().Set(contentType, applicationJSON)
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	_, _ = w.Write(out)
+}
+
+func parseOptionalBool(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// writeError writes the error message as a JSON object.
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.WriteHeader(status)
+	out, _ := json.Marshal(map[string]string{"error": err.Error()})
+	_, _ = w.Write(out)
+}
+
 // getLastReport returns the last or current report.
 func (m *Manager) getLastReport(w http.ResponseWriter, _ *http.Request) {
 	out, err := m.reports.GetLastJSON()
@@ -202,3 +633,91 @@ func (m *Manager) triggerBuild(w http.ResponseWriter, _ *http.Request) {
 		_, _ = w.Write([]byte("{\"message\": \"a build request is already pending\""))
 	}
 }
+
+// getHeldBuild endpoint returns the build held because of its blast radius, if any.
+func (m *Manager) getHeldBuild(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set(contentType, applicationJSON)
+
+	out, err := m.gate.GetHeldJSON()
+	if err != nil {
+		log.Error().Err(err).Send()
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	_, _ = w.Write(out)
+}
+
+// decideOnBuild applies the decision of an authenticated user on a held build or a canary rollout.
+// notFound is the error returned for an unknown build ID.
+func decideOnBuild(w http.ResponseWriter, r *http.Request, decision string, decide func(buildID string) error, notFound error) {
+	w.Header().Set(contentType, applicationJSON)
+	buildID := r.PathValue(buildIDKey)
+
+	if err := decide(buildID); err != nil {
+		if errors.Is(err, notFound) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		log.Error().Err(err).Send()
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	username, _, _ := r.BasicAuth()
+	log.Warn().Str("build_id", buildID).Str("user", username).Msgf("build %s", decision)
+	_, _ = fmt.Fprintf(w, `{"message": "build %s %s"}`, buildID, decision)
+}
+
+// approveBuild endpoint publishes a held build.
+func (m *Manager) approveBuild(w http.ResponseWriter, r *http.Request) {
+	decideOnBuild(w, r, "approved", m.gate.Approve, guard.ErrNoHeldBuild)
+}
+
+// rejectBuild endpoint drops a held build.
+func (m *Manager) rejectBuild(w http.ResponseWriter, r *http.Request) {
+	decideOnBuild(w, r, "rejected", m.gate.Reject, guard.ErrNoHeldBuild)
+}
+
+// getRollout endpoint returns the ongoing canary rollout.
+func (m *Manager) getRollout(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set(contentType, applicationJSON)
+
+	out, err := m.rollout.GetStatusJSON()
+	if err != nil {
+		log.Error().Err(err).Send()
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	_, _ = w.Write(out)
+}
+
+// promoteRollout endpoint serves the canary build to all devices.
+func (m *Manager) promoteRollout(w http.ResponseWriter, r *http.Request) {
+	decideOnBuild(w, r, "promoted", m.rollout.Promote, rollout.ErrNoRollout)
+}
+
+// abortRollout endpoint serves the previous build to the canary devices again.
+func (m *Manager) abortRollout(w http.ResponseWriter, r *http.Request) {
+	decideOnBuild(w, r, "aborted", m.rollout.Abort, rollout.ErrNoRollout)
+}
+
+// listGenerations endpoint returns the builds kept for rollback and pinning.
+func (m *Manager) listGenerations(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set(contentType, applicationJSON)
+
+	out, err := json.Marshal(m.devices.ListGenerations())
+	if err != nil {
+		log.Error().Err(err).Send()
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	_, _ = w.Write(out)
+}
+
+// rollbackBuild endpoint serves a kept build to the whole fleet, until unpinned.
+func (m *Manager) rollbackBuild(w http.ResponseWriter, r *http.Request) {
+	rollback := func(buildID string) error {
+		return m.devices.Pin(wildcard, buildID)
+	}
+	decideOnBuild(w, r, "served to the whole fleet", rollback, device.ErrGenerationNotKept)
+}
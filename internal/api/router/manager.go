@@ -15,16 +15,32 @@ import (
 
 	"github.com/criteo/data-aggregation-api/internal/api/auth"
 	"github.com/criteo/data-aggregation-api/internal/app"
+	"github.com/criteo/data-aggregation-api/internal/apply"
 	"github.com/criteo/data-aggregation-api/internal/config"
 	"github.com/criteo/data-aggregation-api/internal/convertor/device"
+	"github.com/criteo/data-aggregation-api/internal/drift"
+	"github.com/criteo/data-aggregation-api/internal/evaluator"
+	"github.com/criteo/data-aggregation-api/internal/guard"
 	"github.com/criteo/data-aggregation-api/internal/report"
+	"github.com/criteo/data-aggregation-api/internal/rollout"
+	"github.com/criteo/data-aggregation-api/internal/search"
+	"github.com/criteo/data-aggregation-api/internal/topology"
 )
 
 const shutdownTimeout = 5 * time.Second
 const httpReadHeaderTimeout = 60 * time.Second
 
 type DevicesRepository interface {
-	Set(devices map[string]*device.Device)
+	GetBuildInfo(hostname string) (string, string, error)
+	GetDeviceGenerationJSON(hostname string) ([]byte, error)
+	GetAllDevicesGenerationJSON() ([]byte, error)
+	Pin(hostname string, buildID string) error
+	Unpin(hostname string) error
+	ListPins() map[string]string
+	ListGenerations() []device.GenerationInfo
+	AddOverlay(overlay *device.Overlay) error
+	RemoveOverlay(hostname string, id string) error
+	ListOverlaysJSON(hostname string) ([]byte, error)
 	ListAFKEnabledDevicesJSON() ([]byte, error)
 	IsAFKEnabledJSON(hostname string) ([]byte, error)
 	GetAllDevicesOpenConfigJSON() ([]byte, error)
@@ -33,17 +49,49 @@ type DevicesRepository interface {
 	GetDeviceIETFConfigJSON(hostname string) ([]byte, error)
 	GetAllDevicesConfigJSON() ([]byte, error)
 	GetDeviceConfigJSON(hostname string) ([]byte, error)
+	EvaluatePolicyJSON(hostname string, policyName string, route *evaluator.Route) ([]byte, error)
+	SearchJSON(query search.Query) ([]byte, error)
+	GetBGPTopology(filter topology.Filter) *topology.Topology
+}
+
+type DriftRepository interface {
+	CheckRunningJSON(hostname string, runningJSON []byte) ([]byte, error)
+	GetDriftJSON(hostname string) ([]byte, error)
+	GetAllDriftJSON() ([]byte, error)
+}
+
+type ApplyRepository interface {
+	ReportJSON(hostname string, reportJSON []byte) ([]byte, error)
+	GetStatusJSON(hostname string) ([]byte, error)
+	GetAllStatusJSON() ([]byte, error)
+	GetLaggingJSON() ([]byte, error)
+}
+
+type BuildGate interface {
+	GetHeldJSON() ([]byte, error)
+	Approve(buildID string) error
+	Reject(buildID string) error
+}
+
+type RolloutManager interface {
+	GetStatusJSON() ([]byte, error)
+	Promote(buildID string) error
+	Abort(buildID string) error
 }
 
 type Manager struct {
 	devices         DevicesRepository
+	drift           DriftRepository
+	applies         ApplyRepository
+	gate            BuildGate
+	rollout         RolloutManager
 	reports         *report.Repository
 	newBuildRequest chan<- struct{}
 }
 
 // NewManager creates and initializes a new API manager.
-func NewManager(deviceRepo DevicesRepository, reports *report.Repository, restartRequest chan<- struct{}) *Manager {
-	return &Manager{devices: deviceRepo, reports: reports, newBuildRequest: restartRequest}
+func NewManager(deviceRepo DevicesRepository, driftRepo DriftRepository, applyRepo ApplyRepository, gate BuildGate, rolloutManager RolloutManager, reports *report.Repository, restartRequest chan<- struct{}) *Manager {
+	return &Manager{devices: deviceRepo, drift: driftRepo, applies: applyRepo, gate: gate, rollout: rolloutManager, reports: reports, newBuildRequest: restartRequest}
 }
 
 // ListenAndServe starts to serve Web API requests.
@@ -82,6 +130,7 @@ func (m *Manager) ListenAndServe(ctx context.Context, address string, port int,
 	mux.HandleFunc("GET /v1/devices/{hostname}/openconfig", withAuth.Wrap(m.getDeviceOpenConfig))
 	mux.HandleFunc("GET /v1/devices/{hostname}/ietfconfig", withAuth.Wrap(m.getDeviceIETFConfig))
 	mux.HandleFunc("GET /v1/devices/{hostname}/config", withAuth.Wrap(m.getDeviceConfig))
+	mux.HandleFunc("GET /v1/devices/{hostname}/generation", withAuth.Wrap(m.getDeviceGeneration))
 
 	api.Get("/v1/devices/*/afk_enabled").
 		HasResponseModel(http.StatusOK, rest.ModelOf[map[string]device.AFKEnabledResponse]()).
@@ -115,6 +164,126 @@ func (m *Manager) ListenAndServe(ctx context.Context, address string, port int,
 		HasPathParameter("hostname", rest.PathParam{Description: "Device hostname", Type: rest.PrimitiveTypeString}).
 		HasTags([]string{"devices"}).HasDescription("Get full config (OpenConfig + IETF) for one specific device")
 
+	api.Get("/v1/devices/*/generation").
+		HasResponseModel(http.StatusOK, rest.ModelOf[map[string]device.GenerationResponse]()).
+		HasTags([]string{"devices"}).HasDescription("Get the build generation served to each device")
+	api.Get("/v1/devices/{hostname}/generation").
+		HasResponseModel(http.StatusOK, rest.ModelOf[device.GenerationResponse]()).
+		HasPathParameter("hostname", rest.PathParam{Description: "Device hostname", Type: rest.PrimitiveTypeString}).
+		HasTags([]string{"devices"}).HasDescription("Get the build generation (stable or canary) served to one device")
+
+	// pin endpoints
+	mux.HandleFunc("PUT /v1/devices/{hostname}/pin", withAuth.Require(m.pinDevice))
+	mux.HandleFunc("DELETE /v1/devices/{hostname}/pin", withAuth.Require(m.unpinDevice))
+	mux.HandleFunc("GET /v1/pins", withAuth.Wrap(m.listPins))
+
+	api.Put("/v1/devices/{hostname}/pin").
+		HasRequestModel(rest.ModelOf[PinRequest]()).
+		HasResponseModel(http.StatusOK, rest.ModelOf[string]()).
+		HasPathParameter("hostname", rest.PathParam{Description: "Device hostname, * rolls back the whole fleet", Type: rest.PrimitiveTypeString}).
+		HasTags([]string{"pin"}).HasDescription("Serve a kept build to a device, or to all devices, until unpinned, requires authentication")
+	api.Delete("/v1/devices/{hostname}/pin").
+		HasResponseModel(http.StatusOK, rest.ModelOf[string]()).
+		HasPathParameter("hostname", rest.PathParam{Description: "Device hostname, * ends the rollback of the whole fleet", Type: rest.PrimitiveTypeString}).
+		HasTags([]string{"pin"}).HasDescription("Serve the current build to a pinned device again, requires authentication")
+	api.Get("/v1/pins").
+		HasResponseModel(http.StatusOK, rest.ModelOf[map[string]string]()).
+		HasTags([]string{"pin"}).HasDescription("Get the build ID pinned by hostname, * being the whole fleet")
+
+	// overlay endpoints
+	mux.HandleFunc("POST /v1/devices/{hostname}/overlays", withAuth.Require(m.postOverlay))
+	mux.HandleFunc("GET /v1/devices/{hostname}/overlays", withAuth.Wrap(m.getOverlays))
+	mux.HandleFunc("DELETE /v1/devices/{hostname}/overlays/{id}", withAuth.Require(m.deleteOverlay))
+
+	api.Post("/v1/devices/{hostname}/overlays").
+		HasRequestModel(rest.ModelOf[OverlayRequest]()).
+		HasResponseModel(http.StatusCreated, rest.ModelOf[device.Overlay]()).
+		HasPathParameter("hostname", rest.PathParam{Description: "Device hostname", Type: rest.PrimitiveTypeString}).
+		HasTags([]string{"overlay"}).HasDescription("Merge a RFC7951 fragment into the configuration served to a device until it expires, requires authentication. Policy statements are ordered by sequence")
+	api.Get("/v1/devices/*/overlays").
+		HasResponseModel(http.StatusOK, rest.ModelOf[[]device.Overlay]()).
+		HasTags([]string{"overlay"}).HasDescription("Get the active overlays of all devices")
+	api.Get("/v1/devices/{hostname}/overlays").
+		HasResponseModel(http.StatusOK, rest.ModelOf[[]device.Overlay]()).
+		HasPathParameter("hostname", rest.PathParam{Description: "Device hostname", Type: rest.PrimitiveTypeString}).
+		HasTags([]string{"overlay"}).HasDescription("Get the active overlays of one device")
+	api.Delete("/v1/devices/{hostname}/overlays/{id}").
+		HasResponseModel(http.StatusOK, rest.ModelOf[string]()).
+		HasPathParameter("hostname", rest.PathParam{Description: "Device hostname", Type: rest.PrimitiveTypeString}).
+		HasPathParameter("id", rest.PathParam{Description: "Overlay ID", Type: rest.PrimitiveTypeString}).
+		HasTags([]string{"overlay"}).HasDescription("Remove an overlay before its expiry, requires authentication")
+
+	// drift endpoints
+	mux.HandleFunc("POST /v1/devices/{hostname}/running", withAuth.Wrap(m.postRunningConfig))
+	mux.HandleFunc("GET /v1/devices/{hostname}/drift", withAuth.Wrap(m.getDrift))
+
+	api.Post("/v1/devices/{hostname}/running").
+		HasRequestModel(rest.ModelOf[struct{}]()).
+		HasResponseModel(http.StatusOK, rest.ModelOf[drift.Status]()).
+		HasPathParameter("hostname", rest.PathParam{Description: "Device hostname", Type: rest.PrimitiveTypeString}).
+		HasTags([]string{"drift"}).HasDescription("Compare the running OpenConfig (RFC7951 JSON) of a device with its generated configuration")
+	api.Get("/v1/devices/*/drift").
+		HasResponseModel(http.StatusOK, rest.ModelOf[map[string]drift.Status]()).
+		HasTags([]string{"drift"}).HasDescription("Get the last drift check of all devices")
+	api.Get("/v1/devices/{hostname}/drift").
+		HasResponseModel(http.StatusOK, rest.ModelOf[drift.Status]()).
+		HasPathParameter("hostname", rest.PathParam{Description: "Device hostname", Type: rest.PrimitiveTypeString}).
+		HasTags([]string{"drift"}).HasDescription("Get the last drift check of one device")
+
+	// apply endpoints
+	mux.HandleFunc("POST /v1/devices/{hostname}/apply-status", withAuth.Wrap(m.postApplyStatus))
+	mux.HandleFunc("GET /v1/devices/{hostname}/apply-status", withAuth.Wrap(m.getApplyStatus))
+	mux.HandleFunc("GET /v1/apply-status/lagging", withAuth.Wrap(m.getLaggingDevices))
+
+	api.Post("/v1/devices/{hostname}/apply-status").
+		HasRequestModel(rest.ModelOf[apply.Report]()).
+		HasResponseModel(http.StatusOK, rest.ModelOf[apply.Status]()).
+		HasPathParameter("hostname", rest.PathParam{Description: "Device hostname", Type: rest.PrimitiveTypeString}).
+		HasTags([]string{"apply"}).HasDescription("Report the build ID or configuration hash applied by AFK on a device, and whether it succeeded")
+	api.Get("/v1/devices/*/apply-status").
+		HasResponseModel(http.StatusOK, rest.ModelOf[map[string]apply.Status]()).
+		HasTags([]string{"apply"}).HasDescription("Get the apply status of all AFK enabled devices")
+	api.Get("/v1/devices/{hostname}/apply-status").
+		HasResponseModel(http.StatusOK, rest.ModelOf[apply.Status]()).
+		HasPathParameter("hostname", rest.PathParam{Description: "Device hostname", Type: rest.PrimitiveTypeString}).
+		HasTags([]string{"apply"}).HasDescription("Get the last applies of one device compared to the configuration currently served")
+	api.Get("/v1/apply-status/lagging").
+		HasResponseModel(http.StatusOK, rest.ModelOf[map[string]apply.Status]()).
+		HasTags([]string{"apply"}).HasDescription("Get the AFK enabled devices lagging behind the current build")
+
+	// policy endpoints
+	mux.HandleFunc("POST /v1/devices/{hostname}/policy/{name}/evaluate", withAuth.Wrap(m.evaluatePolicy))
+
+	api.Post("/v1/devices/{hostname}/policy/{name}/evaluate").
+		HasRequestModel(rest.ModelOf[evaluator.Route]()).
+		HasResponseModel(http.StatusOK, rest.ModelOf[evaluator.Result]()).
+		HasPathParameter("hostname", rest.PathParam{Description: "Device hostname", Type: rest.PrimitiveTypeString}).
+		HasPathParameter("name", rest.PathParam{Description: "Route-policy name", Type: rest.PrimitiveTypeString}).
+		HasTags([]string{"policy"}).HasDescription("Simulate a route against a generated route-policy of the device")
+
+	// search endpoints
+	mux.HandleFunc("GET /v1/search", withAuth.Wrap(m.search))
+
+	api.Get("/v1/search").
+		HasResponseModel(http.StatusOK, rest.ModelOf[[]search.Match]()).
+		HasQueryParameter("prefix", rest.QueryParam{Description: "IP prefix or address", Type: rest.PrimitiveTypeString}).
+		HasQueryParameter("covering", rest.QueryParam{Description: "Also match the prefixes containing the searched prefix", Type: rest.PrimitiveTypeBool}).
+		HasQueryParameter("community", rest.QueryParam{Description: "Standard or extended community", Type: rest.PrimitiveTypeString}).
+		HasQueryParameter("asn", rest.QueryParam{Description: "AS number", Type: rest.PrimitiveTypeString}).
+		HasQueryParameter("policy", rest.QueryParam{Description: "Route-policy name", Type: rest.PrimitiveTypeString}).
+		HasTags([]string{"search"}).HasDescription("Find the devices and OpenConfig paths referencing the searched values")
+
+	// topology endpoints
+	mux.HandleFunc("GET /v1/topology/bgp", withAuth.Wrap(m.getBGPTopology))
+
+	api.Get("/v1/topology/bgp").
+		HasResponseModel(http.StatusOK, rest.ModelOf[topology.Topology]()).
+		HasQueryParameter("format", rest.QueryParam{Description: "json (default), dot or graphml", Type: rest.PrimitiveTypeString}).
+		HasQueryParameter("device", rest.QueryParam{Description: "Only the sessions of this device, can be repeated", Type: rest.PrimitiveTypeString}).
+		HasQueryParameter("asn", rest.QueryParam{Description: "Only the sessions with an end in this AS", Type: rest.PrimitiveTypeInteger}).
+		HasQueryParameter("afi_safi", rest.QueryParam{Description: "Only the sessions with this address family", Type: rest.PrimitiveTypeString}).
+		HasTags([]string{"topology"}).HasDescription("Get the BGP peering graph of the built devices")
+
 	// report endpoints
 	mux.HandleFunc("GET /v1/report/last", withAuth.Wrap(m.getLastReport))
 	mux.HandleFunc("GET /v1/report/last/complete", withAuth.Wrap(m.getLastCompleteReport))
@@ -137,6 +306,48 @@ func (m *Manager) ListenAndServe(ctx context.Context, address string, port int,
 		HasResponseModel(http.StatusOK, rest.ModelOf[string]()).
 		HasTags([]string{"build"}).HasDescription("Trigger a new build, only one at a time")
 
+	mux.HandleFunc("GET /v1/build/held", withAuth.Wrap(m.getHeldBuild))
+	mux.HandleFunc("GET /v1/build/generations", withAuth.Wrap(m.listGenerations))
+	mux.HandleFunc("POST /v1/build/{build_id}/rollback", withAuth.Require(m.rollbackBuild))
+	mux.HandleFunc("POST /v1/build/{build_id}/approve", withAuth.Require(m.approveBuild))
+	mux.HandleFunc("POST /v1/build/{build_id}/reject", withAuth.Require(m.rejectBuild))
+
+	api.Get("/v1/build/held").
+		HasResponseModel(http.StatusOK, rest.ModelOf[guard.HeldBuild]()).
+		HasTags([]string{"build"}).HasDescription("Build held because of its blast radius, null if none")
+	api.Get("/v1/build/generations").
+		HasResponseModel(http.StatusOK, rest.ModelOf[[]device.GenerationInfo]()).
+		HasTags([]string{"build"}).HasDescription("Get the builds kept for rollback and pinning, oldest first")
+	api.Post("/v1/build/{build_id}/rollback").
+		HasResponseModel(http.StatusOK, rest.ModelOf[string]()).
+		HasPathParameter("build_id", rest.PathParam{Description: "Kept build ID", Type: rest.PrimitiveTypeString}).
+		HasTags([]string{"build"}).HasDescription("Serve a kept build to the whole fleet until unpinned (DELETE /v1/devices/*/pin), requires authentication")
+	api.Post("/v1/build/{build_id}/approve").
+		HasResponseModel(http.StatusOK, rest.ModelOf[string]()).
+		HasPathParameter("build_id", rest.PathParam{Description: "Held build ID", Type: rest.PrimitiveTypeString}).
+		HasTags([]string{"build"}).HasDescription("Publish a held build, requires authentication")
+	api.Post("/v1/build/{build_id}/reject").
+		HasResponseModel(http.StatusOK, rest.ModelOf[string]()).
+		HasPathParameter("build_id", rest.PathParam{Description: "Held build ID", Type: rest.PrimitiveTypeString}).
+		HasTags([]string{"build"}).HasDescription("Drop a held build, the last published build keeps being served, requires authentication")
+
+	// rollout endpoints
+	mux.HandleFunc("GET /v1/rollout", withAuth.Wrap(m.getRollout))
+	mux.HandleFunc("POST /v1/rollout/{build_id}/promote", withAuth.Require(m.promoteRollout))
+	mux.HandleFunc("POST /v1/rollout/{build_id}/abort", withAuth.Require(m.abortRollout))
+
+	api.Get("/v1/rollout").
+		HasResponseModel(http.StatusOK, rest.ModelOf[rollout.Status]()).
+		HasTags([]string{"rollout"}).HasDescription("Get the ongoing canary rollout")
+	api.Post("/v1/rollout/{build_id}/promote").
+		HasResponseModel(http.StatusOK, rest.ModelOf[string]()).
+		HasPathParameter("build_id", rest.PathParam{Description: "Canary build ID", Type: rest.PrimitiveTypeString}).
+		HasTags([]string{"rollout"}).HasDescription("Serve the canary build to all devices, requires authentication")
+	api.Post("/v1/rollout/{build_id}/abort").
+		HasResponseModel(http.StatusOK, rest.ModelOf[string]()).
+		HasPathParameter("build_id", rest.PathParam{Description: "Canary build ID", Type: rest.PrimitiveTypeString}).
+		HasTags([]string{"rollout"}).HasDescription("Serve the previous build to the canary devices again, requires authentication")
+
 	if enablepprof {
 		mux.HandleFunc("GET /debug/pprof/", pprof.Index)
 		mux.HandleFunc("GET /debug/pprof/allocs", pprof.Index)
@@ -0,0 +1,230 @@
+// Package apply tracks the configuration applies reported by AFK, to know which devices lag behind the current build.
+package apply
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/criteo/data-aggregation-api/internal/convertor/device"
+	"github.com/criteo/data-aggregation-api/internal/metrics"
+)
+
+type LagReason string
+
+const (
+	// NeverReported devices have not reported any successful apply yet.
+	NeverReported LagReason = "never-reported"
+	// ApplyFailed devices failed to apply their last configuration.
+	ApplyFailed LagReason = "apply-failed"
+	// Outdated devices successfully applied a configuration which is not the current one.
+	Outdated LagReason = "outdated"
+)
+
+// CurrentConfigs gives the configuration currently served to the devices.
+type CurrentConfigs interface {
+	GetBuildInfo(hostname string) (string, string, error)
+	ListAFKEnabledDevices() []string
+}
+
+// Report is the result of a configuration apply, as reported by AFK.
+// At least one of BuildID or ConfigHash identifies the applied configuration.
+type Report struct {
+	BuildID    string    `json:"build_id,omitempty"`
+	ConfigHash string    `json:"config_hash,omitempty"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+	ReportedAt time.Time `json:"reported_at"`
+}
+
+// Status compares the last applies of a device with the configuration currently served.
+type Status struct {
+	Hostname          string    `json:"hostname"`
+	CurrentBuildID    string    `json:"current_build_id"`
+	CurrentConfigHash string    `json:"current_config_hash"`
+	LastReport        *Report   `json:"last_report,omitempty"`
+	LastSuccess       *Report   `json:"last_success,omitempty"`
+	Lagging           bool      `json:"lagging"`
+	Reason            LagReason `json:"reason,omitempty"`
+}
+
+// Tracker keeps the last applies reported by each device. Its methods are concurrent-safe.
+type Tracker struct {
+	configs     CurrentConfigs
+	metrics     *metrics.ApplyRegistry
+	mutex       *sync.Mutex
+	lastReports map[string]*Report
+	lastSuccess map[string]*Report
+	lastFailure map[string]*Report
+}
+
+func NewTracker(configs CurrentConfigs, registry *metrics.ApplyRegistry) *Tracker {
+	return &Tracker{
+		configs:     configs,
+		metrics:     registry,
+		mutex:       &sync.Mutex{},
+		lastReports: make(map[string]*Report),
+		lastSuccess: make(map[string]*Report),
+		lastFailure: make(map[string]*Report),
+	}
+}
+
+// isCurrent tells if a report is about the current configuration.
+// The hash is preferred as a build ID changes at each build, even when the configuration does not.
+func (r *Report) isCurrent(buildID string, configHash string) bool {
+	if r.ConfigHash != "" {
+		return r.ConfigHash == configHash
+	}
+	return r.BuildID == buildID
+}
+
+// Report records the result of a configuration apply of a device.
+func (t *Tracker) Report(hostname string, report Report) (*Status, error) {
+	if report.BuildID == "" && report.ConfigHash == "" {
+		return nil, errors.New("build_id or config_hash is required")
+	}
+	if _, _, err := t.configs.GetBuildInfo(hostname); err != nil && !errors.Is(err, device.ErrBuidFailed) {
+		return nil, err
+	}
+	if report.Success {
+		report.Error = ""
+	}
+	report.ReportedAt = time.Now()
+
+	t.mutex.Lock()
+	t.lastReports[hostname] = &report
+	if report.Success {
+		t.lastSuccess[hostname] = &report
+	} else {
+		t.lastFailure[hostname] = &report
+	}
+	t.mutex.Unlock()
+
+	t.metrics.ApplyReported(hostname, report.Success, float64(report.ReportedAt.Unix()))
+	t.UpdateMetrics()
+
+	return t.status(hostname, t.afkEnabled())
+}
+
+// afkEnabled returns the set of AFK enabled devices, to be fetched once per pass over the devices.
+func (t *Tracker) afkEnabled() map[string]bool {
+	hostnames := t.configs.ListAFKEnabledDevices()
+	enabled := make(map[string]bool, len(hostnames))
+	for _, hostname := range hostnames {
+		enabled[hostname] = true
+	}
+	return enabled
+}
+
+// status compares the last applies of a device with its current configuration.
+// Devices not enabled for AFK, or without current configuration, never lag.
+func (t *Tracker) status(hostname string, afkEnabled map[string]bool) (*Status, error) {
+	buildID, configHash, err := t.configs.GetBuildInfo(hostname)
+	if err != nil && !errors.Is(err, device.ErrBuidFailed) {
+		return nil, err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	status := &Status{
+		Hostname:          hostname,
+		CurrentBuildID:    buildID,
+		CurrentConfigHash: configHash,
+		LastReport:        t.lastReports[hostname],
+		LastSuccess:       t.lastSuccess[hostname],
+	}
+	if err != nil || !afkEnabled[hostname] {
+		return status, nil
+	}
+
+	lastFailed := status.LastReport != nil && !status.LastReport.Success
+	switch {
+	case status.LastSuccess != nil && status.LastSuccess.isCurrent(buildID, configHash):
+		return status, nil
+	case lastFailed:
+		status.Reason = ApplyFailed
+	case status.LastSuccess == nil:
+		status.Reason = NeverReported
+	default:
+		status.Reason = Outdated
+	}
+	status.Lagging = true
+
+	return status, nil
+}
+
+// FailedSince tells if a device reported an apply failure since the given time.
+func (t *Tracker) FailedSince(hostname string, since time.Time) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	failure, ok := t.lastFailure[hostname]
+	return ok && !failure.ReportedAt.Before(since)
+}
+
+// lagging returns the status of the AFK enabled devices lagging behind the current build, by hostname.
+func (t *Tracker) lagging() map[string]*Status {
+	lagging := make(map[string]*Status)
+	afkEnabled := t.afkEnabled()
+	for hostname := range afkEnabled {
+		status, err := t.status(hostname, afkEnabled)
+		if err != nil || !status.Lagging {
+			continue
+		}
+		lagging[hostname] = status
+	}
+	return lagging
+}
+
+// UpdateMetrics refreshes the lagging devices metrics, e.g. after a new build is published.
+func (t *Tracker) UpdateMetrics() {
+	counts := make(map[string]int)
+	for _, status := range t.lagging() {
+		counts[string(status.Reason)]++
+	}
+	t.metrics.SetLaggingDevices(counts)
+}
+
+// ReportJSON records the result of a configuration apply given as JSON and returns the new status of the device.
+func (t *Tracker) ReportJSON(hostname string, reportJSON []byte) ([]byte, error) {
+	var report Report
+	if err := json.Unmarshal(reportJSON, &report); err != nil {
+		return nil, err
+	}
+
+	status, err := t.Report(hostname, report)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(status)
+}
+
+// GetStatusJSON returns the apply status of a device.
+func (t *Tracker) GetStatusJSON(hostname string) ([]byte, error) {
+	status, err := t.status(hostname, t.afkEnabled())
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(status)
+}
+
+// GetAllStatusJSON returns the apply status of all AFK enabled devices, by hostname.
+func (t *Tracker) GetAllStatusJSON() ([]byte, error) {
+	statuses := make(map[string]*Status)
+	afkEnabled := t.afkEnabled()
+	for hostname := range afkEnabled {
+		status, err := t.status(hostname, afkEnabled)
+		if err != nil {
+			continue
+		}
+		statuses[hostname] = status
+	}
+	return json.Marshal(statuses)
+}
+
+// GetLaggingJSON returns the AFK enabled devices lagging behind the current build, by hostname.
+func (t *Tracker) GetLaggingJSON() ([]byte, error) {
+	return json.Marshal(t.lagging())
+}
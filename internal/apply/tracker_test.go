@@ -0,0 +1,97 @@
+package apply_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/criteo/data-aggregation-api/internal/apply"
+	"github.com/criteo/data-aggregation-api/internal/convertor/device"
+	"github.com/criteo/data-aggregation-api/internal/metrics"
+)
+
+var registry = metrics.NewApplyRegistry()
+
+// currentConfigs serves the configuration hash of the devices of the test, all generated by the same build.
+type currentConfigs struct {
+	buildID    string
+	hashes     map[string]string
+	afkEnabled []string
+}
+
+func (c *currentConfigs) GetBuildInfo(hostname string) (string, string, error) {
+	hash, ok := c.hashes[hostname]
+	if !ok {
+		return "", "", device.ErrNotFound
+	}
+	return c.buildID, hash, nil
+}
+
+func (c *currentConfigs) ListAFKEnabledDevices() []string {
+	return c.afkEnabled
+}
+
+func TestLagging(t *testing.T) {
+	configs := &currentConfigs{
+		buildID:    "20240101T000000Z",
+		hashes:     map[string]string{"tor01-01": "aaa", "tor01-02": "bbb", "tor01-03": "ccc", "tor01-04": "ddd"},
+		afkEnabled: []string{"tor01-01", "tor01-02", "tor01-03", "tor01-04"},
+	}
+	tracker := apply.NewTracker(configs, registry)
+
+	reports := []struct {
+		hostname string
+		report   apply.Report
+	}{
+		{"tor01-01", apply.Report{ConfigHash: "aaa", Success: true}},
+		{"tor01-02", apply.Report{BuildID: "20240101T000000Z", Success: true}},
+		{"tor01-02", apply.Report{BuildID: "20240101T000000Z", Success: false, Error: "commit failed"}},
+		{"tor01-03", apply.Report{ConfigHash: "ccc", Success: true}},
+	}
+	for _, r := range reports {
+		if _, err := tracker.Report(r.hostname, r.report); err != nil {
+			t.Fatalf("unexpected error for %s: %s", r.hostname, err)
+		}
+	}
+
+	// a new build changes the configuration of tor01-03 only
+	configs.buildID = "20240101T010000Z"
+	configs.hashes["tor01-03"] = "ccc2"
+
+	out, err := tracker.GetLaggingJSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var lagging map[string]*apply.Status
+	if err := json.Unmarshal(out, &lagging); err != nil {
+		t.Fatalf("invalid JSON: %s", err)
+	}
+
+	want := map[string]apply.LagReason{
+		"tor01-02": apply.ApplyFailed,
+		"tor01-03": apply.Outdated,
+		"tor01-04": apply.NeverReported,
+	}
+	got := make(map[string]apply.LagReason, len(lagging))
+	for hostname, status := range lagging {
+		got[hostname] = status.Reason
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("unexpected diff for lagging devices: %s\n", diff)
+	}
+	if lagging["tor01-02"].LastReport.Error != "commit failed" {
+		t.Errorf("unexpected last report: %+v", lagging["tor01-02"].LastReport)
+	}
+}
+
+func TestReportValidation(t *testing.T) {
+	tracker := apply.NewTracker(&currentConfigs{hashes: map[string]string{"tor01-01": "aaa"}}, registry)
+
+	if _, err := tracker.Report("tor01-01", apply.Report{Success: true}); err == nil {
+		t.Error("expected an error for a report without build_id nor config_hash")
+	}
+	if _, err := tracker.Report("unknown", apply.Report{ConfigHash: "aaa", Success: true}); err == nil {
+		t.Error("expected an error for an unknown device")
+	}
+}
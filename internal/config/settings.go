@@ -10,6 +10,8 @@ import (
 
 type Filter string
 
+type ConflictPolicy string
+
 const (
 	defaultListenAddress = "0.0.0.0"
 	defaultListenPort    = 8080
@@ -23,6 +25,22 @@ const (
 	SiteRegionFilter Filter = "region"
 
 	defaultLimitPerPage = 100
+
+	defaultKeepGenerations = 10
+
+	defaultGNMIPort     = 9339
+	defaultGNMITimeout  = 30 * time.Second
+	defaultGNMIInterval = 10 * time.Minute
+
+	defaultMaxMissingDevices = 0.1
+	defaultMaxRemovedItems   = 0.2
+
+	defaultSoakTime = 30 * time.Minute
+
+	// KeepFirstOnConflict keeps the first of conflicting CMDB objects and reports the others as warnings.
+	KeepFirstOnConflict ConflictPolicy = "keep-first"
+	// FailOnConflict fails the devices having conflicting CMDB objects.
+	FailOnConflict ConflictPolicy = "fail"
 )
 
 var (
@@ -53,8 +71,25 @@ type Config struct {
 		ListenPort    int
 	}
 	Build struct {
-		Interval            time.Duration
-		AllDevicesMustBuild bool
+		Interval                time.Duration
+		AllDevicesMustBuild     bool
+		FailOnMissingReferences bool
+		OnConflict              ConflictPolicy
+		OptimizePrefixLists     bool
+		// KeepGenerations is the number of recent builds kept in memory for rollback and pinning.
+		KeepGenerations int
+	}
+	Drift struct {
+		// ManagedPaths are the schema paths compared with the running configuration.
+		ManagedPaths []string
+		GNMI         GNMIConfig
+	}
+	BlastRadius BlastRadiusConfig
+	Rollout     RolloutConfig
+	Lint        struct {
+		// BlockPublishing removes the devices with error findings from the build.
+		BlockPublishing bool
+		Rules           []LintRule
 	}
 	Debug struct {
 		Pprof struct {
@@ -63,6 +98,56 @@ type Config struct {
 	}
 }
 
+// GNMIConfig configures the collector of the devices running configuration.
+type GNMIConfig struct {
+	Enabled            bool
+	Port               int
+	Username           string
+	Password           string
+	Plaintext          bool
+	InsecureSkipVerify bool
+	Timeout            time.Duration
+	Interval           time.Duration
+}
+
+// BlastRadiusConfig holds the builds changing too much compared with the last published build.
+// Thresholds are ratios between 0 and 1.
+type BlastRadiusConfig struct {
+	Enabled             bool
+	MaxChangedDevices   float64
+	MaxMissingDevices   float64
+	MaxRemovedNeighbors float64
+	MaxRemovedPolicies  float64
+}
+
+// RolloutConfig serves the new builds to a canary set of devices first.
+// Canary devices have one of the tags or roles (name or slug), or are part of the percentage of the fleet.
+type RolloutConfig struct {
+	Enabled     bool
+	CanaryTags  []string
+	CanaryRoles []string
+	// CanaryPercentage (0 to 100) of the devices, chosen by hostname so the set is the same at each build.
+	CanaryPercentage float64
+	// AutoPromote serves the canary build to the fleet after SoakTime without AFK apply failure on the canary devices.
+	AutoPromote bool
+	SoakTime    time.Duration
+}
+
+// LintRule is a declarative rule checked on the generated configuration of each device.
+// See the lint package for the expression language.
+type LintRule struct {
+	Name string
+	// Path selects the checked nodes, the root of the configuration if empty.
+	Path string
+	// When restricts the check to the nodes matching this expression.
+	When string
+	// Assert must be true for each checked node.
+	Assert string
+	// Severity is "warn" or "error" (default).
+	Severity string
+	Message  string
+}
+
 type AuthConfig struct {
 	LDAP *LDAPConfig
 }
@@ -93,6 +178,31 @@ func setDefaults() {
 
 	viper.SetDefault("Build.Interval", time.Minute)
 	viper.SetDefault("Build.AllDevicesMustBuild", false)
+	viper.SetDefault("Build.FailOnMissingReferences", true)
+	viper.SetDefault("Build.OnConflict", KeepFirstOnConflict)
+	viper.SetDefault("Build.OptimizePrefixLists", false)
+	viper.SetDefault("Build.KeepGenerations", defaultKeepGenerations)
+
+	viper.SetDefault("Drift.ManagedPaths", []string{"/network-instances/network-instance/protocols/protocol/bgp", "/routing-policy"})
+	viper.SetDefault("Drift.GNMI.Enabled", false)
+	viper.SetDefault("Drift.GNMI.Port", defaultGNMIPort)
+	viper.SetDefault("Drift.GNMI.Plaintext", false)
+	viper.SetDefault("Drift.GNMI.InsecureSkipVerify", false)
+	viper.SetDefault("Drift.GNMI.Timeout", defaultGNMITimeout)
+	viper.SetDefault("Drift.GNMI.Interval", defaultGNMIInterval)
+
+	viper.SetDefault("BlastRadius.Enabled", false)
+	viper.SetDefault("BlastRadius.MaxChangedDevices", 1)
+	viper.SetDefault("BlastRadius.MaxMissingDevices", defaultMaxMissingDevices)
+	viper.SetDefault("BlastRadius.MaxRemovedNeighbors", defaultMaxRemovedItems)
+	viper.SetDefault("BlastRadius.MaxRemovedPolicies", defaultMaxRemovedItems)
+
+	viper.SetDefault("Rollout.Enabled", false)
+	viper.SetDefault("Rollout.CanaryPercentage", 0)
+	viper.SetDefault("Rollout.AutoPromote", true)
+	viper.SetDefault("Rollout.SoakTime", defaultSoakTime)
+
+	viper.SetDefault("Lint.BlockPublishing", false)
 
 	viper.SetDefault("Authentication.LDAP.URL", "")
 	viper.SetDefault("Authentication.LDAP.BaseDN", "")
@@ -1,6 +1,7 @@
 package bgp
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/criteo/data-aggregation-api/internal/model/cmdb/bgp"
@@ -9,7 +10,7 @@ import (
 
 // BGPToOpenconfig converts all precomputed assets in BGP/* to OpenConfig.
 // OpenConfig path: /network-instances/network-instance/protocols/protocol/bgp/.
-func BGPToOpenconfig(hostname string, bgpGlobal *bgp.BGPGlobal, sessions []*bgp.Session, peerGroups []*bgp.PeerGroup) (*openconfig.NetworkInstance_Protocol_Bgp, error) {
+func BGPToOpenconfig(hostname string, bgpGlobal *bgp.BGPGlobal, sessions []*bgp.Session, peerGroups []*bgp.PeerGroup, dynamicNeighbors []*bgp.DynamicNeighbor) (*openconfig.NetworkInstance_Protocol_Bgp, error) {
 	neighbors, err := NeighborsToOpenconfig(hostname, sessions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert Neighbors to Openconfig: %w", err)
@@ -20,10 +21,28 @@ func BGPToOpenconfig(hostname string, bgpGlobal *bgp.BGPGlobal, sessions []*bgp.
 		return nil, fmt.Errorf("failed to convert BGPGlobal to Openconfig: %w", err)
 	}
 
+	if len(dynamicNeighbors) > 0 {
+		if globalConf == nil {
+			return nil, errors.New("dynamic neighbors require a BGP global configuration")
+		}
+		if globalConf.DynamicNeighborPrefix, err = DynamicNeighborsToOpenconfig(dynamicNeighbors, peerGroups); err != nil {
+			return nil, fmt.Errorf("failed to convert DynamicNeighbors to Openconfig: %w", err)
+		}
+	}
+
+	groups, err := PeerGroupsToOpenconfig(peerGroups, bgpGlobal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert PeerGroups to Openconfig: %w", err)
+	}
+
+	if err := setClusterID(bgpGlobal, neighbors, groups); err != nil {
+		return nil, fmt.Errorf("failed to set the route-reflector cluster ID: %w", err)
+	}
+
 	defaultInstance := openconfig.NetworkInstance_Protocol_Bgp{
 		Global:    globalConf,
 		Neighbor:  neighbors,
-		PeerGroup: PeerGroupsToOpenconfig(peerGroups),
+		PeerGroup: groups,
 	}
 
 	return &defaultInstance, err
@@ -199,7 +199,7 @@ func TestBGPToOpenConfig(t *testing.T) {
 		PeerGroup: map[string]*openconfig.NetworkInstance_Protocol_Bgp_PeerGroup{},
 	}
 
-	ret, err := bgp.BGPToOpenconfig("spine01-01", &globalConfig, sessions, nil)
+	ret, err := bgp.BGPToOpenconfig("spine01-01", &globalConfig, sessions, nil, nil)
 	if err != nil {
 		t.Errorf("failed to convert BGP to OpenConfig")
 	}
@@ -414,7 +414,7 @@ func TestBGPToOpenConfigTimersAndAggretates(t *testing.T) {
 		PeerGroup: map[string]*openconfig.NetworkInstance_Protocol_Bgp_PeerGroup{},
 	}
 
-	ret, err := bgp.BGPToOpenconfig("spine01-01", &globalConfig, sessions, nil)
+	ret, err := bgp.BGPToOpenconfig("spine01-01", &globalConfig, sessions, nil, nil)
 	if err != nil {
 		t.Errorf("failed to convert BGP to OpenConfig")
 	}
@@ -423,3 +423,468 @@ func TestBGPToOpenConfigTimersAndAggretates(t *testing.T) {
 		t.Errorf("unexpected diff for '%s': %s\n", "BGP integration test", diff)
 	}
 }
+
+func TestNeighborsToOpenconfigTransportTimersAndBFD(t *testing.T) {
+	var as65000 uint32 = 65000
+	var as65001 uint32 = 65001
+	var flagTrue = true
+
+	newSession := func(spine cmdbBGP.DeviceSession) *cmdbBGP.Session {
+		spine.Device.Name = "spine01-01"
+		spine.LocalAddress = cmdbBGP.Address{Address: types.CIDR{IP: net.ParseIP("192.0.2.1"), Netmask: 31}, Family: 4}
+		spine.LocalAsn = common.ASN{Number: &as65001, Organization: "Lab-65001"}
+		spine.Enabled = &flagTrue
+
+		tor := cmdbBGP.DeviceSession{
+			LocalAddress: cmdbBGP.Address{Address: types.CIDR{IP: net.ParseIP("192.0.2.0"), Netmask: 31}, Family: 4},
+			LocalAsn:     common.ASN{Number: &as65000, Organization: "Lab-65000"},
+			Enabled:      &flagTrue,
+		}
+		tor.Device.Name = "tor01-01"
+
+		return &cmdbBGP.Session{PeerA: tor, PeerB: spine}
+	}
+
+	var holdTime9 uint16 = 9
+	var keepalive3 uint16 = 3
+	var connectRetry10 uint16 = 10
+	var ttl2 uint8 = 2
+	var loopback = "Loopback0"
+
+	tests := []struct {
+		name    string
+		local   cmdbBGP.DeviceSession
+		want    *openconfig.NetworkInstance_Protocol_Bgp_Neighbor
+		wantErr bool
+	}{
+		{
+			name: "all attributes",
+			local: cmdbBGP.DeviceSession{
+				HoldTime:          9,
+				KeepaliveInterval: 3,
+				ConnectRetry:      10,
+				EBGPMultihopTTL:   2,
+				UpdateSource:      "Loopback0",
+				PassiveMode:       true,
+				BFD:               &cmdbBGP.BFD{Enabled: true},
+			},
+			want: &openconfig.NetworkInstance_Protocol_Bgp_Neighbor{
+				Timers: &openconfig.NetworkInstance_Protocol_Bgp_Neighbor_Timers{
+					HoldTime:          &holdTime9,
+					KeepaliveInterval: &keepalive3,
+					ConnectRetry:      &connectRetry10,
+				},
+				Transport: &openconfig.NetworkInstance_Protocol_Bgp_Neighbor_Transport{
+					LocalAddress: &loopback,
+					PassiveMode:  &flagTrue,
+				},
+				EbgpMultihop: &openconfig.NetworkInstance_Protocol_Bgp_Neighbor_EbgpMultihop{
+					Enabled:     &flagTrue,
+					MultihopTtl: &ttl2,
+				},
+				EnableBfd: &openconfig.NetworkInstance_Protocol_Bgp_Neighbor_EnableBfd{
+					Enabled: &flagTrue,
+				},
+			},
+		},
+		{
+			name:    "keepalive greater than hold time",
+			local:   cmdbBGP.DeviceSession{HoldTime: 9, KeepaliveInterval: 10},
+			wantErr: true,
+		},
+		{
+			name:    "keepalive without hold time",
+			local:   cmdbBGP.DeviceSession{KeepaliveInterval: 10},
+			wantErr: true,
+		},
+		{
+			name:    "hold time too low",
+			local:   cmdbBGP.DeviceSession{HoldTime: 2},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		neighbors, err := bgp.NeighborsToOpenconfig("spine01-01", []*cmdbBGP.Session{newSession(test.local)})
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("expected an error for '%s'", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for '%s': %s", test.name, err)
+			continue
+		}
+
+		got := neighbors["192.0.2.0"]
+		if diff := cmp.Diff(got.Timers, test.want.Timers); diff != "" {
+			t.Errorf("unexpected timers diff for '%s': %s\n", test.name, diff)
+		}
+		if diff := cmp.Diff(got.Transport, test.want.Transport); diff != "" {
+			t.Errorf("unexpected transport diff for '%s': %s\n", test.name, diff)
+		}
+		if diff := cmp.Diff(got.EbgpMultihop, test.want.EbgpMultihop); diff != "" {
+			t.Errorf("unexpected eBGP multihop diff for '%s': %s\n", test.name, diff)
+		}
+		if diff := cmp.Diff(got.EnableBfd, test.want.EnableBfd); diff != "" {
+			t.Errorf("unexpected BFD diff for '%s': %s\n", test.name, diff)
+		}
+	}
+}
+
+func TestBGPToOpenConfigRouteReflectionAndAddPaths(t *testing.T) {
+	var as65000 uint32 = 65000
+	var as65001 uint32 = 65001
+	var flagTrue = true
+	var flagFalse = false
+	var allowOwnAs1 uint8 = 1
+	var sendMax4 uint8 = 4
+	var groupName = "RR-CLIENTS"
+
+	newSession := func(remoteAsn *uint32, spine cmdbBGP.DeviceSession) *cmdbBGP.Session {
+		spine.Device.Name = "spine01-01"
+		spine.LocalAddress = cmdbBGP.Address{Address: types.CIDR{IP: net.ParseIP("192.0.2.1"), Netmask: 31}, Family: 4}
+		spine.LocalAsn = common.ASN{Number: &as65001, Organization: "Lab-65001"}
+		spine.Enabled = &flagTrue
+
+		tor := cmdbBGP.DeviceSession{
+			LocalAddress: cmdbBGP.Address{Address: types.CIDR{IP: net.ParseIP("192.0.2.0"), Netmask: 31}, Family: 4},
+			LocalAsn:     common.ASN{Number: remoteAsn, Organization: "Lab"},
+			Enabled:      &flagTrue,
+		}
+		tor.Device.Name = "tor01-01"
+
+		return &cmdbBGP.Session{PeerA: tor, PeerB: spine}
+	}
+
+	globalConfig := cmdbBGP.BGPGlobal{
+		LocalAsn:               common.ASN{Number: &as65001, Organization: "Lab-65001"},
+		GracefulRestartEnabled: &flagFalse,
+		EcmpEnabled:            &flagFalse,
+		ClusterID:              "192.0.2.255",
+		AfiSafis:               []*cmdbBGP.GlobalAfiSafi{{Name: cmdbBGP.IPv4Unicast}},
+	}
+
+	peerGroups := []*cmdbBGP.PeerGroup{
+		{
+			Name:                 groupName,
+			RouteReflectorClient: true,
+			AddPaths:             &cmdbBGP.AddPaths{Send: true, Receive: false, SendMax: 4},
+			SendCommunity:        cmdbBGP.SendCommunityBoth,
+			NextHopSelf:          true,
+		},
+	}
+
+	session := newSession(&as65001, cmdbBGP.DeviceSession{
+		AfiSafis:             []*cmdbBGP.AfiSafi{{Name: cmdbBGP.IPv4Unicast}, {Name: cmdbBGP.IPv6Unicast}},
+		RouteReflectorClient: true,
+		AddPaths:             &cmdbBGP.AddPaths{Send: true, Receive: true},
+		AllowOwnAs:           1,
+		RemovePrivateAs:      cmdbBGP.RemovePrivateAsRemoveAll,
+		SendCommunity:        cmdbBGP.SendCommunityStandard,
+	})
+
+	ret, err := bgp.BGPToOpenconfig("spine01-01", &globalConfig, []*cmdbBGP.Session{session}, peerGroups, nil)
+	if err != nil {
+		t.Fatalf("failed to convert BGP to OpenConfig: %s", err)
+	}
+
+	if err := ret.Validate(); err != nil {
+		t.Errorf("generated BGP configuration does not pass ygot validation: %s", err)
+	}
+
+	clusterID := openconfig.UnionString("192.0.2.255")
+	neighbor := ret.Neighbor["192.0.2.0"]
+	wantNeighborRR := &openconfig.NetworkInstance_Protocol_Bgp_Neighbor_RouteReflector{RouteReflectorClient: &flagTrue, RouteReflectorClusterId: clusterID}
+	if diff := cmp.Diff(neighbor.RouteReflector, wantNeighborRR); diff != "" {
+		t.Errorf("unexpected neighbor route-reflector diff: %s\n", diff)
+	}
+	for _, safi := range []openconfig.E_BgpTypes_AFI_SAFI_TYPE{openconfig.BgpTypes_AFI_SAFI_TYPE_IPV4_UNICAST, openconfig.BgpTypes_AFI_SAFI_TYPE_IPV6_UNICAST} {
+		wantAddPaths := &openconfig.NetworkInstance_Protocol_Bgp_Neighbor_AfiSafi_AddPaths{Send: &flagTrue, Receive: &flagTrue}
+		if diff := cmp.Diff(neighbor.AfiSafi[safi].AddPaths, wantAddPaths); diff != "" {
+			t.Errorf("unexpected neighbor %s add-paths diff: %s\n", safi, diff)
+		}
+	}
+	if diff := cmp.Diff(neighbor.AsPathOptions, &openconfig.NetworkInstance_Protocol_Bgp_Neighbor_AsPathOptions{AllowOwnAs: &allowOwnAs1}); diff != "" {
+		t.Errorf("unexpected neighbor as-path-options diff: %s\n", diff)
+	}
+	if neighbor.RemovePrivateAs != openconfig.BgpTypes_RemovePrivateAsOption_PRIVATE_AS_REMOVE_ALL {
+		t.Errorf("unexpected remove-private-as: %v", neighbor.RemovePrivateAs)
+	}
+	if neighbor.SendCommunity != openconfig.BgpTypes_CommunityType_STANDARD {
+		t.Errorf("unexpected send-community: %v", neighbor.SendCommunity)
+	}
+
+	group := ret.PeerGroup[groupName]
+	wantGroupRR := &openconfig.NetworkInstance_Protocol_Bgp_PeerGroup_RouteReflector{RouteReflectorClient: &flagTrue, RouteReflectorClusterId: clusterID}
+	if diff := cmp.Diff(group.RouteReflector, wantGroupRR); diff != "" {
+		t.Errorf("unexpected peer-group route-reflector diff: %s\n", diff)
+	}
+	wantGroupSafis := map[openconfig.E_BgpTypes_AFI_SAFI_TYPE]*openconfig.NetworkInstance_Protocol_Bgp_PeerGroup_AfiSafi{
+		openconfig.BgpTypes_AFI_SAFI_TYPE_IPV4_UNICAST: {
+			AfiSafiName: openconfig.BgpTypes_AFI_SAFI_TYPE_IPV4_UNICAST,
+			AddPaths:    &openconfig.NetworkInstance_Protocol_Bgp_PeerGroup_AfiSafi_AddPaths{Send: &flagTrue, Receive: &flagFalse, SendMax: &sendMax4},
+		},
+	}
+	if diff := cmp.Diff(group.AfiSafi, wantGroupSafis); diff != "" {
+		t.Errorf("unexpected peer-group add-paths diff: %s\n", diff)
+	}
+	if diff := cmp.Diff(group.ApplyPolicy.ExportPolicy, []string{"NEXT-HOP-SELF"}); diff != "" {
+		t.Errorf("unexpected peer-group export policies diff: %s\n", diff)
+	}
+
+	// add-paths is negotiated per AFI/SAFI
+	noSafiSession := newSession(&as65001, cmdbBGP.DeviceSession{AddPaths: &cmdbBGP.AddPaths{Send: true}})
+	if _, err := bgp.BGPToOpenconfig("spine01-01", &globalConfig, []*cmdbBGP.Session{noSafiSession}, nil, nil); err == nil {
+		t.Errorf("expected an error for add-paths on a neighbor without AFI/SAFI")
+	}
+
+	// route-reflector clients must be iBGP neighbors
+	ebgpSession := newSession(&as65000, cmdbBGP.DeviceSession{RouteReflectorClient: true})
+	if _, err := bgp.BGPToOpenconfig("spine01-01", &globalConfig, []*cmdbBGP.Session{ebgpSession}, nil, nil); err == nil {
+		t.Errorf("expected an error for an eBGP route-reflector client")
+	}
+
+	globalConfig.ClusterID = "not-a-cluster-id"
+	if _, err := bgp.BGPToOpenconfig("spine01-01", &globalConfig, []*cmdbBGP.Session{session}, nil, nil); err == nil {
+		t.Errorf("expected an error for an invalid cluster ID")
+	}
+}
+
+func TestNeighborsToOpenconfigLinkLocal(t *testing.T) {
+	var as65000 uint32 = 65000
+	var as65001 uint32 = 65001
+	var flagTrue = true
+
+	newSession := func(torAddress cmdbBGP.Address, spine cmdbBGP.DeviceSession) *cmdbBGP.Session {
+		spine.Device.Name = "spine01-01"
+		spine.LocalAsn = common.ASN{Number: &as65001, Organization: "Lab-65001"}
+		spine.Enabled = &flagTrue
+		spine.AfiSafis = []*cmdbBGP.AfiSafi{{Name: cmdbBGP.IPv4Unicast}, {Name: cmdbBGP.IPv6Unicast}}
+
+		tor := cmdbBGP.DeviceSession{
+			LocalAddress: torAddress,
+			LocalAsn:     common.ASN{Number: &as65000, Organization: "Lab-65000"},
+			Enabled:      &flagTrue,
+		}
+		tor.Device.Name = "tor01-01"
+
+		return &cmdbBGP.Session{PeerA: tor, PeerB: spine}
+	}
+
+	linkLocal := cmdbBGP.Address{Address: types.CIDR{IP: net.ParseIP("fe80::1"), Netmask: 64}, Family: 6}
+
+	tests := []struct {
+		name                string
+		session             *cmdbBGP.Session
+		wantKey             string
+		wantLocalAddress    string
+		wantExtendedNextHop bool
+		wantErr             bool
+	}{
+		{
+			name:    "global address",
+			session: newSession(cmdbBGP.Address{Address: types.CIDR{IP: net.ParseIP("2001:db8::1"), Netmask: 127}, Family: 6}, cmdbBGP.DeviceSession{}),
+			wantKey: "2001:db8::1",
+		},
+		{
+			name:                "link-local scoped by the session interface",
+			session:             newSession(linkLocal, cmdbBGP.DeviceSession{Interface: &cmdbBGP.InterfaceLite{Name: "Ethernet2"}}),
+			wantKey:             "fe80::1%Ethernet2",
+			wantExtendedNextHop: true,
+		},
+		{
+			name: "link-local scoped by the local address",
+			session: newSession(linkLocal, cmdbBGP.DeviceSession{
+				LocalAddress: cmdbBGP.Address{Address: types.CIDR{IP: net.ParseIP("fe80::2"), Netmask: 64, Zone: "Ethernet3"}, Family: 6},
+			}),
+			wantKey:             "fe80::1%Ethernet3",
+			wantExtendedNextHop: true,
+		},
+		{
+			name:                "unnumbered",
+			session:             newSession(cmdbBGP.Address{}, cmdbBGP.DeviceSession{Interface: &cmdbBGP.InterfaceLite{Name: "Ethernet1"}}),
+			wantKey:             "Ethernet1",
+			wantLocalAddress:    "Ethernet1",
+			wantExtendedNextHop: true,
+		},
+		{
+			name:    "unnumbered with update source",
+			session: newSession(cmdbBGP.Address{}, cmdbBGP.DeviceSession{Interface: &cmdbBGP.InterfaceLite{Name: "Ethernet1"}, UpdateSource: "Loopback0"}),
+			wantErr: true,
+		},
+		{
+			name:    "link-local without scope interface",
+			session: newSession(linkLocal, cmdbBGP.DeviceSession{}),
+			wantErr: true,
+		},
+		{
+			name:    "no address nor interface",
+			session: newSession(cmdbBGP.Address{}, cmdbBGP.DeviceSession{}),
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		neighbors, err := bgp.NeighborsToOpenconfig("spine01-01", []*cmdbBGP.Session{test.session})
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("expected an error for '%s'", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for '%s': %s", test.name, err)
+			continue
+		}
+
+		neighbor, ok := neighbors[test.wantKey]
+		if !ok {
+			t.Errorf("neighbor %s not found for '%s'", test.wantKey, test.name)
+			continue
+		}
+		if *neighbor.NeighborAddress != test.wantKey {
+			t.Errorf("unexpected neighbor address for '%s': %s", test.name, *neighbor.NeighborAddress)
+		}
+
+		if localAddress := neighbor.GetTransport().GetLocalAddress(); localAddress != test.wantLocalAddress {
+			t.Errorf("unexpected transport local address for '%s': %s", test.name, localAddress)
+		}
+
+		extendedNextHop := neighbor.AfiSafi[openconfig.BgpTypes_AFI_SAFI_TYPE_IPV4_UNICAST].Ipv4Unicast.ExtendedNextHopEncoding
+		if (extendedNextHop != nil && *extendedNextHop) != test.wantExtendedNextHop {
+			t.Errorf("unexpected extended next-hop for '%s': %v", test.name, extendedNextHop)
+		}
+	}
+}
+
+func TestDynamicNeighborsToOpenconfig(t *testing.T) {
+	var as65000 uint32 = 65000
+	var as65100 uint32 = 65100
+	var groupName = "SERVERS"
+	var prefix = "192.0.2.0/24"
+
+	peerGroups := []*cmdbBGP.PeerGroup{
+		{Name: groupName, RemoteAsn: &common.ASN{Number: &as65100}},
+		{Name: "NO-REMOTE-ASN"},
+	}
+
+	newDynamicNeighbor := func(ip string, netmask int, group string) *cmdbBGP.DynamicNeighbor {
+		return &cmdbBGP.DynamicNeighbor{
+			Prefix:    types.CIDR{IP: net.ParseIP(ip), Netmask: netmask},
+			PeerGroup: cmdbBGP.PeerGroupLite{Name: group},
+		}
+	}
+
+	valid := newDynamicNeighbor("192.0.2.0", 24, groupName)
+	valid.RemoteAsnMin = &common.ASN{Number: &as65100}
+	valid.RemoteAsnMax = &common.ASN{Number: &as65100}
+
+	ret, err := bgp.DynamicNeighborsToOpenconfig([]*cmdbBGP.DynamicNeighbor{valid}, peerGroups)
+	if err != nil {
+		t.Fatalf("failed to convert dynamic neighbors to OpenConfig: %s", err)
+	}
+
+	want := map[string]*openconfig.NetworkInstance_Protocol_Bgp_Global_DynamicNeighborPrefix{
+		prefix: {Prefix: &prefix, PeerGroup: &groupName},
+	}
+	if diff := cmp.Diff(ret, want); diff != "" {
+		t.Errorf("unexpected diff: %s\n", diff)
+	}
+
+	mismatch := newDynamicNeighbor("192.0.2.0", 24, groupName)
+	mismatch.RemoteAsnMin = &common.ASN{Number: &as65000}
+	mismatch.RemoteAsnMax = &common.ASN{Number: &as65000}
+
+	noRemoteASN := newDynamicNeighbor("192.0.2.0", 24, "NO-REMOTE-ASN")
+	noRemoteASN.RemoteAsnMin = &common.ASN{Number: &as65100}
+	noRemoteASN.RemoteAsnMax = &common.ASN{Number: &as65100}
+
+	invalidCases := map[string][]*cmdbBGP.DynamicNeighbor{
+		"unknown peer-group":           {newDynamicNeighbor("192.0.2.0", 24, "UNKNOWN")},
+		"host bits set":                {newDynamicNeighbor("192.0.2.1", 24, groupName)},
+		"duplicated prefix":            {newDynamicNeighbor("192.0.2.0", 24, groupName), newDynamicNeighbor("192.0.2.0", 24, groupName)},
+		"remote ASN mismatch":          {mismatch},
+		"peer-group without remote AS": {noRemoteASN},
+	}
+	for name, dynamicNeighbors := range invalidCases {
+		if _, err := bgp.DynamicNeighborsToOpenconfig(dynamicNeighbors, peerGroups); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestBGPGlobalToOpenconfigConfederationAndBestPath(t *testing.T) {
+	var as65000 uint32 = 65000
+	var as65001 uint32 = 65001
+	var as65002 uint32 = 65002
+	var flagTrue = true
+	var flagFalse = false
+	var staleRoutesTime uint16 = 300
+	var maximumPaths8 uint32 = 8
+
+	globalConfig := cmdbBGP.BGPGlobal{
+		LocalAsn:                       common.ASN{Number: &as65001, Organization: "Lab-65001"},
+		GracefulRestartEnabled:         &flagTrue,
+		GracefulRestartStaleRoutesTime: &staleRoutesTime,
+		GracefulRestartHelperOnly:      true,
+		EcmpEnabled:                    &flagTrue,
+		EcmpAllowMultipleAs:            true,
+		ConfederationID:                &common.ASN{Number: &as65000},
+		ConfederationMembers:           []common.ASN{{Number: &as65002}},
+		AlwaysCompareMed:               true,
+		AfiSafis: []*cmdbBGP.GlobalAfiSafi{
+			{Name: cmdbBGP.IPv4Unicast, EcmpEnabled: &flagTrue, EcmpMaximumPaths: &maximumPaths8},
+		},
+	}
+
+	ret, err := bgp.BGPGlobalToOpenconfig(&globalConfig)
+	if err != nil {
+		t.Fatalf("failed to convert BGP global to OpenConfig: %s", err)
+	}
+
+	wantConfederation := &openconfig.NetworkInstance_Protocol_Bgp_Global_Confederation{
+		Identifier: &as65000,
+		MemberAs:   []uint32{as65002},
+	}
+	if diff := cmp.Diff(ret.Confederation, wantConfederation); diff != "" {
+		t.Errorf("unexpected confederation diff: %s\n", diff)
+	}
+
+	wantRouteSelection := &openconfig.NetworkInstance_Protocol_Bgp_Global_RouteSelectionOptions{
+		AlwaysCompareMed:        &flagTrue,
+		IgnoreAsPathLength:      &flagFalse,
+		ExternalCompareRouterId: &flagFalse,
+	}
+	if diff := cmp.Diff(ret.RouteSelectionOptions, wantRouteSelection); diff != "" {
+		t.Errorf("unexpected route-selection-options diff: %s\n", diff)
+	}
+
+	wantGracefulRestart := &openconfig.NetworkInstance_Protocol_Bgp_Global_GracefulRestart{
+		Enabled:         &flagTrue,
+		StaleRoutesTime: &staleRoutesTime,
+		HelperOnly:      &flagTrue,
+	}
+	if diff := cmp.Diff(ret.GracefulRestart, wantGracefulRestart); diff != "" {
+		t.Errorf("unexpected graceful-restart diff: %s\n", diff)
+	}
+
+	if diff := cmp.Diff(ret.UseMultiplePaths.Ebgp.AllowMultipleAs, &flagTrue); diff != "" {
+		t.Errorf("unexpected allow-multiple-as diff: %s\n", diff)
+	}
+
+	wantSafiMultiplePaths := &openconfig.NetworkInstance_Protocol_Bgp_Global_AfiSafi_UseMultiplePaths{
+		Enabled: &flagTrue,
+		Ebgp:    &openconfig.NetworkInstance_Protocol_Bgp_Global_AfiSafi_UseMultiplePaths_Ebgp{MaximumPaths: &maximumPaths8},
+		Ibgp:    &openconfig.NetworkInstance_Protocol_Bgp_Global_AfiSafi_UseMultiplePaths_Ibgp{MaximumPaths: &maximumPaths8},
+	}
+	if diff := cmp.Diff(ret.AfiSafi[openconfig.BgpTypes_AFI_SAFI_TYPE_IPV4_UNICAST].UseMultiplePaths, wantSafiMultiplePaths); diff != "" {
+		t.Errorf("unexpected AFI/SAFI use-multiple-paths diff: %s\n", diff)
+	}
+
+	globalConfig.ConfederationID = nil
+	if _, err := bgp.BGPGlobalToOpenconfig(&globalConfig); err == nil {
+		t.Errorf("expected an error for confederation members without identifier")
+	}
+}
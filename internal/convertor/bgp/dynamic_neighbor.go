@@ -0,0 +1,68 @@
+package bgp
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/bgp"
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/common"
+	"github.com/criteo/data-aggregation-api/internal/model/openconfig"
+)
+
+// checkRemoteASN ensures the allowed remote ASN is the one of the peer-group, which is used by the dynamic neighbors.
+// ASN ranges cannot be rendered, they are rejected at ingestion.
+func checkRemoteASN(remoteASN *common.ASN, peerGroup *bgp.PeerGroup) error {
+	if remoteASN == nil || remoteASN.Number == nil {
+		return nil
+	}
+
+	if peerGroup.RemoteAsn == nil || peerGroup.RemoteAsn.Number == nil || *peerGroup.RemoteAsn.Number != *remoteASN.Number {
+		return fmt.Errorf("peer-group %s must have remote ASN %d", peerGroup.Name, *remoteASN.Number)
+	}
+
+	return nil
+}
+
+// DynamicNeighborsToOpenconfig converts precomputed dynamic neighbor prefixes to OpenConfig.
+// Each prefix must reference a peer-group defined on the same device.
+// OpenConfig path: /network-instances/network-instance/protocols/protocol/bgp/global/dynamic-neighbor-prefixes/.
+func DynamicNeighborsToOpenconfig(dynamicNeighbors []*bgp.DynamicNeighbor, peerGroups []*bgp.PeerGroup) (map[string]*openconfig.NetworkInstance_Protocol_Bgp_Global_DynamicNeighborPrefix, error) {
+	var groups = make(map[string]*bgp.PeerGroup, len(peerGroups))
+	for _, peerGroup := range peerGroups {
+		groups[peerGroup.Name] = peerGroup
+	}
+
+	var prefixes = make(map[string]*openconfig.NetworkInstance_Protocol_Bgp_Global_DynamicNeighborPrefix)
+	for _, dynamicNeighbor := range dynamicNeighbors {
+		prefixLength := 8 * net.IPv6len
+		if dynamicNeighbor.Prefix.IP.To4() != nil {
+			prefixLength = 8 * net.IPv4len
+		}
+		network := net.IPNet{IP: dynamicNeighbor.Prefix.IP.Mask(net.CIDRMask(dynamicNeighbor.Prefix.Netmask, prefixLength)), Mask: net.CIDRMask(dynamicNeighbor.Prefix.Netmask, prefixLength)}
+		prefix := network.String()
+
+		if !network.IP.Equal(dynamicNeighbor.Prefix.IP) {
+			return nil, fmt.Errorf("dynamic neighbor prefix %s has host bits set", dynamicNeighbor.Prefix.String())
+		}
+
+		if _, ok := prefixes[prefix]; ok {
+			return nil, fmt.Errorf("dynamic neighbor prefix %s is defined more than once", prefix)
+		}
+
+		peerGroup, ok := groups[dynamicNeighbor.PeerGroup.Name]
+		if !ok {
+			return nil, fmt.Errorf("dynamic neighbor prefix %s references unknown peer-group %s", prefix, dynamicNeighbor.PeerGroup.Name)
+		}
+
+		if err := checkRemoteASN(dynamicNeighbor.RemoteAsnMin, peerGroup); err != nil {
+			return nil, fmt.Errorf("invalid dynamic neighbor prefix %s: %w", prefix, err)
+		}
+
+		prefixes[prefix] = &openconfig.NetworkInstance_Protocol_Bgp_Global_DynamicNeighborPrefix{
+			Prefix:    &prefix,
+			PeerGroup: &dynamicNeighbor.PeerGroup.Name,
+		}
+	}
+
+	return prefixes, nil
+}
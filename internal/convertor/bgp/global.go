@@ -1,6 +1,7 @@
 package bgp
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/criteo/data-aggregation-api/internal/model/cmdb/bgp"
@@ -13,6 +14,63 @@ var safiToOCSafi = map[bgp.AfiSafiChoice]openconfig.E_BgpTypes_AFI_SAFI_TYPE{
 	bgp.L2vpnEvpn:   openconfig.BgpTypes_AFI_SAFI_TYPE_L2VPN_EVPN,
 }
 
+// getConfederation returns the confederation configuration, if any.
+// Members are the sub-AS peers of the confederation, so they require a confederation identifier.
+func getConfederation(bgpGlobal *bgp.BGPGlobal) (*openconfig.NetworkInstance_Protocol_Bgp_Global_Confederation, error) {
+	if bgpGlobal.ConfederationID == nil || bgpGlobal.ConfederationID.Number == nil {
+		if len(bgpGlobal.ConfederationMembers) > 0 {
+			return nil, errors.New("confederation members are defined without a confederation identifier")
+		}
+		return nil, nil
+	}
+
+	confederation := &openconfig.NetworkInstance_Protocol_Bgp_Global_Confederation{
+		Identifier: bgpGlobal.ConfederationID.Number,
+	}
+
+	for _, member := range bgpGlobal.ConfederationMembers {
+		if member.Number == nil {
+			return nil, errors.New("confederation member without ASN")
+		}
+		if *member.Number == *bgpGlobal.ConfederationID.Number {
+			return nil, fmt.Errorf("confederation identifier %d cannot be a confederation member", *member.Number)
+		}
+		confederation.MemberAs = append(confederation.MemberAs, *member.Number)
+	}
+
+	return confederation, nil
+}
+
+// getRouteSelectionOptions returns the best-path tuning, only when at least one option is enabled.
+func getRouteSelectionOptions(bgpGlobal *bgp.BGPGlobal) *openconfig.NetworkInstance_Protocol_Bgp_Global_RouteSelectionOptions {
+	if !bgpGlobal.AlwaysCompareMed && !bgpGlobal.IgnoreAsPathLength && !bgpGlobal.ExternalCompareRouterID {
+		return nil
+	}
+
+	return &openconfig.NetworkInstance_Protocol_Bgp_Global_RouteSelectionOptions{
+		AlwaysCompareMed:        &bgpGlobal.AlwaysCompareMed,
+		IgnoreAsPathLength:      &bgpGlobal.IgnoreAsPathLength,
+		ExternalCompareRouterId: &bgpGlobal.ExternalCompareRouterID,
+	}
+}
+
+// getAfiSafiMultiplePaths returns the per-AFI/SAFI ECMP configuration, overriding the global one.
+func getAfiSafiMultiplePaths(safi *bgp.GlobalAfiSafi) *openconfig.NetworkInstance_Protocol_Bgp_Global_AfiSafi_UseMultiplePaths {
+	if safi.EcmpEnabled == nil && safi.EcmpMaximumPaths == nil {
+		return nil
+	}
+
+	return &openconfig.NetworkInstance_Protocol_Bgp_Global_AfiSafi_UseMultiplePaths{
+		Enabled: safi.EcmpEnabled,
+		Ebgp: &openconfig.NetworkInstance_Protocol_Bgp_Global_AfiSafi_UseMultiplePaths_Ebgp{
+			MaximumPaths: safi.EcmpMaximumPaths,
+		},
+		Ibgp: &openconfig.NetworkInstance_Protocol_Bgp_Global_AfiSafi_UseMultiplePaths_Ibgp{
+			MaximumPaths: safi.EcmpMaximumPaths,
+		},
+	}
+}
+
 // BGPGlobalToOpenconfig converts precomputed prefix-lists to OpenConfig.
 // OpenConfig path: /network-instances/network-instance/protocols/protocol/bgp/global/.
 func BGPGlobalToOpenconfig(bgpGlobal *bgp.BGPGlobal) (*openconfig.NetworkInstance_Protocol_Bgp_Global, error) {
@@ -27,7 +85,10 @@ func BGPGlobalToOpenconfig(bgpGlobal *bgp.BGPGlobal) (*openconfig.NetworkInstanc
 		if !ok {
 			return nil, fmt.Errorf("unsupported SAFI: %s", safi.Name)
 		}
-		newSafi := &openconfig.NetworkInstance_Protocol_Bgp_Global_AfiSafi{AfiSafiName: safiName}
+		newSafi := &openconfig.NetworkInstance_Protocol_Bgp_Global_AfiSafi{
+			AfiSafiName:      safiName,
+			UseMultiplePaths: getAfiSafiMultiplePaths(safi),
+		}
 
 		if len(safi.Aggregates) > 0 {
 			aggregates := []string{}
@@ -55,8 +116,9 @@ func BGPGlobalToOpenconfig(bgpGlobal *bgp.BGPGlobal) (*openconfig.NetworkInstanc
 			InternalRouteDistance: bgpGlobal.IBGPAdministrativeDistance,
 		},
 		GracefulRestart: &openconfig.NetworkInstance_Protocol_Bgp_Global_GracefulRestart{
-			Enabled:     bgpGlobal.GracefulRestartEnabled,
-			RestartTime: bgpGlobal.GracefulRestartTime,
+			Enabled:         bgpGlobal.GracefulRestartEnabled,
+			RestartTime:     bgpGlobal.GracefulRestartTime,
+			StaleRoutesTime: bgpGlobal.GracefulRestartStaleRoutesTime,
 		},
 		UseMultiplePaths: &openconfig.NetworkInstance_Protocol_Bgp_Global_UseMultiplePaths{
 			Enabled: bgpGlobal.EcmpEnabled,
@@ -73,6 +135,22 @@ func BGPGlobalToOpenconfig(bgpGlobal *bgp.BGPGlobal) (*openconfig.NetworkInstanc
 		cfg.AfiSafi = safis
 	}
 
+	if bgpGlobal.GracefulRestartHelperOnly {
+		cfg.GracefulRestart.HelperOnly = &bgpGlobal.GracefulRestartHelperOnly
+	}
+
+	if bgpGlobal.EcmpAllowMultipleAs {
+		cfg.UseMultiplePaths.Ebgp.AllowMultipleAs = &bgpGlobal.EcmpAllowMultipleAs
+	}
+
+	cfg.RouteSelectionOptions = getRouteSelectionOptions(bgpGlobal)
+
+	confederation, err := getConfederation(bgpGlobal)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Confederation = confederation
+
 	if bgpGlobal.RouterID != "" {
 		cfg.RouterId = &bgpGlobal.RouterID
 	}
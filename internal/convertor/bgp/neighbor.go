@@ -1,11 +1,17 @@
 package bgp
 
 import (
+	"errors"
+	"fmt"
+
+	rpconvertors "github.com/criteo/data-aggregation-api/internal/convertor/routingpolicy"
 	"github.com/criteo/data-aggregation-api/internal/model/cmdb/bgp"
 	"github.com/criteo/data-aggregation-api/internal/model/openconfig"
 	"github.com/criteo/data-aggregation-api/internal/util"
 )
 
+const minimumHoldTime = 3
+
 // getBGPsides finds which side of the BGP session we are configuring.
 func getBGPsides(hostname string, session *bgp.Session) (*bgp.DeviceSession, *bgp.DeviceSession) {
 	var localInfo, remoteInfo bgp.DeviceSession
@@ -21,6 +27,119 @@ func getBGPsides(hostname string, session *bgp.Session) (*bgp.DeviceSession, *bg
 	return &localInfo, &remoteInfo
 }
 
+// isEBGP tells if the session is established between two different ASNs.
+func isEBGP(localInfo *bgp.DeviceSession, remoteInfo *bgp.DeviceSession) bool {
+	if localInfo.LocalAsn.Number == nil || remoteInfo.LocalAsn.Number == nil {
+		return false
+	}
+	return *localInfo.LocalAsn.Number != *remoteInfo.LocalAsn.Number
+}
+
+// enableExtendedNextHop allows IPv4 NLRI with IPv6 next-hops, required by sessions over IPv6 link-local addresses
+// and interface-based sessions (RFC 5549).
+func enableExtendedNextHop(safis map[openconfig.E_BgpTypes_AFI_SAFI_TYPE]*openconfig.NetworkInstance_Protocol_Bgp_Neighbor_AfiSafi) {
+	enabled := true
+	if safi, ok := safis[openconfig.BgpTypes_AFI_SAFI_TYPE_IPV4_UNICAST]; ok && safi.Ipv4Unicast != nil {
+		safi.Ipv4Unicast.ExtendedNextHopEncoding = &enabled
+	}
+}
+
+// getTimers converts the session timers and checks they are consistent with each other.
+// OpenConfig path: /network-instances/network-instance/protocols/protocol/bgp/neighbors/neighbor/timers/.
+func getTimers(localInfo *bgp.DeviceSession) (*openconfig.NetworkInstance_Protocol_Bgp_Neighbor_Timers, error) {
+	if localInfo.DelayOpenTimer == 0 && localInfo.HoldTime == 0 && localInfo.KeepaliveInterval == 0 && localInfo.ConnectRetry == 0 {
+		return nil, nil
+	}
+
+	// a hold time of 0 disables the keepalive mechanism, otherwise RFC 4271 requires at least 3 seconds
+	if localInfo.HoldTime > 0 && localInfo.HoldTime < minimumHoldTime {
+		return nil, fmt.Errorf("hold_time must be 0 or at least %d seconds, got %d", minimumHoldTime, localInfo.HoldTime)
+	}
+
+	if localInfo.KeepaliveInterval > 0 {
+		if localInfo.HoldTime == 0 {
+			return nil, errors.New("keepalive_interval cannot be set without hold_time")
+		}
+		if localInfo.KeepaliveInterval >= localInfo.HoldTime {
+			return nil, fmt.Errorf("keepalive_interval (%d) must be lower than hold_time (%d)", localInfo.KeepaliveInterval, localInfo.HoldTime)
+		}
+	}
+
+	timers := &openconfig.NetworkInstance_Protocol_Bgp_Neighbor_Timers{}
+	if localInfo.DelayOpenTimer > 0 {
+		timers.DelayOpenTimer = &localInfo.DelayOpenTimer
+	}
+	if localInfo.HoldTime > 0 {
+		timers.HoldTime = &localInfo.HoldTime
+	}
+	if localInfo.KeepaliveInterval > 0 {
+		timers.KeepaliveInterval = &localInfo.KeepaliveInterval
+	}
+	if localInfo.ConnectRetry > 0 {
+		timers.ConnectRetry = &localInfo.ConnectRetry
+	}
+
+	return timers, nil
+}
+
+// getTransport converts the session transport options.
+// Interface-based sessions are bound to the local interface through the transport local address.
+// OpenConfig path: /network-instances/network-instance/protocols/protocol/bgp/neighbors/neighbor/transport/.
+func getTransport(localInfo *bgp.DeviceSession, remoteInfo *bgp.DeviceSession) (*openconfig.NetworkInstance_Protocol_Bgp_Neighbor_Transport, error) {
+	interfaceBased := bgp.IsInterfaceBased(localInfo, remoteInfo)
+	if localInfo.UpdateSource == "" && !localInfo.PassiveMode && !interfaceBased {
+		return nil, nil
+	}
+
+	transport := &openconfig.NetworkInstance_Protocol_Bgp_Neighbor_Transport{}
+	if interfaceBased {
+		if localInfo.UpdateSource != "" {
+			return nil, errors.New("update_source cannot be set on interface-based sessions")
+		}
+		interfaceName := localInfo.InterfaceName()
+		transport.LocalAddress = &interfaceName
+	}
+	if localInfo.UpdateSource != "" {
+		transport.LocalAddress = &localInfo.UpdateSource
+	}
+	if localInfo.PassiveMode {
+		transport.PassiveMode = &localInfo.PassiveMode
+	}
+
+	return transport, nil
+}
+
+// getEBGPMultihop converts the eBGP multihop TTL, which only makes sense for eBGP sessions.
+// OpenConfig path: /network-instances/network-instance/protocols/protocol/bgp/neighbors/neighbor/ebgp-multihop/.
+func getEBGPMultihop(localInfo *bgp.DeviceSession, remoteInfo *bgp.DeviceSession) (*openconfig.NetworkInstance_Protocol_Bgp_Neighbor_EbgpMultihop, error) {
+	if localInfo.EBGPMultihopTTL == 0 {
+		return nil, nil
+	}
+
+	if !isEBGP(localInfo, remoteInfo) {
+		return nil, errors.New("ebgp_multihop_ttl is only supported on eBGP sessions")
+	}
+
+	enabled := true
+	return &openconfig.NetworkInstance_Protocol_Bgp_Neighbor_EbgpMultihop{
+		Enabled:     &enabled,
+		MultihopTtl: &localInfo.EBGPMultihopTTL,
+	}, nil
+}
+
+// getBFD converts the BFD option of the session.
+// OpenConfig only allows enabling BFD on the neighbor, its timers are part of the BFD interface configuration.
+// OpenConfig path: /network-instances/network-instance/protocols/protocol/bgp/neighbors/neighbor/enable-bfd/.
+func getBFD(localInfo *bgp.DeviceSession) *openconfig.NetworkInstance_Protocol_Bgp_Neighbor_EnableBfd {
+	if localInfo.BFD == nil {
+		return nil
+	}
+
+	return &openconfig.NetworkInstance_Protocol_Bgp_Neighbor_EnableBfd{
+		Enabled: &localInfo.BFD.Enabled,
+	}
+}
+
 // NeighborsToOpenconfig converts all BGP neighbors to OpenConfig.
 // OpenConfig path: /network-instances/network-instance/protocols/protocol/bgp/neighbors/.
 func NeighborsToOpenconfig(hostname string, sessions []*bgp.Session) (map[string]*openconfig.NetworkInstance_Protocol_Bgp_Neighbor, error) {
@@ -29,13 +148,20 @@ func NeighborsToOpenconfig(hostname string, sessions []*bgp.Session) (map[string
 	for _, session := range sessions {
 		localInfo, remoteInfo := getBGPsides(hostname, session)
 
-		neighborAddress := remoteInfo.LocalAddress.Address.IP.String()
+		neighborAddress, err := bgp.NeighborKey(localInfo, remoteInfo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid neighbor on session to %s: %w", remoteInfo.Device.Name, err)
+		}
 
 		policy := openconfig.NetworkInstance_Protocol_Bgp_Neighbor_ApplyPolicy{}
 		if localInfo.RoutePolicyIn != nil {
 			policy.ImportPolicy = util.AppendIfDefined(policy.ImportPolicy, localInfo.RoutePolicyIn.Name)
 		}
 
+		if localInfo.NextHopSelf {
+			policy.ExportPolicy = append(policy.ExportPolicy, rpconvertors.NextHopSelfPolicyName)
+		}
+
 		if localInfo.RoutePolicyOut != nil {
 			policy.ExportPolicy = util.AppendIfDefined(policy.ExportPolicy, localInfo.RoutePolicyOut.Name)
 		}
@@ -44,6 +170,9 @@ func NeighborsToOpenconfig(hostname string, sessions []*bgp.Session) (map[string
 		if err != nil {
 			return nil, err
 		}
+		if remoteInfo.LocalAddress.Address.IsLinkLocal() || bgp.IsInterfaceBased(localInfo, remoteInfo) {
+			enableExtendedNextHop(safis)
+		}
 
 		neighbor := openconfig.NetworkInstance_Protocol_Bgp_Neighbor{
 			NeighborAddress: &neighborAddress,
@@ -54,9 +183,30 @@ func NeighborsToOpenconfig(hostname string, sessions []*bgp.Session) (map[string
 			LocalAs:         localInfo.LocalAsn.Number,
 			AuthPassword:    &session.Password,
 			Description:     &localInfo.Description,
+			EnableBfd:       getBFD(localInfo),
+			AsPathOptions:   getNeighborAsPathOptions(localInfo),
+			RemovePrivateAs: removePrivateAsMap[localInfo.RemovePrivateAs],
+			SendCommunity:   sendCommunityMap[localInfo.SendCommunity],
+		}
+
+		if neighbor.Transport, err = getTransport(localInfo, remoteInfo); err != nil {
+			return nil, fmt.Errorf("invalid transport for neighbor %s: %w", neighborAddress, err)
 		}
-		if localInfo.DelayOpenTimer > 0 {
-			neighbor.Timers = &openconfig.NetworkInstance_Protocol_Bgp_Neighbor_Timers{DelayOpenTimer: &localInfo.DelayOpenTimer}
+
+		if neighbor.Timers, err = getTimers(localInfo); err != nil {
+			return nil, fmt.Errorf("invalid timers for neighbor %s: %w", neighborAddress, err)
+		}
+
+		if neighbor.EbgpMultihop, err = getEBGPMultihop(localInfo, remoteInfo); err != nil {
+			return nil, fmt.Errorf("invalid eBGP multihop for neighbor %s: %w", neighborAddress, err)
+		}
+
+		if neighbor.RouteReflector, err = getNeighborRouteReflector(localInfo, remoteInfo); err != nil {
+			return nil, fmt.Errorf("invalid route-reflector for neighbor %s: %w", neighborAddress, err)
+		}
+
+		if err := setNeighborAddPaths(localInfo.AddPaths, safis); err != nil {
+			return nil, fmt.Errorf("invalid add-paths for neighbor %s: %w", neighborAddress, err)
 		}
 
 		if localInfo.PeerGroup != nil && localInfo.PeerGroup.Name != "" {
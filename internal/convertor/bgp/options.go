@@ -0,0 +1,213 @@
+package bgp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/bgp"
+	"github.com/criteo/data-aggregation-api/internal/model/openconfig"
+)
+
+var removePrivateAsMap = map[bgp.RemovePrivateAsChoice]openconfig.E_BgpTypes_RemovePrivateAsOption{
+	bgp.RemovePrivateAsRemoveAll:  openconfig.BgpTypes_RemovePrivateAsOption_PRIVATE_AS_REMOVE_ALL,
+	bgp.RemovePrivateAsReplaceAll: openconfig.BgpTypes_RemovePrivateAsOption_PRIVATE_AS_REPLACE_ALL,
+	bgp.RemovePrivateAsNone:       openconfig.BgpTypes_RemovePrivateAsOption_UNSET,
+}
+
+var sendCommunityMap = map[bgp.SendCommunityChoice]openconfig.E_BgpTypes_CommunityType{
+	bgp.SendCommunityStandard: openconfig.BgpTypes_CommunityType_STANDARD,
+	bgp.SendCommunityExtended: openconfig.BgpTypes_CommunityType_EXTENDED,
+	bgp.SendCommunityBoth:     openconfig.BgpTypes_CommunityType_BOTH,
+	bgp.SendCommunityNone:     openconfig.BgpTypes_CommunityType_NONE,
+	bgp.SendCommunityUnset:    openconfig.BgpTypes_CommunityType_UNSET,
+}
+
+var errRouteReflectorClientEBGP = errors.New("route_reflector_client is only supported on iBGP sessions")
+var errAddPathsWithoutAfiSafi = errors.New("add_paths requires at least one AFI/SAFI")
+
+// clusterID is a route-reflector cluster ID, valid for both neighbors and peer-groups.
+type clusterID interface {
+	openconfig.NetworkInstance_Protocol_Bgp_Neighbor_RouteReflector_RouteReflectorClusterId_Union
+	openconfig.NetworkInstance_Protocol_Bgp_PeerGroup_RouteReflector_RouteReflectorClusterId_Union
+}
+
+// getClusterID parses a route-reflector cluster ID, which is either an IPv4 address or a 32-bit integer.
+func getClusterID(id string) (clusterID, error) {
+	if ip := net.ParseIP(id); ip != nil && ip.To4() != nil {
+		return openconfig.UnionString(id), nil
+	}
+	if number, err := strconv.ParseUint(id, 10, 32); err == nil {
+		return openconfig.UnionUint32(uint32(number)), nil
+	}
+	return nil, fmt.Errorf("invalid cluster ID '%s': must be an IPv4 address or a 32-bit integer", id)
+}
+
+// setClusterID configures the cluster ID of the BGP global configuration on the route-reflector clients.
+// OpenConfig has no global cluster ID: it is set on each neighbor and peer-group.
+func setClusterID(bgpGlobal *bgp.BGPGlobal, neighbors map[string]*openconfig.NetworkInstance_Protocol_Bgp_Neighbor, groups map[string]*openconfig.NetworkInstance_Protocol_Bgp_PeerGroup) error {
+	if bgpGlobal == nil || bgpGlobal.ClusterID == "" {
+		return nil
+	}
+
+	id, err := getClusterID(bgpGlobal.ClusterID)
+	if err != nil {
+		return err
+	}
+
+	for _, neighbor := range neighbors {
+		if neighbor.RouteReflector != nil {
+			neighbor.RouteReflector.RouteReflectorClusterId = id
+		}
+	}
+	for _, group := range groups {
+		if group.RouteReflector != nil {
+			group.RouteReflector.RouteReflectorClusterId = id
+		}
+	}
+
+	return nil
+}
+
+func validateAddPaths(addPaths *bgp.AddPaths) error {
+	if addPaths != nil && addPaths.SendMax > 0 && !addPaths.Send {
+		return errors.New("add_paths send_max requires send to be enabled")
+	}
+	return nil
+}
+
+// getNeighborRouteReflector converts the route-reflector client flag of a neighbor.
+// OpenConfig path: /network-instances/network-instance/protocols/protocol/bgp/neighbors/neighbor/route-reflector/.
+func getNeighborRouteReflector(localInfo *bgp.DeviceSession, remoteInfo *bgp.DeviceSession) (*openconfig.NetworkInstance_Protocol_Bgp_Neighbor_RouteReflector, error) {
+	if !localInfo.RouteReflectorClient {
+		return nil, nil
+	}
+	if isEBGP(localInfo, remoteInfo) {
+		return nil, errRouteReflectorClientEBGP
+	}
+
+	return &openconfig.NetworkInstance_Protocol_Bgp_Neighbor_RouteReflector{
+		RouteReflectorClient: &localInfo.RouteReflectorClient,
+	}, nil
+}
+
+// setNeighborAddPaths enables the add-paths capability on every AFI/SAFI of a neighbor.
+// OpenConfig path: /network-instances/network-instance/protocols/protocol/bgp/neighbors/neighbor/afi-safis/afi-safi/add-paths/.
+func setNeighborAddPaths(addPaths *bgp.AddPaths, safis map[openconfig.E_BgpTypes_AFI_SAFI_TYPE]*openconfig.NetworkInstance_Protocol_Bgp_Neighbor_AfiSafi) error {
+	if addPaths == nil {
+		return nil
+	}
+	if err := validateAddPaths(addPaths); err != nil {
+		return err
+	}
+	if len(safis) == 0 {
+		return errAddPathsWithoutAfiSafi
+	}
+
+	for _, safi := range safis {
+		safi.AddPaths = &openconfig.NetworkInstance_Protocol_Bgp_Neighbor_AfiSafi_AddPaths{
+			Send:    &addPaths.Send,
+			Receive: &addPaths.Receive,
+		}
+		if addPaths.SendMax > 0 {
+			safi.AddPaths.SendMax = &addPaths.SendMax
+		}
+	}
+
+	return nil
+}
+
+// getNeighborAsPathOptions converts the AS-path options of a neighbor.
+// OpenConfig path: /network-instances/network-instance/protocols/protocol/bgp/neighbors/neighbor/as-path-options/.
+func getNeighborAsPathOptions(localInfo *bgp.DeviceSession) *openconfig.NetworkInstance_Protocol_Bgp_Neighbor_AsPathOptions {
+	if localInfo.AllowOwnAs == 0 {
+		return nil
+	}
+
+	return &openconfig.NetworkInstance_Protocol_Bgp_Neighbor_AsPathOptions{
+		AllowOwnAs: &localInfo.AllowOwnAs,
+	}
+}
+
+// getPeerGroupRouteReflector converts the route-reflector client flag of a peer-group.
+// OpenConfig path: /network-instances/network-instance/protocols/protocol/bgp/peer-groups/peer-group/route-reflector/.
+func getPeerGroupRouteReflector(peerGroup *bgp.PeerGroup) (*openconfig.NetworkInstance_Protocol_Bgp_PeerGroup_RouteReflector, error) {
+	if !peerGroup.RouteReflectorClient {
+		return nil, nil
+	}
+	if peerGroup.LocalAsn != nil && peerGroup.RemoteAsn != nil &&
+		peerGroup.LocalAsn.Number != nil && peerGroup.RemoteAsn.Number != nil &&
+		*peerGroup.LocalAsn.Number != *peerGroup.RemoteAsn.Number {
+		return nil, errRouteReflectorClientEBGP
+	}
+
+	return &openconfig.NetworkInstance_Protocol_Bgp_PeerGroup_RouteReflector{
+		RouteReflectorClient: &peerGroup.RouteReflectorClient,
+	}, nil
+}
+
+// getPeerGroupAfiSafis returns the add-paths capability of a peer-group for each AFI/SAFI of the BGP global configuration,
+// as peer-groups do not define their own AFI/SAFIs.
+// OpenConfig path: /network-instances/network-instance/protocols/protocol/bgp/peer-groups/peer-group/afi-safis/afi-safi/add-paths/.
+func getPeerGroupAfiSafis(addPaths *bgp.AddPaths, bgpGlobal *bgp.BGPGlobal) (map[openconfig.E_BgpTypes_AFI_SAFI_TYPE]*openconfig.NetworkInstance_Protocol_Bgp_PeerGroup_AfiSafi, error) {
+	if addPaths == nil {
+		return nil, nil
+	}
+	if err := validateAddPaths(addPaths); err != nil {
+		return nil, err
+	}
+	if bgpGlobal == nil || len(bgpGlobal.AfiSafis) == 0 {
+		return nil, errAddPathsWithoutAfiSafi
+	}
+
+	safis := make(map[openconfig.E_BgpTypes_AFI_SAFI_TYPE]*openconfig.NetworkInstance_Protocol_Bgp_PeerGroup_AfiSafi)
+	for _, safi := range bgpGlobal.AfiSafis {
+		safiName, ok := safiToOCSafi[safi.Name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported SAFI: %s", safi.Name)
+		}
+
+		ocAddPaths := &openconfig.NetworkInstance_Protocol_Bgp_PeerGroup_AfiSafi_AddPaths{
+			Send:    &addPaths.Send,
+			Receive: &addPaths.Receive,
+		}
+		if addPaths.SendMax > 0 {
+			ocAddPaths.SendMax = &addPaths.SendMax
+		}
+
+		safis[safiName] = &openconfig.NetworkInstance_Protocol_Bgp_PeerGroup_AfiSafi{
+			AfiSafiName: safiName,
+			AddPaths:    ocAddPaths,
+		}
+	}
+
+	return safis, nil
+}
+
+// getPeerGroupAsPathOptions converts the AS-path options of a peer-group.
+// OpenConfig path: /network-instances/network-instance/protocols/protocol/bgp/peer-groups/peer-group/as-path-options/.
+func getPeerGroupAsPathOptions(peerGroup *bgp.PeerGroup) *openconfig.NetworkInstance_Protocol_Bgp_PeerGroup_AsPathOptions {
+	if peerGroup.AllowOwnAs == 0 {
+		return nil
+	}
+
+	return &openconfig.NetworkInstance_Protocol_Bgp_PeerGroup_AsPathOptions{
+		AllowOwnAs: &peerGroup.AllowOwnAs,
+	}
+}
+
+// UsesNextHopSelf tells if at least one neighbor or peer-group of the device requires the next-hop-self policy.
+func UsesNextHopSelf(hostname string, sessions []*bgp.Session, peerGroups []*bgp.PeerGroup) bool {
+	for _, session := range sessions {
+		if localInfo, _ := getBGPsides(hostname, session); localInfo.NextHopSelf {
+			return true
+		}
+	}
+	for _, peerGroup := range peerGroups {
+		if peerGroup.NextHopSelf {
+			return true
+		}
+	}
+	return false
+}
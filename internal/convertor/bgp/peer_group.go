@@ -1,6 +1,9 @@
 package bgp
 
 import (
+	"fmt"
+
+	rpconvertors "github.com/criteo/data-aggregation-api/internal/convertor/routingpolicy"
 	"github.com/criteo/data-aggregation-api/internal/model/cmdb/bgp"
 	"github.com/criteo/data-aggregation-api/internal/model/openconfig"
 	"github.com/criteo/data-aggregation-api/internal/util"
@@ -11,7 +14,7 @@ import (
 //
 // Deprecated: peer-groups will be removed from the CMDB in future releases.
 // You should migrate to configuration without using peer-groups.
-func PeerGroupsToOpenconfig(peerGroups []*bgp.PeerGroup) map[string]*openconfig.NetworkInstance_Protocol_Bgp_PeerGroup {
+func PeerGroupsToOpenconfig(peerGroups []*bgp.PeerGroup, bgpGlobal *bgp.BGPGlobal) (map[string]*openconfig.NetworkInstance_Protocol_Bgp_PeerGroup, error) {
 	var groups = make(map[string]*openconfig.NetworkInstance_Protocol_Bgp_PeerGroup)
 
 	for _, peerGroup := range peerGroups {
@@ -19,14 +22,28 @@ func PeerGroupsToOpenconfig(peerGroups []*bgp.PeerGroup) map[string]*openconfig.
 		if peerGroup.RoutePolicyIn != nil {
 			policy.ImportPolicy = util.AppendIfDefined(policy.ImportPolicy, peerGroup.RoutePolicyIn.Name)
 		}
+		if peerGroup.NextHopSelf {
+			policy.ExportPolicy = append(policy.ExportPolicy, rpconvertors.NextHopSelfPolicyName)
+		}
 		if peerGroup.RoutePolicyOut != nil {
 			policy.ExportPolicy = util.AppendIfDefined(policy.ExportPolicy, peerGroup.RoutePolicyOut.Name)
 		}
 
 		group := openconfig.NetworkInstance_Protocol_Bgp_PeerGroup{
-			PeerGroupName: &peerGroup.Name,
-			Description:   &peerGroup.Description,
-			ApplyPolicy:   &policy,
+			PeerGroupName:   &peerGroup.Name,
+			Description:     &peerGroup.Description,
+			ApplyPolicy:     &policy,
+			AsPathOptions:   getPeerGroupAsPathOptions(peerGroup),
+			RemovePrivateAs: removePrivateAsMap[peerGroup.RemovePrivateAs],
+			SendCommunity:   sendCommunityMap[peerGroup.SendCommunity],
+		}
+
+		var err error
+		if group.RouteReflector, err = getPeerGroupRouteReflector(peerGroup); err != nil {
+			return nil, fmt.Errorf("invalid route-reflector for peer-group %s: %w", peerGroup.Name, err)
+		}
+		if group.AfiSafi, err = getPeerGroupAfiSafis(peerGroup.AddPaths, bgpGlobal); err != nil {
+			return nil, fmt.Errorf("invalid add-paths for peer-group %s: %w", peerGroup.Name, err)
 		}
 
 		if peerGroup.LocalAsn != nil {
@@ -39,5 +56,5 @@ func PeerGroupsToOpenconfig(peerGroups []*bgp.PeerGroup) map[string]*openconfig.
 		groups[peerGroup.Name] = &group
 	}
 
-	return groups
+	return groups, nil
 }
@@ -2,6 +2,8 @@ package device
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"sync"
@@ -9,6 +11,7 @@ import (
 	bgpconvertors "github.com/criteo/data-aggregation-api/internal/convertor/bgp"
 	rpconvertors "github.com/criteo/data-aggregation-api/internal/convertor/routingpolicy"
 	snmpconvertors "github.com/criteo/data-aggregation-api/internal/convertor/snmp"
+	"github.com/criteo/data-aggregation-api/internal/ingestor/cmdb"
 	"github.com/criteo/data-aggregation-api/internal/ingestor/repository"
 	"github.com/criteo/data-aggregation-api/internal/model/cmdb/bgp"
 	"github.com/criteo/data-aggregation-api/internal/model/cmdb/routingpolicy"
@@ -29,20 +32,33 @@ type GeneratedConfig struct {
 	JSONIETF       string
 	Openconfig     *openconfig.Device
 	JSONOpenConfig string
+	// Hash identifies the generated configuration, it changes only when the configuration does.
+	Hash string
+}
+
+// computeHash returns the SHA-256 of the OpenConfig and IETF JSON configurations.
+func (c *GeneratedConfig) computeHash() string {
+	hash := sha256.New()
+	hash.Write([]byte(c.JSONOpenConfig))
+	hash.Write([]byte{0})
+	hash.Write([]byte(c.JSONIETF))
+	return hex.EncodeToString(hash.Sum(nil))
 }
 
 type Device struct {
-	mutex           *sync.Mutex
-	Dcim            *dcim.NetworkDevice
-	Config          *GeneratedConfig
-	BGPGlobalConfig *bgp.BGPGlobal
-	SNMP            *snmp.SNMP
-	Sessions        []*bgp.Session
-	PeerGroups      []*bgp.PeerGroup
-	PrefixLists     []*routingpolicy.PrefixList
-	CommunityLists  []*routingpolicy.CommunityList
-	RoutePolicies   []*routingpolicy.RoutePolicy
-	AFKEnabled      bool
+	mutex            *sync.Mutex
+	Dcim             *dcim.NetworkDevice
+	Config           *GeneratedConfig
+	BGPGlobalConfig  *bgp.BGPGlobal
+	SNMP             *snmp.SNMP
+	Sessions         []*bgp.Session
+	PeerGroups       []*bgp.PeerGroup
+	DynamicNeighbors []*bgp.DynamicNeighbor
+	PrefixLists      []*routingpolicy.PrefixList
+	CommunityLists   []*routingpolicy.CommunityList
+	ASPathLists      []*routingpolicy.ASPathList
+	RoutePolicies    []*routingpolicy.RoutePolicy
+	AFKEnabled       bool
 }
 
 // isAFKenabled checks if the device contains the AFKEnabledTag.
@@ -83,6 +99,8 @@ func NewDevice(dcimInfo *dcim.NetworkDevice, devicesData *repository.AssetsPerDe
 		log.Warn().Msgf("no peer-groups found for %s", dcimInfo.Hostname)
 	}
 
+	device.DynamicNeighbors = devicesData.DynamicNeighbors[dcimInfo.Hostname]
+
 	device.PrefixLists, ok = devicesData.PrefixLists[dcimInfo.Hostname]
 	if !ok {
 		return nil, fmt.Errorf("no prefix-lists found for %s", dcimInfo.Hostname)
@@ -93,11 +111,17 @@ func NewDevice(dcimInfo *dcim.NetworkDevice, devicesData *repository.AssetsPerDe
 		return nil, fmt.Errorf("no community-lists found for %s", dcimInfo.Hostname)
 	}
 
+	device.ASPathLists = devicesData.ASPathLists[dcimInfo.Hostname]
+
 	device.RoutePolicies, ok = devicesData.RoutePolicies[dcimInfo.Hostname]
 	if !ok {
 		return nil, fmt.Errorf("no route-policies found for %s", dcimInfo.Hostname)
 	}
 
+	if err := cmdb.CheckRoutePolicyCalls(device.RoutePolicies); err != nil {
+		return nil, fmt.Errorf("invalid route-policies for %s: %w", dcimInfo.Hostname, err)
+	}
+
 	device.SNMP, ok = devicesData.SNMP[dcimInfo.Hostname]
 	if !ok {
 		log.Warn().Msgf("no snmp found for %s", dcimInfo.Hostname)
@@ -112,16 +136,25 @@ func (d *Device) Generateconfigs() error {
 	defer d.mutex.Unlock()
 
 	// Generate sub-configs
-	bgpConfig, err := bgpconvertors.BGPToOpenconfig(d.Dcim.Hostname, d.BGPGlobalConfig, d.Sessions, d.PeerGroups)
+	bgpConfig, err := bgpconvertors.BGPToOpenconfig(d.Dcim.Hostname, d.BGPGlobalConfig, d.Sessions, d.PeerGroups, d.DynamicNeighbors)
 	if err != nil {
 		return fmt.Errorf("convert from BGP to OpenConfig failed: %w", err)
 	}
 
-	routingPolicyConfig, err := rpconvertors.RoutingPolicyToOpenconfig(d.PrefixLists, d.CommunityLists, d.RoutePolicies)
+	routingPolicyConfig, err := rpconvertors.RoutingPolicyToOpenconfig(d.PrefixLists, d.CommunityLists, d.ASPathLists, d.RoutePolicies)
 	if err != nil {
 		return fmt.Errorf("convert from Routing Policy to OpenConfig failed: %w", err)
 	}
 
+	if bgpconvertors.UsesNextHopSelf(d.Dcim.Hostname, d.Sessions, d.PeerGroups) {
+		if _, ok := routingPolicyConfig.PolicyDefinition[rpconvertors.NextHopSelfPolicyName]; ok {
+			return fmt.Errorf("route-policy %s is reserved for next-hop-self", rpconvertors.NextHopSelfPolicyName)
+		}
+		if routingPolicyConfig.PolicyDefinition[rpconvertors.NextHopSelfPolicyName], err = rpconvertors.NextHopSelfPolicy(); err != nil {
+			return fmt.Errorf("failed to generate the next-hop-self policy: %w", err)
+		}
+	}
+
 	// Assemble global configuration
 	bgpKey := openconfig.NetworkInstance_Protocol_Key{Identifier: openconfig.PolicyTypes_INSTALL_PROTOCOL_TYPE_BGP, Name: "bgp"}
 
@@ -184,6 +217,8 @@ func (d *Device) Generateconfigs() error {
 			return fmt.Errorf("failed to transform an ietf device specification (%s) into JSON using ygot: %w", d.Dcim.Hostname, err)
 		}
 	}
+
+	d.Config.Hash = d.Config.computeHash()
 	return nil
 }
 
@@ -0,0 +1,321 @@
+package device
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openconfig/ygot/ygot"
+	"github.com/rs/zerolog/log"
+
+	"github.com/criteo/data-aggregation-api/internal/model/ietf"
+	"github.com/criteo/data-aggregation-api/internal/model/openconfig"
+)
+
+const overlayExpiryInterval = time.Minute
+
+var ErrOverlayNotFound = errors.New("overlay not found")
+var ErrInvalidOverlay = errors.New("invalid overlay")
+
+type OverlayModel string
+
+const (
+	OpenConfigOverlay OverlayModel = "openconfig"
+	IETFOverlay       OverlayModel = "ietf"
+)
+
+// Overlay is a break-glass RFC7951 fragment merged into the generated configuration of a device until it expires.
+type Overlay struct {
+	ID        string          `json:"id"`
+	Hostname  string          `json:"hostname"`
+	Model     OverlayModel    `json:"model"`
+	Fragment  json.RawMessage `json:"fragment"`
+	Author    string          `json:"author"`
+	Reason    string          `json:"reason,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	ExpiresAt time.Time       `json:"expires_at"`
+	// Error is set when the overlay cannot be merged into the configuration served to the device.
+	Error string `json:"error,omitempty"`
+}
+
+func newOverlayID() string {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// orderStatements sorts the statements of the policies changed by an overlay by sequence.
+// Merging only appends the new statements, they would be evaluated after the generated default statement.
+// Statements are named by their sequence, so an overlay statement is inserted before the generated ones
+// with a greater sequence.
+func orderStatements(merged *openconfig.Device, fragment *openconfig.Device) error {
+	for name, fragmentPolicy := range fragment.GetRoutingPolicy().PolicyDefinition {
+		if fragmentPolicy.Statement == nil || fragmentPolicy.Statement.Len() == 0 {
+			continue
+		}
+		policy := merged.GetRoutingPolicy().GetPolicyDefinition(name)
+
+		sequences := make(map[string]int, policy.Statement.Len())
+		for _, statement := range policy.Statement.Keys() {
+			sequence, err := strconv.Atoi(statement)
+			if err != nil {
+				return fmt.Errorf("policy %s: statement %s must be named by its sequence", name, statement)
+			}
+			sequences[statement] = sequence
+		}
+
+		statements := policy.Statement.Values()
+		slices.SortStableFunc(statements, func(a, b *openconfig.RoutingPolicy_PolicyDefinition_Statement) int {
+			return sequences[a.GetName()] - sequences[b.GetName()]
+		})
+
+		var ordered openconfig.RoutingPolicy_PolicyDefinition_Statement_OrderedMap
+		for _, statement := range statements {
+			if err := ordered.Append(statement); err != nil {
+				return fmt.Errorf("policy %s: %w", name, err)
+			}
+		}
+		policy.Statement = &ordered
+	}
+	return nil
+}
+
+// withOverlays returns a copy of the configuration with the overlays merged, in order, using ygot merge semantics.
+// Leaves set by an overlay overwrite the generated ones, policy statements are ordered by sequence.
+// The merged configuration must pass ygot validation.
+func (c *GeneratedConfig) withOverlays(overlays []*Overlay) (*GeneratedConfig, error) {
+	merged := *c
+	openconfigChanged, ietfChanged := false, false
+
+	for _, overlay := range overlays {
+		switch overlay.Model {
+		case OpenConfigOverlay:
+			fragment := &openconfig.Device{}
+			if err := openconfig.Unmarshal(overlay.Fragment, fragment); err != nil {
+				return nil, fmt.Errorf("overlay %s is not valid OpenConfig: %w", overlay.ID, err)
+			}
+			base := merged.Openconfig
+			if base == nil {
+				base = &openconfig.Device{}
+			}
+			out, err := ygot.MergeStructs(base, fragment, &ygot.MergeOverwriteExistingFields{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to merge overlay %s: %w", overlay.ID, err)
+			}
+			merged.Openconfig = out.(*openconfig.Device)
+			if err := orderStatements(merged.Openconfig, fragment); err != nil {
+				return nil, fmt.Errorf("failed to merge overlay %s: %w", overlay.ID, err)
+			}
+			openconfigChanged = true
+		case IETFOverlay:
+			fragment := &ietf.Device{}
+			if err := ietf.Unmarshal(overlay.Fragment, fragment); err != nil {
+				return nil, fmt.Errorf("overlay %s is not valid IETF: %w", overlay.ID, err)
+			}
+			base := merged.IETF
+			if base == nil {
+				base = &ietf.Device{}
+			}
+			out, err := ygot.MergeStructs(base, fragment, &ygot.MergeOverwriteExistingFields{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to merge overlay %s: %w", overlay.ID, err)
+			}
+			merged.IETF = out.(*ietf.Device)
+			ietfChanged = true
+		default:
+			return nil, fmt.Errorf("overlay %s has an unknown model %q", overlay.ID, overlay.Model)
+		}
+	}
+
+	var err error
+	emitConfig := &ygot.EmitJSONConfig{Format: ygot.RFC7951, SkipValidation: false, Indent: "  "}
+	if openconfigChanged {
+		if merged.JSONOpenConfig, err = ygot.EmitJSON(merged.Openconfig, emitConfig); err != nil {
+			return nil, fmt.Errorf("merged OpenConfig configuration is invalid: %w", err)
+		}
+	}
+	if ietfChanged {
+		if merged.JSONIETF, err = ygot.EmitJSON(merged.IETF, emitConfig); err != nil {
+			return nil, fmt.Errorf("merged IETF configuration is invalid: %w", err)
+		}
+	}
+
+	merged.Hash = merged.computeHash()
+	return &merged, nil
+}
+
+// withOverlays returns a copy of the device serving its configuration merged with the overlays.
+func (d *Device) withOverlays(overlays []*Overlay) (*Device, error) {
+	if d == nil || d.Config == nil {
+		return nil, ErrBuidFailed
+	}
+	config, err := d.Config.withOverlays(overlays)
+	if err != nil {
+		return nil, err
+	}
+	merged := *d
+	merged.Config = config
+	return &merged, nil
+}
+
+// activeOverlays returns the overlays of a device not expired, oldest first.
+// It must be called with the mutex locked.
+func (s *SafeRepository) activeOverlays(hostname string, now time.Time) []*Overlay {
+	var overlays []*Overlay
+	for _, overlay := range s.overlays {
+		if overlay.Hostname == hostname && now.Before(overlay.ExpiresAt) {
+			overlays = append(overlays, overlay)
+		}
+	}
+	slices.SortFunc(overlays, func(a, b *Overlay) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return overlays
+}
+
+// AddOverlay attaches an overlay to a device. It is rejected if it cannot be merged into the configuration served to
+// the device. The ID and creation time are set by the repository.
+// This method is concurrent-safe.
+func (s *SafeRepository) AddOverlay(overlay *Overlay) error {
+	now := time.Now()
+	if overlay.Author == "" {
+		return fmt.Errorf("%w: author is required", ErrInvalidOverlay)
+	}
+	if !now.Before(overlay.ExpiresAt) {
+		return fmt.Errorf("%w: expiry time must be in the future", ErrInvalidOverlay)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	overlay.ID = newOverlayID()
+	overlay.CreatedAt = now
+	overlay.Error = ""
+
+	base, ok := s.baseDevices[overlay.Hostname]
+	if !ok {
+		return ErrNotFound
+	}
+	if _, err := base.withOverlays(append(s.activeOverlays(overlay.Hostname, now), overlay)); err != nil {
+		if errors.Is(err, ErrBuidFailed) {
+			return err
+		}
+		return fmt.Errorf("%w: %w", ErrInvalidOverlay, err)
+	}
+
+	s.overlays[overlay.ID] = overlay
+	s.resolve()
+	return nil
+}
+
+// RemoveOverlay detaches an overlay from a device.
+// This method is concurrent-safe.
+func (s *SafeRepository) RemoveOverlay(hostname string, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if overlay, ok := s.overlays[id]; !ok || overlay.Hostname != hostname {
+		return ErrOverlayNotFound
+	}
+	delete(s.overlays, id)
+	s.resolve()
+	return nil
+}
+
+// ListOverlays returns the active overlays of a device, or of all devices with the wildcard hostname, by creation time.
+// This method is concurrent-safe.
+func (s *SafeRepository) ListOverlays(hostname string) []*Overlay {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	overlays := []*Overlay{}
+	for _, overlay := range s.overlays {
+		if (hostname == Wildcard || overlay.Hostname == hostname) && now.Before(overlay.ExpiresAt) {
+			listed := *overlay
+			listed.Error = s.overlayErrors[overlay.ID]
+			overlays = append(overlays, &listed)
+		}
+	}
+	slices.SortFunc(overlays, func(a, b *Overlay) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return overlays
+}
+
+// ListOverlaysJSON returns the active overlays of a device, or of all devices with the wildcard hostname.
+func (s *SafeRepository) ListOverlaysJSON(hostname string) ([]byte, error) {
+	return json.Marshal(s.ListOverlays(hostname))
+}
+
+// ExpireOverlays drops the expired overlays, their devices are served the generated configuration again.
+// This method is concurrent-safe.
+func (s *SafeRepository) ExpireOverlays(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	expired := false
+	for id, overlay := range s.overlays {
+		if !now.Before(overlay.ExpiresAt) {
+			log.Info().Str("hostname", overlay.Hostname).Str("overlay", id).Str("author", overlay.Author).Msg("overlay expired")
+			delete(s.overlays, id)
+			expired = true
+		}
+	}
+	if expired {
+		s.resolve()
+	}
+}
+
+// RunOverlayExpiry drops the expired overlays periodically, until the context is done.
+func (s *SafeRepository) RunOverlayExpiry(ctx context.Context) {
+	ticker := time.NewTicker(overlayExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.ExpireOverlays(now)
+		}
+	}
+}
+
+// applyOverlays merges the active overlays into the resolved devices. A device whose overlays cannot be merged, e.g.
+// after a new build, is served its generated configuration and the error is reported with its overlays.
+// It must be called with the mutex locked.
+func (s *SafeRepository) applyOverlays(devices map[string]*Device) {
+	s.overlayErrors = make(map[string]string)
+
+	now := time.Now()
+	hostnames := make(map[string]bool)
+	for _, overlay := range s.overlays {
+		hostnames[overlay.Hostname] = true
+	}
+	for hostname := range hostnames {
+		overlays := s.activeOverlays(hostname, now)
+		if _, ok := devices[hostname]; !ok || len(overlays) == 0 {
+			continue
+		}
+		merged, err := devices[hostname].withOverlays(overlays)
+		if err != nil {
+			log.Error().Err(err).Str("hostname", hostname).Msg("failed to merge overlays")
+			for _, overlay := range overlays {
+				s.overlayErrors[overlay.ID] = err.Error()
+			}
+			continue
+		}
+		devices[hostname] = merged
+	}
+}
@@ -0,0 +1,163 @@
+package device_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openconfig/ygot/ygot"
+
+	"github.com/criteo/data-aggregation-api/internal/convertor/device"
+	"github.com/criteo/data-aggregation-api/internal/evaluator"
+	"github.com/criteo/data-aggregation-api/internal/model/ietf"
+	"github.com/criteo/data-aggregation-api/internal/model/openconfig"
+)
+
+func TestOverlay(t *testing.T) {
+	system := &ietf.Device{System: &ietf.IETFSystem_System{Contact: ygot.String("noc@example.com")}}
+	systemJSON, err := ygot.EmitJSON(system, &ygot.EmitJSONConfig{Format: ygot.RFC7951})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	repo := device.NewSafeRepository(1)
+	repo.Set("build-1", map[string]*device.Device{
+		"tor01-01": {Config: &device.GeneratedConfig{IETF: system, JSONIETF: systemJSON, JSONOpenConfig: "{}", Hash: "a"}},
+	})
+
+	overlay := &device.Overlay{
+		Hostname:  "tor01-01",
+		Model:     device.IETFOverlay,
+		Fragment:  json.RawMessage(`{"ietf-system:system": {"location": "rack 12"}}`),
+		Author:    "jdoe",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if err := repo.AddOverlay(overlay); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	out, err := repo.GetDeviceIETFConfigJSON("tor01-01")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(string(out), "rack 12") || !strings.Contains(string(out), "noc@example.com") {
+		t.Errorf("expected the overlay to be merged into the generated configuration, got %s", out)
+	}
+	if _, hash, _ := repo.GetBuildInfo("tor01-01"); hash == "a" {
+		t.Error("expected the configuration hash to change with the overlay")
+	}
+	if overlays := repo.ListOverlays("*"); len(overlays) != 1 || overlays[0].ID != overlay.ID {
+		t.Errorf("unexpected active overlays: %+v", overlays)
+	}
+
+	invalid := &device.Overlay{
+		Hostname:  "tor01-01",
+		Model:     device.IETFOverlay,
+		Fragment:  json.RawMessage(`{"ietf-system:system": {"unknown-leaf": "value"}}`),
+		Author:    "jdoe",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if err := repo.AddOverlay(invalid); !errors.Is(err, device.ErrInvalidOverlay) {
+		t.Errorf("expected an invalid overlay error, got %v", err)
+	}
+
+	// the overlays of a device failing to build cannot be merged, the error is reported with each of them
+	repo.Set("build-2", map[string]*device.Device{
+		"tor01-01": nil,
+		"tor01-02": {Config: &device.GeneratedConfig{IETF: system, JSONIETF: systemJSON, JSONOpenConfig: "{}", Hash: "b"}},
+	})
+	other := &device.Overlay{
+		Hostname:  "tor01-02",
+		Model:     device.IETFOverlay,
+		Fragment:  json.RawMessage(`{"ietf-system:system": {"location": "rack 13"}}`),
+		Author:    "jdoe",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if err := repo.AddOverlay(other); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, listed := range repo.ListOverlays("*") {
+		if failed := listed.ID == overlay.ID; failed != (listed.Error != "") {
+			t.Errorf("unexpected error for the overlay of %s: %q", listed.Hostname, listed.Error)
+		}
+	}
+	repo.Set("build-3", map[string]*device.Device{
+		"tor01-01": {Config: &device.GeneratedConfig{IETF: system, JSONIETF: systemJSON, JSONOpenConfig: "{}", Hash: "a"}},
+	})
+
+	// the generated configuration is served again once the overlay expired
+	repo.ExpireOverlays(time.Now().Add(2 * time.Hour))
+	if _, hash, _ := repo.GetBuildInfo("tor01-01"); hash != "a" {
+		t.Errorf("expected the generated configuration after expiry, got hash %s", hash)
+	}
+	if overlays := repo.ListOverlays("tor01-01"); len(overlays) != 0 {
+		t.Errorf("expected no active overlay, got %+v", overlays)
+	}
+}
+
+func TestOverlayStatementOrder(t *testing.T) {
+	// the generated policy ends with a catch-all statement accepting every route
+	var statements openconfig.RoutingPolicy_PolicyDefinition_Statement_OrderedMap
+	if err := statements.Append(&openconfig.RoutingPolicy_PolicyDefinition_Statement{
+		Name:    ygot.String("11"),
+		Actions: &openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions{PolicyResult: openconfig.RoutingPolicy_PolicyResultType_ACCEPT_ROUTE},
+	}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	generated := &openconfig.Device{RoutingPolicy: &openconfig.RoutingPolicy{
+		PolicyDefinition: map[string]*openconfig.RoutingPolicy_PolicyDefinition{
+			"SERVERS:IN": {Name: ygot.String("SERVERS:IN"), Statement: &statements},
+		},
+	}}
+	generatedJSON, err := ygot.EmitJSON(generated, &ygot.EmitJSONConfig{Format: ygot.RFC7951})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	repo := device.NewSafeRepository(1)
+	repo.Set("build-1", map[string]*device.Device{
+		"tor01-01": {Config: &device.GeneratedConfig{Openconfig: generated, JSONOpenConfig: generatedJSON, JSONIETF: "{}", Hash: "a"}},
+	})
+
+	newOverlay := func(statement string) *device.Overlay {
+		return &device.Overlay{
+			Hostname: "tor01-01",
+			Model:    device.OpenConfigOverlay,
+			Fragment: json.RawMessage(`{"openconfig-routing-policy:routing-policy": {
+				"defined-sets": {"prefix-sets": {"prefix-set": [{"name": "BLACKHOLED", "config": {"name": "BLACKHOLED"},
+					"prefixes": {"prefix": [{"ip-prefix": "192.0.2.0/24", "masklength-range": "exact",
+						"config": {"ip-prefix": "192.0.2.0/24", "masklength-range": "exact"}}]}}]}},
+				"policy-definitions": {"policy-definition": [{"name": "SERVERS:IN", "config": {"name": "SERVERS:IN"},
+					"statements": {"statement": [{"name": "` + statement + `", "config": {"name": "` + statement + `"},
+						"conditions": {"match-prefix-set": {"config": {"prefix-set": "BLACKHOLED"}}},
+						"actions": {"config": {"policy-result": "REJECT_ROUTE"}}}]}}]}}}`),
+			Author:    "jdoe",
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+	}
+
+	// statements are named by their sequence
+	if err := repo.AddOverlay(newOverlay("DENY")); !errors.Is(err, device.ErrInvalidOverlay) {
+		t.Errorf("expected an invalid overlay error, got %v", err)
+	}
+
+	// the overlay statement is evaluated before the generated catch-all statement
+	if err := repo.AddOverlay(newOverlay("5")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for prefix, want := range map[string]evaluator.Decision{"192.0.2.0/24": evaluator.Reject, "198.51.100.0/24": evaluator.Accept} {
+		out, err := repo.EvaluatePolicyJSON("tor01-01", "SERVERS:IN", &evaluator.Route{Prefix: prefix})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		var result evaluator.Result
+		if err := json.Unmarshal(out, &result); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if result.Decision != want {
+			t.Errorf("expected %s for %s, got %+v", want, prefix, result)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package device
+
+import (
+	"fmt"
+
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/routingpolicy"
+)
+
+// ReferenceError is a name reference which cannot be resolved within the device CMDB data.
+type ReferenceError struct {
+	Hostname string
+	// Object is the object holding the reference (e.g. "route-policy LAN:IN term 10").
+	Object string
+	// Kind is the kind of the referenced object (e.g. "prefix-list").
+	Kind string
+	Name string
+}
+
+func (e *ReferenceError) Error() string {
+	return fmt.Sprintf("%s: %s references unknown %s %s", e.Hostname, e.Object, e.Kind, e.Name)
+}
+
+type nameSet map[string]struct{}
+
+func newNameSet[T any](items []T, name func(T) string) nameSet {
+	set := make(nameSet, len(items))
+	for _, item := range items {
+		set[name(item)] = struct{}{}
+	}
+	return set
+}
+
+// referenceChecker accumulates the unresolved references of a device.
+type referenceChecker struct {
+	hostname string
+	errors   []*ReferenceError
+}
+
+func (c *referenceChecker) check(set nameSet, object string, kind string, name string) {
+	if _, ok := set[name]; !ok {
+		c.errors = append(c.errors, &ReferenceError{Hostname: c.hostname, Object: object, Kind: kind, Name: name})
+	}
+}
+
+func (c *referenceChecker) checkRoutePolicy(policies nameSet, object string, routePolicy *routingpolicy.RoutePolicyLite) {
+	if routePolicy != nil {
+		c.check(policies, object, "route-policy", routePolicy.Name)
+	}
+}
+
+// ValidateReferences resolves every name reference of the device CMDB data.
+// References are resolved within the device only, as each device configuration must be self-contained.
+func (d *Device) ValidateReferences() []*ReferenceError {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	checker := referenceChecker{hostname: d.Dcim.Hostname}
+
+	policies := newNameSet(d.RoutePolicies, func(p *routingpolicy.RoutePolicy) string { return p.Name })
+	prefixLists := newNameSet(d.PrefixLists, func(p *routingpolicy.PrefixList) string { return p.Name })
+	asPathLists := newNameSet(d.ASPathLists, func(p *routingpolicy.ASPathList) string { return p.Name })
+
+	communityLists := make(map[routingpolicy.CommunityType]nameSet)
+	for _, communityType := range []routingpolicy.CommunityType{routingpolicy.StandardCommunity, routingpolicy.ExtendedCommunity, routingpolicy.LargeCommunity} {
+		communityLists[communityType] = make(nameSet)
+	}
+	for _, communityList := range d.CommunityLists {
+		communityType := communityList.Type
+		if communityType == routingpolicy.UnsetCommunity {
+			communityType = routingpolicy.StandardCommunity
+		}
+		communityLists[communityType][communityList.Name] = struct{}{}
+	}
+
+	peerGroups := make(nameSet, len(d.PeerGroups))
+	for _, peerGroup := range d.PeerGroups {
+		peerGroups[peerGroup.Name] = struct{}{}
+		object := "peer-group " + peerGroup.Name
+		checker.checkRoutePolicy(policies, object, peerGroup.RoutePolicyIn)
+		checker.checkRoutePolicy(policies, object, peerGroup.RoutePolicyOut)
+	}
+
+	for _, session := range d.Sessions {
+		local, remote := &session.PeerA, &session.PeerB
+		if session.PeerB.Device.Name == d.Dcim.Hostname {
+			local, remote = &session.PeerB, &session.PeerA
+		}
+
+		object := "BGP session to " + remote.Device.Name
+		if local.PeerGroup != nil {
+			checker.check(peerGroups, object, "peer-group", local.PeerGroup.Name)
+		}
+		checker.checkRoutePolicy(policies, object, local.RoutePolicyIn)
+		checker.checkRoutePolicy(policies, object, local.RoutePolicyOut)
+	}
+
+	for _, dynamicNeighbor := range d.DynamicNeighbors {
+		checker.check(peerGroups, "dynamic neighbor prefix "+dynamicNeighbor.Prefix.String(), "peer-group", dynamicNeighbor.PeerGroup.Name)
+	}
+
+	for _, routePolicy := range d.RoutePolicies {
+		for _, term := range routePolicy.Terms {
+			object := fmt.Sprintf("route-policy %s term %d", routePolicy.Name, term.Sequence)
+			if term.FromPrefixList != nil {
+				checker.check(prefixLists, object, "prefix-list", term.FromPrefixList.Name)
+			}
+			if term.FromBGPCommunityList != nil {
+				checker.check(communityLists[routingpolicy.StandardCommunity], object, "community-list", term.FromBGPCommunityList.Name)
+			}
+			if term.FromBGPExtCommunityList != nil {
+				checker.check(communityLists[routingpolicy.ExtendedCommunity], object, "extended community-list", term.FromBGPExtCommunityList.Name)
+			}
+			if term.FromBGPLargeCommunityList != nil {
+				checker.check(communityLists[routingpolicy.LargeCommunity], object, "large community-list", term.FromBGPLargeCommunityList.Name)
+			}
+			if term.FromASPathList != nil {
+				checker.check(asPathLists, object, "AS-path-list", term.FromASPathList.Name)
+			}
+			if term.SetCommunityList != nil {
+				checker.check(communityLists[routingpolicy.StandardCommunity], object, "community-list", term.SetCommunityList.Name)
+			}
+			if term.CallPolicy != nil {
+				checker.check(policies, object, "route-policy", term.CallPolicy.Name)
+			}
+		}
+	}
+
+	return checker.errors
+}
@@ -0,0 +1,70 @@
+package device_test
+
+import (
+	"testing"
+
+	"github.com/criteo/data-aggregation-api/internal/convertor/device"
+	"github.com/criteo/data-aggregation-api/internal/ingestor/repository"
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/bgp"
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/routingpolicy"
+	"github.com/criteo/data-aggregation-api/internal/model/dcim"
+	"github.com/google/go-cmp/cmp"
+)
+
+type nameRef = struct {
+	Name string `json:"name" validate:"required"`
+}
+
+func TestValidateReferences(t *testing.T) {
+	hostname := "tor01-01"
+
+	session := &bgp.Session{}
+	session.PeerA.Device.Name = hostname
+	session.PeerA.PeerGroup = &bgp.PeerGroupLite{Name: "MISSING-GROUP"}
+	session.PeerA.RoutePolicyIn = &routingpolicy.RoutePolicyLite{Name: "LAN:IN"}
+	session.PeerB.Device.Name = "spine01-01"
+	// the remote side references are resolved on the remote device
+	session.PeerB.RoutePolicyOut = &routingpolicy.RoutePolicyLite{Name: "REMOTE:OUT"}
+
+	term := &routingpolicy.RoutePolicyTerm{
+		Sequence:                10,
+		Decision:                routingpolicy.Permit,
+		FromPrefixList:          &nameRef{Name: "SERVERS"},
+		FromBGPCommunityList:    &nameRef{Name: "RT:PROD"},
+		FromBGPExtCommunityList: &nameRef{Name: "RT:PROD"},
+		FromASPathList:          &nameRef{Name: "TRANSIT"},
+		CallPolicy:              &nameRef{Name: "MISSING-POLICY"},
+	}
+
+	devicesData := &repository.AssetsPerDevice{
+		BGPsessions: map[string][]*bgp.Session{hostname: {session}},
+		PrefixLists: map[string][]*routingpolicy.PrefixList{hostname: {{Name: "SERVERS"}}},
+		CommunityLists: map[string][]*routingpolicy.CommunityList{
+			hostname: {{Name: "RT:PROD", Type: routingpolicy.ExtendedCommunity}},
+		},
+		RoutePolicies: map[string][]*routingpolicy.RoutePolicy{
+			hostname: {{Name: "LAN:IN", Terms: []*routingpolicy.RoutePolicyTerm{term}}},
+		},
+	}
+
+	dev, err := device.NewDevice(&dcim.NetworkDevice{Hostname: hostname}, devicesData)
+	if err != nil {
+		t.Fatalf("failed to create device: %s", err)
+	}
+
+	want := []string{
+		"tor01-01: BGP session to spine01-01 references unknown peer-group MISSING-GROUP",
+		"tor01-01: route-policy LAN:IN term 10 references unknown community-list RT:PROD",
+		"tor01-01: route-policy LAN:IN term 10 references unknown AS-path-list TRANSIT",
+		"tor01-01: route-policy LAN:IN term 10 references unknown route-policy MISSING-POLICY",
+	}
+
+	var got []string
+	for _, referenceError := range dev.ValidateReferences() {
+		got = append(got, referenceError.Error())
+	}
+
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("unexpected diff: %s\n", diff)
+	}
+}
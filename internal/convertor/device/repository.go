@@ -1,29 +1,334 @@
 package device
 
 import (
+	"context"
+	"maps"
+	"slices"
 	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/criteo/data-aggregation-api/internal/search"
 )
 
+// generation is the devices configuration produced by one build.
+type generation struct {
+	buildID     string
+	publishedAt time.Time
+	devices     map[string]*Device
+}
+
+// GenerationInfo describes a generation kept by the repository.
+type GenerationInfo struct {
+	BuildID     string    `json:"build_id"`
+	PublishedAt time.Time `json:"published_at"`
+	Devices     int       `json:"devices"`
+}
+
+// SafeRepository serves the stable generation to the fleet and, during a rollout, the canary generation to the
+// canary devices. Devices pinned to a generation, or the whole fleet when rolled back, are served this generation
+// until unpinned. The recent generations are kept to allow rollback. The overlays of a device are merged into the
+// configuration it is served.
 type SafeRepository struct {
-	devices map[string]*Device
-	mutex   *sync.Mutex
+	stable        *generation
+	canary        *generation
+	canaryDevices map[string]bool
+	// history holds the recent generations, oldest first, and the ones still pinned.
+	history         []*generation
+	keepGenerations int
+	// pins are the build IDs by hostname, the wildcard hostname pins the whole fleet.
+	pins map[string]string
+	// overlays are merged into the configuration served to their device, by ID, their merge errors as well.
+	overlays      map[string]*Overlay
+	overlayErrors map[string]string
+	// devices and buildIDs are resolved from the generations, per device.
+	// baseDevices are the resolved devices before merging the overlays.
+	devices     map[string]*Device
+	baseDevices map[string]*Device
+	buildIDs    map[string]string
+	index       *search.Index
+	// changes signals the notifier that the served configuration changed, the pending signals are merged.
+	changes  chan struct{}
+	onChange []func()
+	mutex    *sync.Mutex
 }
 
 type AFKEnabledResponse struct {
 	AFKEnabled bool `json:"afk_enabled"`
 }
 
-func NewSafeRepository() SafeRepository {
+// NewSafeRepository creates a repository keeping the given number of recent generations for rollback.
+func NewSafeRepository(keepGenerations int) SafeRepository {
 	return SafeRepository{
-		mutex:   &sync.Mutex{},
-		devices: map[string]*Device{},
+		mutex:           &sync.Mutex{},
+		keepGenerations: keepGenerations,
+		pins:            map[string]string{},
+		overlays:        map[string]*Overlay{},
+		overlayErrors:   map[string]string{},
+		devices:         map[string]*Device{},
+		baseDevices:     map[string]*Device{},
+		buildIDs:        map[string]string{},
+		index:           search.NewIndex(),
+		changes:         make(chan struct{}, 1),
 	}
 }
 
-// Set new device configuration in the repository.
+// OnChange registers a function called each time the configuration served to the devices changes: new build, canary
+// rollout, pin, overlay. The functions are called by RunChangeNotifier, without holding any lock.
 // This method is concurrent-safe.
-func (s *SafeRepository) Set(devices map[string]*Device) {
+func (s *SafeRepository) OnChange(fn func()) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
+	s.onChange = append(s.onChange, fn)
+}
+
+// RunChangeNotifier calls the OnChange functions after the served configuration changes, until the context is done.
+// Several changes in a row may result in a single call.
+func (s *SafeRepository) RunChangeNotifier(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.changes:
+			s.mutex.Lock()
+			onChange := slices.Clone(s.onChange)
+			s.mutex.Unlock()
+
+			for _, fn := range onChange {
+				fn()
+			}
+		}
+	}
+}
+
+// Set new device configuration in the repository, generated by the given build, for all devices.
+// An ongoing canary generation is dropped.
+// This method is concurrent-safe.
+func (s *SafeRepository) Set(buildID string, devices map[string]*Device) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.stable = s.addGeneration(buildID, devices)
+	s.canary = nil
+	s.canaryDevices = nil
+	s.resolve()
+}
+
+// SetCanary serves the configuration generated by the given build to the canary devices only.
+// Devices unknown to the stable generation get it as well, there is no previous configuration to keep for them.
+// This method is concurrent-safe.
+func (s *SafeRepository) SetCanary(buildID string, devices map[string]*Device, canaryDevices []string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.canary = s.addGeneration(buildID, devices)
+	s.canaryDevices = make(map[string]bool, len(canaryDevices))
+	for _, hostname := range canaryDevices {
+		s.canaryDevices[hostname] = true
+	}
+	s.resolve()
+}
+
+// PromoteCanary serves the canary generation to all devices.
+// This method is concurrent-safe.
+func (s *SafeRepository) PromoteCanary() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.canary == nil {
+		return ErrNoCanary
+	}
+	s.stable = s.canary
+	s.canary = nil
+	s.canaryDevices = nil
+	s.resolve()
+	return nil
+}
+
+// AbortCanary serves the stable generation to the canary devices again.
+// This method is concurrent-safe.
+func (s *SafeRepository) AbortCanary() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.canary == nil {
+		return ErrNoCanary
+	}
+	s.canary = nil
+	s.canaryDevices = nil
+	s.resolve()
+	return nil
+}
+
+// Pin serves a kept generation to a device, or to the whole fleet with the wildcard hostname, until unpinned.
+// This method is concurrent-safe.
+func (s *SafeRepository) Pin(hostname string, buildID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	gen := s.findGeneration(buildID)
+	if gen == nil {
+		return ErrGenerationNotKept
+	}
+	if hostname != Wildcard {
+		dev, ok := gen.devices[hostname]
+		if !ok {
+			return ErrNotFound
+		}
+		if dev == nil {
+			return ErrBuidFailed
+		}
+	}
+	s.pins[hostname] = buildID
+	s.resolve()
+	return nil
+}
+
+// Unpin serves the stable, or canary, generation to a device again, or to the whole fleet with the wildcard hostname.
+// This method is concurrent-safe.
+func (s *SafeRepository) Unpin(hostname string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.pins[hostname]; !ok {
+		return ErrNotPinned
+	}
+	delete(s.pins, hostname)
+	s.trimHistory()
+	s.resolve()
+	return nil
+}
+
+// ListPins returns the build IDs pinned by hostname, the wildcard hostname being the whole fleet.
+// This method is concurrent-safe.
+func (s *SafeRepository) ListPins() map[string]string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return maps.Clone(s.pins)
+}
+
+// GetServedDevices returns the devices currently served, resolved from the generations and pins, before merging the overlays.
+// This method is concurrent-safe.
+func (s *SafeRepository) GetServedDevices() map[string]*Device {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return maps.Clone(s.baseDevices)
+}
+
+// ListGenerations returns the kept generations, oldest first.
+// This method is concurrent-safe.
+func (s *SafeRepository) ListGenerations() []GenerationInfo {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	generations := make([]GenerationInfo, 0, len(s.history))
+	for _, gen := range s.history {
+		generations = append(generations, GenerationInfo{BuildID: gen.buildID, PublishedAt: gen.publishedAt, Devices: len(gen.devices)})
+	}
+	return generations
+}
+
+// addGeneration must be called with the mutex locked.
+func (s *SafeRepository) addGeneration(buildID string, devices map[string]*Device) *generation {
+	gen := &generation{buildID: buildID, publishedAt: time.Now(), devices: devices}
+	s.history = append(s.history, gen)
+	s.trimHistory()
+	return gen
+}
+
+// findGeneration must be called with the mutex locked.
+func (s *SafeRepository) findGeneration(buildID string) *generation {
+	for _, gen := range s.history {
+		if gen.buildID == buildID {
+			return gen
+		}
+	}
+	return nil
+}
+
+// trimHistory drops the oldest generations beyond the number to keep, unless served or pinned.
+// It must be called with the mutex locked.
+func (s *SafeRepository) trimHistory() {
+	inUse := make(map[string]bool, len(s.pins)+2)
+	for _, buildID := range s.pins {
+		inUse[buildID] = true
+	}
+	for _, gen := range []*generation{s.stable, s.canary} {
+		if gen != nil {
+			inUse[gen.buildID] = true
+		}
+	}
+
+	var history []*generation
+	for i, gen := range s.history {
+		if len(s.history)-i <= s.keepGenerations || inUse[gen.buildID] {
+			history = append(history, gen)
+		}
+	}
+	s.history = history
+}
+
+// resolve computes the configuration served to each device from the generations.
+// The device pins have precedence over the fleet pin, then the canary and the stable generations.
+// The devices failing to build in the fleet pin generation are not rolled back.
+// The overlays are merged last.
+// It must be called with the mutex locked.
+func (s *SafeRepository) resolve() {
+	devices := make(map[string]*Device)
+	buildIDs := make(map[string]string)
+	if s.stable != nil {
+		for hostname, dev := range s.stable.devices {
+			devices[hostname] = dev
+			buildIDs[hostname] = s.stable.buildID
+		}
+	}
+	if s.canary != nil {
+		for hostname, dev := range s.canary.devices {
+			if _, isStable := devices[hostname]; isStable && !s.canaryDevices[hostname] {
+				continue
+			}
+			devices[hostname] = dev
+			buildIDs[hostname] = s.canary.buildID
+		}
+	}
+	if fleetPin := s.findGeneration(s.pins[Wildcard]); fleetPin != nil {
+		for hostname, dev := range fleetPin.devices {
+			// a device failing to build in the pinned generation keeps its current configuration
+			if dev == nil {
+				continue
+			}
+			devices[hostname] = dev
+			buildIDs[hostname] = fleetPin.buildID
+		}
+	}
+	for hostname, buildID := range s.pins {
+		if gen := s.findGeneration(buildID); gen != nil && hostname != Wildcard {
+			devices[hostname] = gen.devices[hostname]
+			buildIDs[hostname] = buildID
+		}
+	}
+
+	s.baseDevices = maps.Clone(devices)
+	s.applyOverlays(devices)
+
 	s.devices = devices
+	s.buildIDs = buildIDs
+	s.index = newSearchIndex(devices)
+
+	select {
+	case s.changes <- struct{}{}:
+	default:
+		// a change is already pending
+	}
+}
+
+// newSearchIndex indexes the OpenConfig data of the successfully built devices.
+func newSearchIndex(devices map[string]*Device) *search.Index {
+	index := search.NewIndex()
+	for hostname, dev := range devices {
+		if dev == nil || dev.Config == nil {
+			continue
+		}
+		if err := index.Add(hostname, dev.Config.JSONOpenConfig); err != nil {
+			log.Error().Err(err).Send()
+		}
+	}
+	return index
 }
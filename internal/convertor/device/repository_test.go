@@ -0,0 +1,135 @@
+package device_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/criteo/data-aggregation-api/internal/convertor/device"
+)
+
+func newGeneration(hash string) map[string]*device.Device {
+	return map[string]*device.Device{
+		"tor01-01": {Config: &device.GeneratedConfig{Hash: hash}},
+		"tor01-02": {Config: &device.GeneratedConfig{Hash: hash}},
+	}
+}
+
+func servedHashes(t *testing.T, repo *device.SafeRepository) map[string]string {
+	t.Helper()
+
+	hashes := make(map[string]string)
+	for _, hostname := range []string{"tor01-01", "tor01-02"} {
+		_, hash, err := repo.GetBuildInfo(hostname)
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %s", hostname, err)
+		}
+		hashes[hostname] = hash
+	}
+	return hashes
+}
+
+func TestPin(t *testing.T) {
+	repo := device.NewSafeRepository(2)
+	repo.Set("build-1", newGeneration("a"))
+	repo.Set("build-2", newGeneration("b"))
+
+	if err := repo.Pin("tor01-01", "build-1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := repo.Pin("unknown", "build-1"); !errors.Is(err, device.ErrNotFound) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+
+	// the pinned generation is kept beyond the number of generations to keep
+	repo.Set("build-3", newGeneration("c"))
+	repo.Set("build-4", newGeneration("d"))
+	if diff := cmp.Diff(servedHashes(t, &repo), map[string]string{"tor01-01": "a", "tor01-02": "d"}); diff != "" {
+		t.Errorf("unexpected diff for pinned device: %s\n", diff)
+	}
+
+	var kept []string
+	for _, generation := range repo.ListGenerations() {
+		kept = append(kept, generation.BuildID)
+	}
+	if diff := cmp.Diff(kept, []string{"build-1", "build-3", "build-4"}); diff != "" {
+		t.Errorf("unexpected diff for kept generations: %s\n", diff)
+	}
+	if err := repo.Pin("tor01-02", "build-2"); !errors.Is(err, device.ErrGenerationNotKept) {
+		t.Errorf("expected a generation not kept error, got %v", err)
+	}
+
+	// the device pin has precedence over the fleet rollback
+	if err := repo.Pin("*", "build-3"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff := cmp.Diff(servedHashes(t, &repo), map[string]string{"tor01-01": "a", "tor01-02": "c"}); diff != "" {
+		t.Errorf("unexpected diff for rolled back fleet: %s\n", diff)
+	}
+
+	if err := repo.Unpin("tor01-01"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := repo.Unpin("*"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff := cmp.Diff(servedHashes(t, &repo), map[string]string{"tor01-01": "d", "tor01-02": "d"}); diff != "" {
+		t.Errorf("unexpected diff after unpin: %s\n", diff)
+	}
+	if len(repo.ListGenerations()) != 2 {
+		t.Errorf("expected the unpinned generations to be dropped, got %+v", repo.ListGenerations())
+	}
+}
+
+func TestFleetPinSkipsFailedDevices(t *testing.T) {
+	repo := device.NewSafeRepository(2)
+	failed := newGeneration("a")
+	failed["tor01-02"] = nil
+	repo.Set("build-1", failed)
+	repo.Set("build-2", newGeneration("b"))
+
+	if err := repo.Pin("*", "build-1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// the device which failed to build in the pinned generation keeps the current one
+	if diff := cmp.Diff(servedHashes(t, &repo), map[string]string{"tor01-01": "a", "tor01-02": "b"}); diff != "" {
+		t.Errorf("unexpected diff for rolled back fleet: %s\n", diff)
+	}
+}
+
+func TestOnChange(t *testing.T) {
+	repo := device.NewSafeRepository(2)
+	repo.Set("build-1", newGeneration("a"))
+	repo.Set("build-2", newGeneration("b"))
+
+	// the functions may read the repository, no lock is held when they are called
+	pins := make(chan map[string]string, 10)
+	repo.OnChange(func() { pins <- repo.ListPins() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go repo.RunChangeNotifier(ctx)
+
+	// the pending change of the builds is notified first
+	select {
+	case <-pins:
+	case <-time.After(time.Second):
+		t.Fatal("expected the builds to be notified")
+	}
+
+	if err := repo.Pin(device.Wildcard, "build-1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	select {
+	case got := <-pins:
+		if diff := cmp.Diff(got, map[string]string{device.Wildcard: "build-1"}); diff != "" {
+			t.Errorf("unexpected diff: %s\n", diff)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the pin to be notified")
+	}
+}
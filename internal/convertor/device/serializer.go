@@ -3,14 +3,41 @@ package device
 import (
 	"encoding/json"
 	"errors"
+	"slices"
 
 	"github.com/rs/zerolog/log"
+
+	"github.com/criteo/data-aggregation-api/internal/evaluator"
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/bgp"
+	"github.com/criteo/data-aggregation-api/internal/search"
+	"github.com/criteo/data-aggregation-api/internal/topology"
 )
 
 const emptyJSON string = "{}"
 
 var ErrBuidFailed = errors.New("build failed for this device")
 var ErrNotFound = errors.New("not found")
+var ErrNoCanary = errors.New("no canary generation")
+var ErrGenerationNotKept = errors.New("build generation not kept")
+var ErrNotPinned = errors.New("not pinned")
+
+// Wildcard is the hostname of the whole fleet.
+const Wildcard = "*"
+
+const (
+	StableGeneration = "stable"
+	CanaryGeneration = "canary"
+	// PinnedGeneration is served to a pinned device.
+	PinnedGeneration = "pinned"
+	// RollbackGeneration is served to the whole fleet when rolled back.
+	RollbackGeneration = "rollback"
+)
+
+// GenerationResponse tells which build generated the configuration served to a device.
+type GenerationResponse struct {
+	BuildID    string `json:"build_id"`
+	Generation string `json:"generation"`
+}
 
 // IsAFKEnabledJSON checks if one device is AFK enabled.
 func (s *SafeRepository) IsAFKEnabledJSON(hostname string) ([]byte, error) {
@@ -251,3 +278,141 @@ func (s *SafeRepository) GetDeviceConfigJSON(hostname string) ([]byte, error) {
 
 	return []byte(emptyJSON), nil
 }
+
+// EvaluatePolicyJSON simulates a route against a route-policy generated for the device.
+func (s *SafeRepository) EvaluatePolicyJSON(hostname string, policyName string, route *evaluator.Route) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	dev, ok := s.devices[hostname]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if dev == nil || dev.Config == nil || dev.Config.Openconfig == nil {
+		return nil, ErrBuidFailed
+	}
+
+	result, err := evaluator.Evaluate(dev.Config.Openconfig.RoutingPolicy, policyName, route)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(result)
+}
+
+// SearchJSON returns the devices and paths referencing the searched values.
+func (s *SafeRepository) SearchJSON(query search.Query) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	matches, err := s.index.Search(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(matches)
+}
+
+// GetBGPTopology returns the BGP peering graph of the successfully built devices.
+func (s *SafeRepository) GetBGPTopology(filter topology.Filter) *topology.Topology {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	sessionsPerDevice := make(map[string][]*bgp.Session, len(s.devices))
+	for hostname, dev := range s.devices {
+		// dev is nil when failed or no configuration
+		if dev != nil {
+			sessionsPerDevice[hostname] = dev.Sessions
+		}
+	}
+
+	return topology.Build(sessionsPerDevice, filter)
+}
+
+// GetOpenConfigJSON returns the generated OpenConfig RFC7951 JSON of one device.
+func (s *SafeRepository) GetOpenConfigJSON(hostname string) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	dev, ok := s.devices[hostname]
+	if !ok {
+		return "", ErrNotFound
+	}
+	// dev is nil when failed or no configuration
+	if dev == nil || dev.Config == nil {
+		return "", ErrBuidFailed
+	}
+
+	return dev.Config.JSONOpenConfig, nil
+}
+
+// ListAFKEnabledDevices returns the hostnames of the AFK enabled devices, sorted.
+func (s *SafeRepository) ListAFKEnabledDevices() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var hostnames []string
+	for hostname, dev := range s.devices {
+		if dev != nil && dev.AFKEnabled {
+			hostnames = append(hostnames, hostname)
+		}
+	}
+	slices.Sort(hostnames)
+
+	return hostnames
+}
+
+// GetBuildInfo returns the build ID and the configuration hash served for one device.
+func (s *SafeRepository) GetBuildInfo(hostname string) (string, string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	dev, ok := s.devices[hostname]
+	if !ok {
+		return "", "", ErrNotFound
+	}
+	// dev is nil when failed or no configuration
+	if dev == nil || dev.Config == nil {
+		return "", "", ErrBuidFailed
+	}
+
+	return s.buildIDs[hostname], dev.Config.Hash, nil
+}
+
+// generationOf must be called with the mutex locked.
+func (s *SafeRepository) generationOf(hostname string) GenerationResponse {
+	buildID := s.buildIDs[hostname]
+	if _, pinned := s.pins[hostname]; pinned {
+		return GenerationResponse{BuildID: buildID, Generation: PinnedGeneration}
+	}
+	if fleetPin, rolledBack := s.pins[Wildcard]; rolledBack && buildID == fleetPin {
+		return GenerationResponse{BuildID: buildID, Generation: RollbackGeneration}
+	}
+	if s.canary != nil && buildID == s.canary.buildID {
+		return GenerationResponse{BuildID: buildID, Generation: CanaryGeneration}
+	}
+	return GenerationResponse{BuildID: buildID, Generation: StableGeneration}
+}
+
+// GetDeviceGenerationJSON returns the generation served to one device.
+func (s *SafeRepository) GetDeviceGenerationJSON(hostname string) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.devices[hostname]; !ok {
+		return nil, ErrNotFound
+	}
+	return json.Marshal(s.generationOf(hostname))
+}
+
+// GetAllDevicesGenerationJSON returns the generation served to each device.
+func (s *SafeRepository) GetAllDevicesGenerationJSON() ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	r := make(map[string]GenerationResponse, len(s.devices))
+	for hostname := range s.devices {
+		r[hostname] = s.generationOf(hostname)
+	}
+	return json.Marshal(r)
+}
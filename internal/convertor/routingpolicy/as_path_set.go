@@ -0,0 +1,31 @@
+package routingpolicy
+
+import (
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/routingpolicy"
+	"github.com/criteo/data-aggregation-api/internal/model/openconfig"
+)
+
+func extractASPathListTerms(asPathList *routingpolicy.ASPathList) []string {
+	terms := make([]string, 0, len(asPathList.Terms))
+	for _, term := range asPathList.Terms {
+		terms = append(terms, term.Regex)
+	}
+	return terms
+}
+
+// ASPathListsToOpenconfig converts precomputed AS-path-lists to OpenConfig.
+// OpenConfig path: /routing-policy/defined-sets/bgp-defined-sets/as-path-sets/.
+func ASPathListsToOpenconfig(asPathLists []*routingpolicy.ASPathList) map[string]*openconfig.RoutingPolicy_DefinedSets_BgpDefinedSets_AsPathSet {
+	var asPathSets = make(map[string]*openconfig.RoutingPolicy_DefinedSets_BgpDefinedSets_AsPathSet)
+
+	for _, asPathList := range asPathLists {
+		asPathSet := openconfig.RoutingPolicy_DefinedSets_BgpDefinedSets_AsPathSet{
+			AsPathSetName:   &asPathList.Name,
+			AsPathSetMember: extractASPathListTerms(asPathList),
+		}
+
+		asPathSets[asPathList.Name] = &asPathSet
+	}
+
+	return asPathSets
+}
@@ -1,10 +1,36 @@
 package routingpolicy
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
 	"github.com/criteo/data-aggregation-api/internal/model/cmdb/routingpolicy"
 	"github.com/criteo/data-aggregation-api/internal/model/openconfig"
 )
 
+// extCommunityTypes maps the CMDB extended community prefixes to the OpenConfig ones.
+var extCommunityTypes = map[string]string{
+	"rt:":  "route-target:",
+	"soo:": "route-origin:",
+}
+
+// extCommunityToOpenconfig converts an extended community (rt:65000:1) to the OpenConfig format (route-target:65000:1).
+func extCommunityToOpenconfig(community string) string {
+	for prefix, ocPrefix := range extCommunityTypes {
+		if strings.HasPrefix(community, prefix) {
+			return ocPrefix + strings.TrimPrefix(community, prefix)
+		}
+	}
+	return community
+}
+
+var errLargeCommunityUnsupported = errors.New("the OpenConfig model has no large communities")
+
+func isStandardCommunityList(communityList *routingpolicy.CommunityList) bool {
+	return communityList.Type == routingpolicy.StandardCommunity || communityList.Type == routingpolicy.UnsetCommunity
+}
+
 func extractCommunityListTerms(communityList *routingpolicy.CommunityList) []openconfig.RoutingPolicy_DefinedSets_BgpDefinedSets_CommunitySet_CommunityMember_Union {
 	terms := make([]openconfig.RoutingPolicy_DefinedSets_BgpDefinedSets_CommunitySet_CommunityMember_Union, 0, len(communityList.Terms))
 	for _, term := range communityList.Terms {
@@ -14,12 +40,16 @@ func extractCommunityListTerms(communityList *routingpolicy.CommunityList) []ope
 	return terms
 }
 
-// CommunityListToOpenconfig converts precomputed prefix-lists to OpenConfig.
+// CommunityListToOpenconfig converts precomputed standard community-lists to OpenConfig.
 // OpenConfig path: /routing-policy/defined-sets/bgp-defined-sets/community-sets/.
 func CommunityListToOpenconfig(communityLists []*routingpolicy.CommunityList) map[string]*openconfig.RoutingPolicy_DefinedSets_BgpDefinedSets_CommunitySet {
 	var communitySets = make(map[string]*openconfig.RoutingPolicy_DefinedSets_BgpDefinedSets_CommunitySet)
 
 	for _, communityList := range communityLists {
+		if !isStandardCommunityList(communityList) {
+			continue
+		}
+
 		communitySet := openconfig.RoutingPolicy_DefinedSets_BgpDefinedSets_CommunitySet{
 			CommunitySetName: &communityList.Name,
 			CommunityMember:  extractCommunityListTerms(communityList),
@@ -30,3 +60,37 @@ func CommunityListToOpenconfig(communityLists []*routingpolicy.CommunityList) ma
 
 	return communitySets
 }
+
+// ExtCommunityListsToOpenconfig converts precomputed extended community-lists to OpenConfig.
+// OpenConfig path: /routing-policy/defined-sets/bgp-defined-sets/ext-community-sets/.
+func ExtCommunityListsToOpenconfig(communityLists []*routingpolicy.CommunityList) map[string]*openconfig.RoutingPolicy_DefinedSets_BgpDefinedSets_ExtCommunitySet {
+	var extCommunitySets = make(map[string]*openconfig.RoutingPolicy_DefinedSets_BgpDefinedSets_ExtCommunitySet)
+
+	for _, communityList := range communityLists {
+		if communityList.Type != routingpolicy.ExtendedCommunity {
+			continue
+		}
+
+		members := make([]string, 0, len(communityList.Terms))
+		for _, term := range communityList.Terms {
+			members = append(members, extCommunityToOpenconfig(term.Community))
+		}
+
+		extCommunitySets[communityList.Name] = &openconfig.RoutingPolicy_DefinedSets_BgpDefinedSets_ExtCommunitySet{
+			ExtCommunitySetName: &communityList.Name,
+			ExtCommunityMember:  members,
+		}
+	}
+
+	return extCommunitySets
+}
+
+// checkLargeCommunityLists rejects large community-lists, the OpenConfig model has no large community sets.
+func checkLargeCommunityLists(communityLists []*routingpolicy.CommunityList) error {
+	for _, communityList := range communityLists {
+		if communityList.Type == routingpolicy.LargeCommunity {
+			return fmt.Errorf("large community-list %s is not supported: %w", communityList.Name, errLargeCommunityUnsupported)
+		}
+	}
+	return nil
+}
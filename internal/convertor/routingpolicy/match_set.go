@@ -0,0 +1,90 @@
+package routingpolicy
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/routingpolicy"
+	"github.com/criteo/data-aggregation-api/internal/model/openconfig"
+)
+
+const (
+	tagSetPrefix          = "TAG-"
+	prefixLengthSetPrefix = "PREFIX-LENGTH-"
+
+	maxIPv4PrefixLength = 32
+	maxIPv6PrefixLength = 128
+)
+
+func tagSetName(tag uint32) string {
+	return tagSetPrefix + strconv.FormatUint(uint64(tag), 10)
+}
+
+func hasPrefixLengthMatch(term *routingpolicy.RoutePolicyTerm) bool {
+	return term.FromPrefixLengthMin > 0 || term.FromPrefixLengthMax > 0
+}
+
+// prefixLengthBounds returns the prefix length range of a term, an unset maximum meaning any length.
+func prefixLengthBounds(term *routingpolicy.RoutePolicyTerm) (uint8, uint8) {
+	maxLength := term.FromPrefixLengthMax
+	if maxLength == 0 {
+		maxLength = maxIPv6PrefixLength
+	}
+	return term.FromPrefixLengthMin, maxLength
+}
+
+func prefixLengthSetName(term *routingpolicy.RoutePolicyTerm) string {
+	minLength, maxLength := prefixLengthBounds(term)
+	return fmt.Sprintf("%s%d-%d", prefixLengthSetPrefix, minLength, maxLength)
+}
+
+func newPrefixLengthSetPrefix(prefix string, minLength uint8, maxLength uint8) (openconfig.RoutingPolicy_DefinedSets_PrefixSet_Prefix_Key, *openconfig.RoutingPolicy_DefinedSets_PrefixSet_Prefix) {
+	mask := fmt.Sprintf("%d..%d", minLength, maxLength)
+	key := openconfig.RoutingPolicy_DefinedSets_PrefixSet_Prefix_Key{IpPrefix: prefix, MasklengthRange: mask}
+	return key, &openconfig.RoutingPolicy_DefinedSets_PrefixSet_Prefix{IpPrefix: &prefix, MasklengthRange: &mask}
+}
+
+// prefixLengthSet builds a mixed prefix-set matching any IPv4 or IPv6 prefix with a length in the term range.
+func prefixLengthSet(term *routingpolicy.RoutePolicyTerm) *openconfig.RoutingPolicy_DefinedSets_PrefixSet {
+	name := prefixLengthSetName(term)
+	minLength, maxLength := prefixLengthBounds(term)
+
+	prefixes := make(map[openconfig.RoutingPolicy_DefinedSets_PrefixSet_Prefix_Key]*openconfig.RoutingPolicy_DefinedSets_PrefixSet_Prefix)
+	if minLength <= maxIPv4PrefixLength {
+		key, prefix := newPrefixLengthSetPrefix("0.0.0.0/0", minLength, min(maxLength, maxIPv4PrefixLength))
+		prefixes[key] = prefix
+	}
+	key, prefix := newPrefixLengthSetPrefix("::/0", minLength, maxLength)
+	prefixes[key] = prefix
+
+	return &openconfig.RoutingPolicy_DefinedSets_PrefixSet{
+		Name:   &name,
+		Mode:   openconfig.PrefixSet_Mode_MIXED,
+		Prefix: prefixes,
+	}
+}
+
+// matchSetsToOpenconfig generates the tag-sets and prefix-sets needed by terms matching on tag or prefix length.
+// OpenConfig has no BGP condition for these, they can only be matched through a defined set.
+func matchSetsToOpenconfig(routePolicies []*routingpolicy.RoutePolicy) (map[string]*openconfig.RoutingPolicy_DefinedSets_TagSet, map[string]*openconfig.RoutingPolicy_DefinedSets_PrefixSet) {
+	tagSets := make(map[string]*openconfig.RoutingPolicy_DefinedSets_TagSet)
+	prefixSets := make(map[string]*openconfig.RoutingPolicy_DefinedSets_PrefixSet)
+
+	for _, routePolicy := range routePolicies {
+		for _, term := range routePolicy.Terms {
+			if term.FromTag != nil {
+				name := tagSetName(*term.FromTag)
+				tagSets[name] = &openconfig.RoutingPolicy_DefinedSets_TagSet{
+					Name:     &name,
+					TagValue: []openconfig.RoutingPolicy_DefinedSets_TagSet_TagValue_Union{openconfig.UnionUint32(*term.FromTag)},
+				}
+			}
+
+			if hasPrefixLengthMatch(term) {
+				prefixSets[prefixLengthSetName(term)] = prefixLengthSet(term)
+			}
+		}
+	}
+
+	return tagSets, prefixSets
+}
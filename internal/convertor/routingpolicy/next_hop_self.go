@@ -0,0 +1,34 @@
+package routingpolicy
+
+import (
+	"github.com/criteo/data-aggregation-api/internal/model/openconfig"
+)
+
+// NextHopSelfPolicyName is the policy chained in front of the export policies of next-hop-self neighbors.
+const NextHopSelfPolicyName = "NEXT-HOP-SELF"
+
+// NextHopSelfPolicy returns a policy rewriting the BGP next-hop with the local address.
+// It neither accepts nor rejects the route, so the evaluation continues with the next policy of the chain.
+// OpenConfig path: /routing-policy/policy-definitions/.
+func NextHopSelfPolicy() (*openconfig.RoutingPolicy_PolicyDefinition, error) {
+	name := NextHopSelfPolicyName
+	statementName := "1"
+	var nextHop openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions_SetNextHop_Union = openconfig.BgpPolicy_BgpNextHopType_Enum_SELF
+
+	var statements openconfig.RoutingPolicy_PolicyDefinition_Statement_OrderedMap
+	if err := statements.Append(&openconfig.RoutingPolicy_PolicyDefinition_Statement{
+		Name: &statementName,
+		Actions: &openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions{
+			BgpActions: &openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions{
+				SetNextHop: nextHop,
+			},
+		},
+	}); err != nil {
+		return nil, err
+	}
+
+	return &openconfig.RoutingPolicy_PolicyDefinition{
+		Name:      &name,
+		Statement: &statements,
+	}, nil
+}
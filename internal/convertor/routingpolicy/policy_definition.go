@@ -2,6 +2,7 @@ package routingpolicy
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -21,8 +22,16 @@ var sourceProtocolMap = map[routingpolicy.RoutingProtocols]openconfig.E_PolicyTy
 }
 
 var decisionPolicyMap = map[routingpolicy.Decision]openconfig.E_RoutingPolicy_PolicyResultType{
-	routingpolicy.Permit: openconfig.RoutingPolicy_PolicyResultType_ACCEPT_ROUTE,
-	routingpolicy.Deny:   openconfig.RoutingPolicy_PolicyResultType_REJECT_ROUTE,
+	routingpolicy.Permit:        openconfig.RoutingPolicy_PolicyResultType_ACCEPT_ROUTE,
+	routingpolicy.Deny:          openconfig.RoutingPolicy_PolicyResultType_REJECT_ROUTE,
+	routingpolicy.NextStatement: openconfig.RoutingPolicy_PolicyResultType_NEXT_STATEMENT,
+}
+
+var communitySetOptionMap = map[routingpolicy.CommunitySetOption]openconfig.E_BgpPolicy_BgpSetCommunityOptionType{
+	routingpolicy.CommunityAdd:     openconfig.BgpPolicy_BgpSetCommunityOptionType_ADD,
+	routingpolicy.CommunityRemove:  openconfig.BgpPolicy_BgpSetCommunityOptionType_REMOVE,
+	routingpolicy.CommunityReplace: openconfig.BgpPolicy_BgpSetCommunityOptionType_REPLACE,
+	routingpolicy.CommunityUnset:   openconfig.BgpPolicy_BgpSetCommunityOptionType_REPLACE,
 }
 
 var setRouteOriginMap = map[routingpolicy.RouteProtocolOrigin]openconfig.E_BgpTypes_BgpOriginAttrType{
@@ -46,13 +55,64 @@ func extractASPathPrepend(term *routingpolicy.RoutePolicyTerm) (*openconfig.Rout
 	return asPathPrepend, nil
 }
 
-func extractCommunities(term *routingpolicy.RoutePolicyTerm) []openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions_SetCommunity_Inline_Communities_Union {
-	var communities []openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions_SetCommunity_Inline_Communities_Union
-	for _, community := range strings.Split(term.SetCommunity, stringListSeparator) {
+func splitCommunities(communities string) []string {
+	var values []string
+	for _, community := range strings.Split(communities, stringListSeparator) {
 		if community != "" {
-			communities = append(communities, openconfig.UnionString(community))
+			values = append(values, community)
 		}
 	}
+	return values
+}
+
+func extractCommunities(term *routingpolicy.RoutePolicyTerm) []openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions_SetCommunity_Inline_Communities_Union {
+	var communities []openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions_SetCommunity_Inline_Communities_Union
+	for _, community := range splitCommunities(term.SetCommunity) {
+		communities = append(communities, openconfig.UnionString(community))
+	}
+	return communities
+}
+
+// getSetCommunity returns the community action, either inline or referencing a community-set.
+// Removing communities by reference deletes every community matching the set.
+func getSetCommunity(term *routingpolicy.RoutePolicyTerm) (*openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions_SetCommunity, error) {
+	communities := extractCommunities(term)
+	hasReference := term.SetCommunityList != nil && term.SetCommunityList.Name != ""
+
+	option, ok := communitySetOptionMap[term.SetCommunityOption]
+	if !ok {
+		return nil, fmt.Errorf("unsupported set_community_option: %s", term.SetCommunityOption)
+	}
+
+	switch {
+	case hasReference && len(communities) > 0:
+		return nil, errors.New("set_community and set_community_list are mutually exclusive")
+	case hasReference:
+		return &openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions_SetCommunity{
+			Method:  openconfig.SetCommunity_Method_REFERENCE,
+			Options: option,
+			Reference: &openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions_SetCommunity_Reference{
+				CommunitySetRef: &term.SetCommunityList.Name,
+			},
+		}, nil
+	case len(communities) > 0:
+		return &openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions_SetCommunity{
+			Method:  openconfig.SetCommunity_Method_INLINE,
+			Options: option,
+			Inline: &openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions_SetCommunity_Inline{
+				Communities: communities,
+			},
+		}, nil
+	default:
+		return nil, nil
+	}
+}
+
+func extractExtCommunities(term *routingpolicy.RoutePolicyTerm) []openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions_SetExtCommunity_Inline_Communities_Union {
+	var communities []openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions_SetExtCommunity_Inline_Communities_Union
+	for _, community := range splitCommunities(term.SetExtCommunity) {
+		communities = append(communities, openconfig.UnionString(extCommunityToOpenconfig(community)))
+	}
 	return communities
 }
 
@@ -68,17 +128,25 @@ func getStatementActions(term *routingpolicy.RoutePolicyTerm) (*openconfig.Routi
 	}
 	actions.BgpActions.SetRouteOrigin = setRouteOriginMap[term.SetOrigin]
 
-	communities := extractCommunities(term)
-	if len(communities) > 0 {
-		actions.BgpActions.SetCommunity = &openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions_SetCommunity{
+	if actions.BgpActions.SetCommunity, err = getSetCommunity(term); err != nil {
+		return nil, err
+	}
+
+	extCommunities := extractExtCommunities(term)
+	if len(extCommunities) > 0 {
+		actions.BgpActions.SetExtCommunity = &openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions_SetExtCommunity{
 			Method:  openconfig.SetCommunity_Method_INLINE,
 			Options: openconfig.BgpPolicy_BgpSetCommunityOptionType_REPLACE,
-			Inline: &openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions_SetCommunity_Inline{
-				Communities: communities,
+			Inline: &openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions_SetExtCommunity_Inline{
+				Communities: extCommunities,
 			},
 		}
 	}
 
+	if term.SetLargeCommunity != "" {
+		return nil, fmt.Errorf("set_large_community is not supported: %w", errLargeCommunityUnsupported)
+	}
+
 	if term.SetMetric > 0 {
 		actions.BgpActions.SetMed = openconfig.UnionUint32(term.SetMetric)
 	}
@@ -95,48 +163,152 @@ func getStatementActions(term *routingpolicy.RoutePolicyTerm) (*openconfig.Routi
 	return &actions, nil
 }
 
-func getStatementConditions(term *routingpolicy.RoutePolicyTerm) *openconfig.RoutingPolicy_PolicyDefinition_Statement_Conditions {
+var originMatchMap = map[routingpolicy.RouteProtocolOrigin]openconfig.E_BgpTypes_BgpOriginAttrType{
+	routingpolicy.OriginEGP:        openconfig.BgpTypes_BgpOriginAttrType_EGP,
+	routingpolicy.OriginIGP:        openconfig.BgpTypes_BgpOriginAttrType_IGP,
+	routingpolicy.OriginIncomplete: openconfig.BgpTypes_BgpOriginAttrType_INCOMPLETE,
+}
+
+var afiSafiMatchMap = map[string]openconfig.E_BgpTypes_AFI_SAFI_TYPE{
+	"ipv4-unicast": openconfig.BgpTypes_AFI_SAFI_TYPE_IPV4_UNICAST,
+	"ipv6-unicast": openconfig.BgpTypes_AFI_SAFI_TYPE_IPV6_UNICAST,
+	"l2vpn-evpn":   openconfig.BgpTypes_AFI_SAFI_TYPE_L2VPN_EVPN,
+}
+
+// hasBGPOnlyConditions tells if the term matches on BGP path attributes, which non-BGP routes do not carry.
+func hasBGPOnlyConditions(term *routingpolicy.RoutePolicyTerm) bool {
+	return term.FromMed != nil ||
+		term.FromOrigin != routingpolicy.OriginNone ||
+		len(term.FromNextHops) > 0 ||
+		term.FromASPathList != nil ||
+		term.FromBGPExtCommunityList != nil ||
+		term.FromBGPLargeCommunityList != nil
+}
+
+func checkStatementConditions(term *routingpolicy.RoutePolicyTerm) error {
+	if term.FromSourceProtocol != routingpolicy.Unset && term.FromSourceProtocol != routingpolicy.BGP && hasBGPOnlyConditions(term) {
+		return fmt.Errorf("BGP attributes cannot be matched with source protocol %s", term.FromSourceProtocol)
+	}
+
+	if hasPrefixLengthMatch(term) {
+		if term.FromPrefixList != nil && term.FromPrefixList.Name != "" {
+			return errors.New("prefix length cannot be matched together with a prefix-list")
+		}
+		if minLength, maxLength := prefixLengthBounds(term); minLength > maxLength {
+			return fmt.Errorf("from_prefix_length_min (%d) is greater than from_prefix_length_max (%d)", minLength, maxLength)
+		}
+	}
+
+	return nil
+}
+
+func getStatementConditions(term *routingpolicy.RoutePolicyTerm) (*openconfig.RoutingPolicy_PolicyDefinition_Statement_Conditions, error) {
+	if err := checkStatementConditions(term); err != nil {
+		return nil, err
+	}
+
 	conditions := openconfig.RoutingPolicy_PolicyDefinition_Statement_Conditions{
-		// CallPolicy:        nil, // TODO: implement sub-route-map to CMDB model
 		InstallProtocolEq: sourceProtocolMap[term.FromSourceProtocol],
-		BgpConditions:     &openconfig.RoutingPolicy_PolicyDefinition_Statement_Conditions_BgpConditions{
-			// TODO: implement MedEq in CMDB
-			// TODO: implement OriginEq in CMDB
-			// TODO: implement NextHopIn in CMDB
-			// TODO: implement AfiSafiIn in CMDB
-			// TODO: implement ExtCommunitySet in CMDB
-			// TODO: implement LargeCommunity in CMDB + Openconfig
+		BgpConditions: &openconfig.RoutingPolicy_PolicyDefinition_Statement_Conditions_BgpConditions{
+			MedEq:    term.FromMed,
+			OriginEq: originMatchMap[term.FromOrigin],
 		},
 	}
 
+	if term.CallPolicy != nil && term.CallPolicy.Name != "" {
+		conditions.CallPolicy = &term.CallPolicy.Name
+	}
+
 	if term.FromPrefixList != nil && term.FromPrefixList.Name != "" {
 		conditions.MatchPrefixSet = &openconfig.RoutingPolicy_PolicyDefinition_Statement_Conditions_MatchPrefixSet{
 			PrefixSet: &term.FromPrefixList.Name,
 		}
 	}
 
+	if hasPrefixLengthMatch(term) {
+		name := prefixLengthSetName(term)
+		conditions.MatchPrefixSet = &openconfig.RoutingPolicy_PolicyDefinition_Statement_Conditions_MatchPrefixSet{
+			PrefixSet: &name,
+		}
+	}
+
+	if term.FromTag != nil {
+		name := tagSetName(*term.FromTag)
+		conditions.MatchTagSet = &openconfig.RoutingPolicy_PolicyDefinition_Statement_Conditions_MatchTagSet{
+			TagSet: &name,
+		}
+	}
+
 	if term.FromLocalPref > 0 {
 		conditions.BgpConditions.LocalPrefEq = &term.FromLocalPref
 	}
 	if term.FromBGPCommunityList != nil && term.FromBGPCommunityList.Name != "" {
 		conditions.BgpConditions.CommunitySet = &term.FromBGPCommunityList.Name
 	}
+	if term.FromBGPExtCommunityList != nil && term.FromBGPExtCommunityList.Name != "" {
+		conditions.BgpConditions.ExtCommunitySet = &term.FromBGPExtCommunityList.Name
+	}
+	if term.FromBGPLargeCommunityList != nil && term.FromBGPLargeCommunityList.Name != "" {
+		return nil, fmt.Errorf("from_bgp_large_community_list is not supported: %w", errLargeCommunityUnsupported)
+	}
+	if term.FromASPathList != nil && term.FromASPathList.Name != "" {
+		conditions.BgpConditions.MatchAsPathSet = &openconfig.RoutingPolicy_PolicyDefinition_Statement_Conditions_BgpConditions_MatchAsPathSet{
+			AsPathSet: &term.FromASPathList.Name,
+		}
+	}
 
-	return &conditions
+	for _, nextHop := range term.FromNextHops {
+		conditions.BgpConditions.NextHopIn = append(conditions.BgpConditions.NextHopIn, nextHop.String())
+	}
+
+	for _, afiSafi := range term.FromAfiSafis {
+		safi, ok := afiSafiMatchMap[afiSafi]
+		if !ok {
+			return nil, fmt.Errorf("unsupported AFI/SAFI: %s", afiSafi)
+		}
+		conditions.BgpConditions.AfiSafiIn = append(conditions.BgpConditions.AfiSafiIn, safi)
+	}
+
+	return &conditions, nil
+}
+
+// getDefaultStatement returns a catch-all statement, evaluated after every term, applying the policy default result.
+func getDefaultStatement(routePolicy *routingpolicy.RoutePolicy) (*openconfig.RoutingPolicy_PolicyDefinition_Statement, error) {
+	result, ok := decisionPolicyMap[routePolicy.DefaultResult]
+	if !ok || routePolicy.DefaultResult == routingpolicy.NextStatement {
+		return nil, fmt.Errorf("unsupported default result: %s", routePolicy.DefaultResult)
+	}
+
+	lastSequence := 0
+	for _, term := range routePolicy.Terms {
+		lastSequence = max(lastSequence, term.Sequence)
+	}
+	name := strconv.Itoa(lastSequence + 1)
+
+	return &openconfig.RoutingPolicy_PolicyDefinition_Statement{
+		Name: &name,
+		Actions: &openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions{
+			PolicyResult: result,
+		},
+	}, nil
 }
 
-func extractPolicyStatements(terms []*routingpolicy.RoutePolicyTerm) (*openconfig.RoutingPolicy_PolicyDefinition_Statement_OrderedMap, error) {
+func extractPolicyStatements(routePolicy *routingpolicy.RoutePolicy) (*openconfig.RoutingPolicy_PolicyDefinition_Statement_OrderedMap, error) {
 	var statements openconfig.RoutingPolicy_PolicyDefinition_Statement_OrderedMap
 
-	for _, term := range terms {
+	for _, term := range routePolicy.Terms {
 		name := strconv.Itoa(term.Sequence)
 		actions, err := getStatementActions(term)
 		if err != nil {
 			return nil, err
 		}
+		conditions, err := getStatementConditions(term)
+		if err != nil {
+			return nil, fmt.Errorf("term %d: %w", term.Sequence, err)
+		}
 		statement := openconfig.RoutingPolicy_PolicyDefinition_Statement{
 			Name:       &name,
-			Conditions: getStatementConditions(term),
+			Conditions: conditions,
 			Actions:    actions,
 		}
 
@@ -145,6 +317,16 @@ func extractPolicyStatements(terms []*routingpolicy.RoutePolicyTerm) (*openconfi
 		}
 	}
 
+	if routePolicy.DefaultResult != "" {
+		statement, err := getDefaultStatement(routePolicy)
+		if err != nil {
+			return nil, err
+		}
+		if err := statements.Append(statement); err != nil {
+			return nil, err
+		}
+	}
+
 	return &statements, nil
 }
 
@@ -154,9 +336,9 @@ func RoutePoliciesToOpenconfig(routePolicies []*routingpolicy.RoutePolicy) (map[
 	var policyDefinitions = make(map[string]*openconfig.RoutingPolicy_PolicyDefinition)
 
 	for _, routePolicy := range routePolicies {
-		terms, err := extractPolicyStatements(routePolicy.Terms)
+		terms, err := extractPolicyStatements(routePolicy)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("route-policy %s: %w", routePolicy.Name, err)
 		}
 		policy := openconfig.RoutingPolicy_PolicyDefinition{
 			Name:      &routePolicy.Name,
@@ -0,0 +1,170 @@
+package routingpolicy
+
+import (
+	"bytes"
+	"net"
+	"sort"
+
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/routingpolicy"
+	"github.com/criteo/data-aggregation-api/internal/types"
+)
+
+// prefixRange is a prefix-list term with its effective mask length range.
+type prefixRange struct {
+	ip        net.IP
+	length    int
+	bits      int
+	minLength int
+	maxLength int
+	// term is the original term, nil if the range results from a merge.
+	term *routingpolicy.PrefixListTerm
+}
+
+func newPrefixRange(term *routingpolicy.PrefixListTerm) *prefixRange {
+	r := &prefixRange{ip: term.Prefix.IP.To16(), length: term.Prefix.Netmask, bits: net.IPv6len * 8, term: term}
+	if ip4 := term.Prefix.IP.To4(); ip4 != nil {
+		r.ip, r.bits = ip4, net.IPv4len*8
+	}
+	r.ip = r.ip.Mask(net.CIDRMask(r.length, r.bits))
+
+	switch {
+	case term.GreaterOrEqual == 0 && term.LessOrEqual == 0:
+		r.minLength, r.maxLength = r.length, r.length
+	default:
+		r.minLength, r.maxLength = r.length, r.bits
+		if term.GreaterOrEqual > 0 {
+			r.minLength = term.GreaterOrEqual
+		}
+		if term.LessOrEqual > 0 {
+			r.maxLength = term.LessOrEqual
+		}
+	}
+
+	return r
+}
+
+// covers tells if every route matched by other is also matched by r.
+func (r *prefixRange) covers(other *prefixRange) bool {
+	return r.bits == other.bits &&
+		r.length <= other.length &&
+		other.ip.Mask(net.CIDRMask(r.length, r.bits)).Equal(r.ip) &&
+		r.minLength <= other.minLength && other.maxLength <= r.maxLength
+}
+
+// sibling returns the other half of the parent prefix.
+func (r *prefixRange) sibling() net.IP {
+	sibling := make(net.IP, len(r.ip))
+	copy(sibling, r.ip)
+	bit := r.length - 1
+	sibling[bit/8] ^= 1 << (7 - bit%8)
+	return sibling
+}
+
+func (r *prefixRange) toTerm() *routingpolicy.PrefixListTerm {
+	if r.term != nil {
+		return r.term
+	}
+
+	term := &routingpolicy.PrefixListTerm{Prefix: types.CIDR{IP: r.ip, Netmask: r.length}}
+	if r.minLength != r.length {
+		term.GreaterOrEqual = r.minLength
+	}
+	if r.minLength != r.length || r.maxLength != r.length {
+		term.LessOrEqual = r.maxLength
+	}
+	return term
+}
+
+// removeCovered drops the ranges already matched by another range.
+func removeCovered(ranges []*prefixRange) []*prefixRange {
+	kept := make([]*prefixRange, 0, len(ranges))
+	for i, r := range ranges {
+		covered := false
+		for j, other := range ranges {
+			// identical ranges cover each other: only the first one is kept
+			if i != j && other.covers(r) && (!r.covers(other) || j < i) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// mergeSiblings replaces two adjacent prefixes having the same mask length range by their parent.
+// The parent keeps the range of its children, which are longer than the parent itself:
+// 192.0.2.0/25 le 32 and 192.0.2.128/25 le 32 become 192.0.2.0/24 ge 25 le 32.
+func mergeSiblings(ranges []*prefixRange) ([]*prefixRange, bool) {
+	type rangeKey struct {
+		ip                   string
+		length               int
+		minLength, maxLength int
+	}
+
+	index := make(map[rangeKey]int, len(ranges))
+	for i, r := range ranges {
+		index[rangeKey{string(r.ip), r.length, r.minLength, r.maxLength}] = i
+	}
+
+	merged := make([]*prefixRange, 0, len(ranges))
+	dropped := make([]bool, len(ranges))
+	changed := false
+	for i, r := range ranges {
+		if dropped[i] || r.length == 0 {
+			continue
+		}
+
+		j, ok := index[rangeKey{string(r.sibling()), r.length, r.minLength, r.maxLength}]
+		if !ok || dropped[j] {
+			continue
+		}
+
+		dropped[i], dropped[j] = true, true
+		changed = true
+		merged = append(merged, &prefixRange{
+			ip:        r.ip.Mask(net.CIDRMask(r.length-1, r.bits)),
+			length:    r.length - 1,
+			bits:      r.bits,
+			minLength: r.minLength,
+			maxLength: r.maxLength,
+		})
+	}
+
+	for i, r := range ranges {
+		if !dropped[i] {
+			merged = append(merged, r)
+		}
+	}
+
+	return merged, changed
+}
+
+// OptimizePrefixListTerms returns an equivalent and shorter list of terms built by
+// removing the terms covered by another one and merging adjacent prefixes.
+// Terms left untouched are returned as is.
+func OptimizePrefixListTerms(terms []*routingpolicy.PrefixListTerm) []*routingpolicy.PrefixListTerm {
+	ranges := make([]*prefixRange, 0, len(terms))
+	for _, term := range terms {
+		ranges = append(ranges, newPrefixRange(term))
+	}
+
+	for changed := true; changed; {
+		ranges, changed = mergeSiblings(removeCovered(ranges))
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if c := bytes.Compare(ranges[i].ip, ranges[j].ip); c != 0 {
+			return c < 0
+		}
+		return ranges[i].length < ranges[j].length
+	})
+
+	optimized := make([]*routingpolicy.PrefixListTerm, 0, len(ranges))
+	for _, r := range ranges {
+		optimized = append(optimized, r.toTerm())
+	}
+	return optimized
+}
@@ -0,0 +1,72 @@
+package routingpolicy_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/criteo/data-aggregation-api/internal/convertor/routingpolicy"
+	cmdbRP "github.com/criteo/data-aggregation-api/internal/model/cmdb/routingpolicy"
+	"github.com/criteo/data-aggregation-api/internal/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestOptimizePrefixListTerms(t *testing.T) {
+	term := func(prefix string, ge, le int) *cmdbRP.PrefixListTerm {
+		cidr, err := types.ParseCIDR(prefix)
+		if err != nil {
+			t.Fatalf("unable to load test data: %s", err)
+		}
+		return &cmdbRP.PrefixListTerm{Prefix: cidr, GreaterOrEqual: ge, LessOrEqual: le}
+	}
+	format := func(terms []*cmdbRP.PrefixListTerm) []string {
+		var out []string
+		for _, term := range terms {
+			out = append(out, fmt.Sprintf("%s ge %d le %d", term.Prefix.String(), term.GreaterOrEqual, term.LessOrEqual))
+		}
+		return out
+	}
+
+	tests := []struct {
+		name  string
+		terms []*cmdbRP.PrefixListTerm
+		want  []string
+	}{
+		{
+			name:  "duplicate",
+			terms: []*cmdbRP.PrefixListTerm{term("192.0.2.0/24", 0, 0), term("192.0.2.0/24", 0, 0)},
+			want:  []string{"192.0.2.0/24 ge 0 le 0"},
+		},
+		{
+			name:  "covered by a wider range",
+			terms: []*cmdbRP.PrefixListTerm{term("192.0.2.0/24", 0, 32), term("192.0.2.64/26", 0, 0), term("198.51.100.0/24", 0, 0)},
+			want:  []string{"192.0.2.0/24 ge 0 le 32", "198.51.100.0/24 ge 0 le 0"},
+		},
+		{
+			name:  "not covered by an exact prefix",
+			terms: []*cmdbRP.PrefixListTerm{term("192.0.2.0/24", 0, 0), term("192.0.2.64/26", 0, 0)},
+			want:  []string{"192.0.2.0/24 ge 0 le 0", "192.0.2.64/26 ge 0 le 0"},
+		},
+		{
+			name: "adjacent prefixes merged recursively",
+			terms: []*cmdbRP.PrefixListTerm{
+				term("192.0.2.0/26", 0, 0),
+				term("192.0.2.64/26", 0, 0),
+				term("192.0.2.128/26", 0, 0),
+				term("192.0.2.192/26", 0, 0),
+			},
+			want: []string{"192.0.2.0/24 ge 26 le 26"},
+		},
+		{
+			name:  "adjacent prefixes with different ranges",
+			terms: []*cmdbRP.PrefixListTerm{term("2001:db8::/33", 0, 48), term("2001:db8:8000::/33", 0, 64)},
+			want:  []string{"2001:db8::/33 ge 0 le 48", "2001:db8:8000::/33 ge 0 le 64"},
+		},
+	}
+
+	for _, test := range tests {
+		out := routingpolicy.OptimizePrefixListTerms(test.terms)
+		if diff := cmp.Diff(format(out), test.want); diff != "" {
+			t.Errorf("unexpected diff for '%s': %s\n", test.name, diff)
+		}
+	}
+}
@@ -1,27 +1,54 @@
 package routingpolicy
 
 import (
+	"fmt"
+
 	"github.com/criteo/data-aggregation-api/internal/model/cmdb/routingpolicy"
 	"github.com/criteo/data-aggregation-api/internal/model/openconfig"
 )
 
 // RoutingPolicyToOpenconfig converts all precomputed assets in routing-policy/* to OpenConfig.
 // OpenConfig path: /routing-policy/.
-func RoutingPolicyToOpenconfig(prefixLists []*routingpolicy.PrefixList, communityLists []*routingpolicy.CommunityList, routePolicies []*routingpolicy.RoutePolicy) (*openconfig.RoutingPolicy, error) {
+func RoutingPolicyToOpenconfig(prefixLists []*routingpolicy.PrefixList, communityLists []*routingpolicy.CommunityList, asPathLists []*routingpolicy.ASPathList, routePolicies []*routingpolicy.RoutePolicy) (*openconfig.RoutingPolicy, error) {
+	if err := checkLargeCommunityLists(communityLists); err != nil {
+		return nil, err
+	}
+
 	policies, err := RoutePoliciesToOpenconfig(routePolicies)
 	if err != nil {
 		return nil, err
 	}
 
+	prefixSets := PrefixListsToOpenconfig(prefixLists)
+	tagSets, matchPrefixSets := matchSetsToOpenconfig(routePolicies)
+	for name, prefixSet := range matchPrefixSets {
+		if _, ok := prefixSets[name]; ok {
+			return nil, fmt.Errorf("prefix-list %s conflicts with a generated prefix length match", name)
+		}
+		prefixSets[name] = prefixSet
+	}
+
 	routingPolicy := openconfig.RoutingPolicy{
 		PolicyDefinition: policies,
 		DefinedSets: &openconfig.RoutingPolicy_DefinedSets{
-			PrefixSet: PrefixListsToOpenconfig(prefixLists),
+			PrefixSet: prefixSets,
 			BgpDefinedSets: &openconfig.RoutingPolicy_DefinedSets_BgpDefinedSets{
 				CommunitySet: CommunityListToOpenconfig(communityLists),
 			},
 		},
 	}
 
+	if extCommunitySets := ExtCommunityListsToOpenconfig(communityLists); len(extCommunitySets) > 0 {
+		routingPolicy.DefinedSets.BgpDefinedSets.ExtCommunitySet = extCommunitySets
+	}
+
+	if asPathSets := ASPathListsToOpenconfig(asPathLists); len(asPathSets) > 0 {
+		routingPolicy.DefinedSets.BgpDefinedSets.AsPathSet = asPathSets
+	}
+
+	if len(tagSets) > 0 {
+		routingPolicy.DefinedSets.TagSet = tagSets
+	}
+
 	return &routingPolicy, nil
 }
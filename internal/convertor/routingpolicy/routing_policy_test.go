@@ -252,7 +252,7 @@ func TestRoutingPolicyToOpenconfig(t *testing.T) {
 		},
 	}
 
-	ret, err := routingpolicy.RoutingPolicyToOpenconfig(prefixLists, communityLists, routingPolicies)
+	ret, err := routingpolicy.RoutingPolicyToOpenconfig(prefixLists, communityLists, nil, routingPolicies)
 	if err != nil {
 		t.Errorf("failed to convert routing policies to OpenConfig")
 	}
@@ -261,3 +261,293 @@ func TestRoutingPolicyToOpenconfig(t *testing.T) {
 		t.Errorf("unexpected diff for '%s': %s\n", "BGP integration test", diff)
 	}
 }
+
+func TestRoutingPolicyToOpenconfigMatchConditions(t *testing.T) {
+	var med50 uint32 = 50
+	var tag100 uint32 = 100
+	var asPathSetName = "TRANSIT"
+	var extCommunitySetName = "RT:PROD"
+	var tagSetName = "TAG-100"
+	var prefixLengthSetName = "PREFIX-LENGTH-24-48"
+	var ipv4Prefix = "0.0.0.0/0"
+	var ipv6Prefix = "::/0"
+	var ipv4Range = "24..32"
+	var ipv6Range = "24..48"
+
+	term := &cmdbRP.RoutePolicyTerm{
+		Sequence:            10,
+		Decision:            cmdbRP.Permit,
+		FromRouteType:       cmdbRP.EBGP,
+		FromSourceProtocol:  cmdbRP.BGP,
+		FromMed:             &med50,
+		FromOrigin:          cmdbRP.OriginIGP,
+		FromNextHops:        []net.IP{net.ParseIP("192.0.2.1")},
+		FromAfiSafis:        []string{"ipv4-unicast", "ipv6-unicast"},
+		FromTag:             &tag100,
+		FromPrefixLengthMin: 24,
+		FromPrefixLengthMax: 48,
+	}
+	term.FromASPathList = &struct {
+		Name string `json:"name" validate:"required"`
+	}{Name: asPathSetName}
+	term.FromBGPExtCommunityList = &struct {
+		Name string `json:"name" validate:"required"`
+	}{Name: extCommunitySetName}
+
+	routePolicies := []*cmdbRP.RoutePolicy{{Name: "MATCH", Terms: []*cmdbRP.RoutePolicyTerm{term}}}
+
+	ret, err := routingpolicy.RoutingPolicyToOpenconfig(nil, nil, nil, routePolicies)
+	if err != nil {
+		t.Fatalf("failed to convert routing policies to OpenConfig: %s", err)
+	}
+
+	wantConditions := &openconfig.RoutingPolicy_PolicyDefinition_Statement_Conditions{
+		InstallProtocolEq: openconfig.PolicyTypes_INSTALL_PROTOCOL_TYPE_BGP,
+		MatchPrefixSet:    &openconfig.RoutingPolicy_PolicyDefinition_Statement_Conditions_MatchPrefixSet{PrefixSet: &prefixLengthSetName},
+		MatchTagSet:       &openconfig.RoutingPolicy_PolicyDefinition_Statement_Conditions_MatchTagSet{TagSet: &tagSetName},
+		BgpConditions: &openconfig.RoutingPolicy_PolicyDefinition_Statement_Conditions_BgpConditions{
+			MedEq:           &med50,
+			OriginEq:        openconfig.BgpTypes_BgpOriginAttrType_IGP,
+			NextHopIn:       []string{"192.0.2.1"},
+			AfiSafiIn:       []openconfig.E_BgpTypes_AFI_SAFI_TYPE{openconfig.BgpTypes_AFI_SAFI_TYPE_IPV4_UNICAST, openconfig.BgpTypes_AFI_SAFI_TYPE_IPV6_UNICAST},
+			ExtCommunitySet: &extCommunitySetName,
+			MatchAsPathSet:  &openconfig.RoutingPolicy_PolicyDefinition_Statement_Conditions_BgpConditions_MatchAsPathSet{AsPathSet: &asPathSetName},
+		},
+	}
+	if diff := cmp.Diff(ret.PolicyDefinition["MATCH"].Statement.Get("10").Conditions, wantConditions); diff != "" {
+		t.Errorf("unexpected conditions diff: %s\n", diff)
+	}
+
+	wantTagSets := map[string]*openconfig.RoutingPolicy_DefinedSets_TagSet{
+		tagSetName: {Name: &tagSetName, TagValue: []openconfig.RoutingPolicy_DefinedSets_TagSet_TagValue_Union{openconfig.UnionUint32(tag100)}},
+	}
+	if diff := cmp.Diff(ret.DefinedSets.TagSet, wantTagSets); diff != "" {
+		t.Errorf("unexpected tag-sets diff: %s\n", diff)
+	}
+
+	wantPrefixSets := map[string]*openconfig.RoutingPolicy_DefinedSets_PrefixSet{
+		prefixLengthSetName: {
+			Name: &prefixLengthSetName,
+			Mode: openconfig.PrefixSet_Mode_MIXED,
+			Prefix: map[openconfig.RoutingPolicy_DefinedSets_PrefixSet_Prefix_Key]*openconfig.RoutingPolicy_DefinedSets_PrefixSet_Prefix{
+				{IpPrefix: ipv4Prefix, MasklengthRange: ipv4Range}: {IpPrefix: &ipv4Prefix, MasklengthRange: &ipv4Range},
+				{IpPrefix: ipv6Prefix, MasklengthRange: ipv6Range}: {IpPrefix: &ipv6Prefix, MasklengthRange: &ipv6Range},
+			},
+		},
+	}
+	if diff := cmp.Diff(ret.DefinedSets.PrefixSet, wantPrefixSets); diff != "" {
+		t.Errorf("unexpected prefix-sets diff: %s\n", diff)
+	}
+
+	if err := ret.Validate(); err != nil {
+		t.Errorf("generated routing policy does not pass ygot validation: %s", err)
+	}
+
+	// OpenConfig has no large community-set to match
+	term.FromBGPLargeCommunityList = &struct {
+		Name string `json:"name" validate:"required"`
+	}{Name: "LARGE:PROD"}
+	if _, err := routingpolicy.RoutingPolicyToOpenconfig(nil, nil, nil, routePolicies); err == nil {
+		t.Errorf("expected an error for a large community-list match")
+	}
+	term.FromBGPLargeCommunityList = nil
+
+	// MED is a BGP attribute, it cannot be matched on static routes
+	term.FromSourceProtocol = cmdbRP.Static
+	if _, err := routingpolicy.RoutingPolicyToOpenconfig(nil, nil, nil, routePolicies); err == nil {
+		t.Errorf("expected an error for a MED match with a non-BGP source protocol")
+	}
+
+	// a statement can only match a single prefix-set
+	term.FromSourceProtocol = cmdbRP.BGP
+	term.FromPrefixList = &struct {
+		Name string `json:"name" validate:"required"`
+	}{Name: "SERVER:VLAN:PROD"}
+	if _, err := routingpolicy.RoutingPolicyToOpenconfig(nil, nil, nil, routePolicies); err == nil {
+		t.Errorf("expected an error for a prefix length match combined with a prefix-list")
+	}
+}
+
+func TestASPathListsToOpenconfig(t *testing.T) {
+	var transitName = "TRANSIT"
+
+	asPathLists := []*cmdbRP.ASPathList{
+		{
+			Name:  transitName,
+			Terms: []*cmdbRP.ASPathListTerm{{Regex: "^65000_"}, {Regex: "_6500[1-9]$"}},
+		},
+	}
+
+	want := map[string]*openconfig.RoutingPolicy_DefinedSets_BgpDefinedSets_AsPathSet{
+		transitName: {
+			AsPathSetName:   &transitName,
+			AsPathSetMember: []string{"^65000_", "_6500[1-9]$"},
+		},
+	}
+
+	if diff := cmp.Diff(routingpolicy.ASPathListsToOpenconfig(asPathLists), want); diff != "" {
+		t.Errorf("unexpected diff: %s\n", diff)
+	}
+}
+
+func TestRoutingPolicyToOpenconfigExtendedCommunities(t *testing.T) {
+	var extName = "RT:PROD"
+
+	communityLists := []*cmdbRP.CommunityList{
+		{Name: "SERVERS", Terms: []*cmdbRP.CommunityListTerm{{Community: "65000:1"}}},
+		{Name: extName, Type: cmdbRP.ExtendedCommunity, Terms: []*cmdbRP.CommunityListTerm{{Community: "rt:65000:100"}, {Community: "soo:192.0.2.1:1"}}},
+	}
+
+	routePolicies := []*cmdbRP.RoutePolicy{
+		{
+			Name: "SET",
+			Terms: []*cmdbRP.RoutePolicyTerm{
+				{Sequence: 1, Decision: cmdbRP.Permit, SetExtCommunity: "rt:65000:200"},
+			},
+		},
+	}
+
+	ret, err := routingpolicy.RoutingPolicyToOpenconfig(nil, communityLists, nil, routePolicies)
+	if err != nil {
+		t.Fatalf("failed to convert routing policies to OpenConfig: %s", err)
+	}
+	if err := ret.Validate(); err != nil {
+		t.Errorf("generated routing policy does not pass ygot validation: %s", err)
+	}
+
+	if _, ok := ret.DefinedSets.BgpDefinedSets.CommunitySet[extName]; ok {
+		t.Errorf("extended community-list must not be rendered as a standard community-set")
+	}
+
+	wantExt := map[string]*openconfig.RoutingPolicy_DefinedSets_BgpDefinedSets_ExtCommunitySet{
+		extName: {
+			ExtCommunitySetName: &extName,
+			ExtCommunityMember:  []string{"route-target:65000:100", "route-origin:192.0.2.1:1"},
+		},
+	}
+	if diff := cmp.Diff(ret.DefinedSets.BgpDefinedSets.ExtCommunitySet, wantExt); diff != "" {
+		t.Errorf("unexpected ext-community-sets diff: %s\n", diff)
+	}
+
+	actions := ret.PolicyDefinition["SET"].Statement.Get("1").Actions.BgpActions
+	wantSetExt := &openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions_SetExtCommunity{
+		Method:  openconfig.SetCommunity_Method_INLINE,
+		Options: openconfig.BgpPolicy_BgpSetCommunityOptionType_REPLACE,
+		Inline: &openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions_SetExtCommunity_Inline{
+			Communities: []openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions_SetExtCommunity_Inline_Communities_Union{
+				openconfig.UnionString("route-target:65000:200"),
+			},
+		},
+	}
+	if diff := cmp.Diff(actions.SetExtCommunity, wantSetExt); diff != "" {
+		t.Errorf("unexpected set-ext-community diff: %s\n", diff)
+	}
+}
+
+func TestRoutingPolicyToOpenconfigLargeCommunities(t *testing.T) {
+	// OpenConfig has neither large community-sets nor a set-large-community action
+	largeList := []*cmdbRP.CommunityList{
+		{Name: "LARGE:PROD", Type: cmdbRP.LargeCommunity, Terms: []*cmdbRP.CommunityListTerm{{Community: "65000:1:2"}}},
+	}
+	if _, err := routingpolicy.RoutingPolicyToOpenconfig(nil, largeList, nil, nil); err == nil {
+		t.Errorf("expected an error for a large community-list")
+	}
+
+	setLarge := []*cmdbRP.RoutePolicy{
+		{Name: "SET", Terms: []*cmdbRP.RoutePolicyTerm{{Sequence: 1, Decision: cmdbRP.Permit, SetLargeCommunity: "65000:3:4"}}},
+	}
+	if _, err := routingpolicy.RoutingPolicyToOpenconfig(nil, nil, nil, setLarge); err == nil {
+		t.Errorf("expected an error for a set-large-community action")
+	}
+}
+
+func TestRoutingPolicyToOpenconfigSetCommunityOptions(t *testing.T) {
+	var blackholeName = "BLACKHOLE"
+
+	inline := &cmdbRP.RoutePolicyTerm{Sequence: 1, Decision: cmdbRP.Permit, SetCommunity: "65000:1", SetCommunityOption: cmdbRP.CommunityAdd}
+	reference := &cmdbRP.RoutePolicyTerm{Sequence: 2, Decision: cmdbRP.Permit, SetCommunityOption: cmdbRP.CommunityRemove}
+	reference.SetCommunityList = &struct {
+		Name string `json:"name" validate:"required"`
+	}{Name: blackholeName}
+
+	routePolicies := []*cmdbRP.RoutePolicy{{Name: "COMMUNITIES", Terms: []*cmdbRP.RoutePolicyTerm{inline, reference}}}
+
+	ret, err := routingpolicy.RoutePoliciesToOpenconfig(routePolicies)
+	if err != nil {
+		t.Fatalf("failed to convert route policies to OpenConfig: %s", err)
+	}
+
+	wantInline := &openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions_SetCommunity{
+		Method:  openconfig.SetCommunity_Method_INLINE,
+		Options: openconfig.BgpPolicy_BgpSetCommunityOptionType_ADD,
+		Inline: &openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions_SetCommunity_Inline{
+			Communities: []openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions_SetCommunity_Inline_Communities_Union{
+				openconfig.UnionString("65000:1"),
+			},
+		},
+	}
+	if diff := cmp.Diff(ret["COMMUNITIES"].Statement.Get("1").Actions.BgpActions.SetCommunity, wantInline); diff != "" {
+		t.Errorf("unexpected inline set-community diff: %s\n", diff)
+	}
+
+	wantReference := &openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions_SetCommunity{
+		Method:  openconfig.SetCommunity_Method_REFERENCE,
+		Options: openconfig.BgpPolicy_BgpSetCommunityOptionType_REMOVE,
+		Reference: &openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions_SetCommunity_Reference{
+			CommunitySetRef: &blackholeName,
+		},
+	}
+	if diff := cmp.Diff(ret["COMMUNITIES"].Statement.Get("2").Actions.BgpActions.SetCommunity, wantReference); diff != "" {
+		t.Errorf("unexpected reference set-community diff: %s\n", diff)
+	}
+
+	// inline communities and community-set reference cannot be used together
+	reference.SetCommunity = "65000:2"
+	if _, err := routingpolicy.RoutePoliciesToOpenconfig(routePolicies); err == nil {
+		t.Errorf("expected an error for inline communities with a community-set reference")
+	}
+}
+
+func TestRoutePoliciesToOpenconfigCallPolicyAndDefaultResult(t *testing.T) {
+	var calledName = "COMMON"
+	var defaultStatementName = "21"
+
+	callTerm := &cmdbRP.RoutePolicyTerm{Sequence: 10, Decision: cmdbRP.NextStatement}
+	callTerm.CallPolicy = &struct {
+		Name string `json:"name" validate:"required"`
+	}{Name: calledName}
+
+	routePolicies := []*cmdbRP.RoutePolicy{
+		{
+			Name:          "MAIN",
+			DefaultResult: cmdbRP.Deny,
+			Terms:         []*cmdbRP.RoutePolicyTerm{callTerm, {Sequence: 20, Decision: cmdbRP.Permit}},
+		},
+	}
+
+	ret, err := routingpolicy.RoutePoliciesToOpenconfig(routePolicies)
+	if err != nil {
+		t.Fatalf("failed to convert route policies to OpenConfig: %s", err)
+	}
+
+	statements := ret["MAIN"].Statement
+	if diff := cmp.Diff(statements.Keys(), []string{"10", "20", defaultStatementName}); diff != "" {
+		t.Errorf("unexpected statements diff: %s\n", diff)
+	}
+
+	call := statements.Get("10")
+	if diff := cmp.Diff(call.Conditions.CallPolicy, &calledName); diff != "" {
+		t.Errorf("unexpected call-policy diff: %s\n", diff)
+	}
+	if call.Actions.PolicyResult != openconfig.RoutingPolicy_PolicyResultType_NEXT_STATEMENT {
+		t.Errorf("unexpected policy result for the call-policy statement: %v", call.Actions.PolicyResult)
+	}
+
+	wantDefault := &openconfig.RoutingPolicy_PolicyDefinition_Statement{
+		Name:    &defaultStatementName,
+		Actions: &openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions{PolicyResult: openconfig.RoutingPolicy_PolicyResultType_REJECT_ROUTE},
+	}
+	if diff := cmp.Diff(statements.Get(defaultStatementName), wantDefault); diff != "" {
+		t.Errorf("unexpected default statement diff: %s\n", diff)
+	}
+}
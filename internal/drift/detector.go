@@ -0,0 +1,134 @@
+package drift
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/criteo/data-aggregation-api/internal/metrics"
+)
+
+type Source string
+
+const (
+	// GNMISource is a running configuration fetched by the gNMI collector.
+	GNMISource Source = "gnmi"
+	// PushSource is a running configuration pushed to the API, e.g. by AFK.
+	PushSource Source = "push"
+)
+
+var ErrNotChecked = errors.New("drift not checked for this device")
+
+// GeneratedConfigs gives the generated OpenConfig RFC7951 JSON of a device.
+type GeneratedConfigs interface {
+	GetOpenConfigJSON(hostname string) (string, error)
+}
+
+// Status is the result of the last drift check of a device.
+type Status struct {
+	Hostname    string        `json:"hostname"`
+	Source      Source        `json:"source"`
+	CheckedAt   time.Time     `json:"checked_at"`
+	Drifted     bool          `json:"drifted"`
+	Differences []*Difference `json:"differences"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// Detector compares running configurations with the generated ones and keeps the last status of each device.
+// Its methods are concurrent-safe.
+type Detector struct {
+	configs      GeneratedConfigs
+	managedPaths []string
+	metrics      *metrics.DriftRegistry
+	mutex        *sync.Mutex
+	statuses     map[string]*Status
+}
+
+func NewDetector(configs GeneratedConfigs, managedPaths []string, registry *metrics.DriftRegistry) *Detector {
+	return &Detector{
+		configs:      configs,
+		managedPaths: managedPaths,
+		metrics:      registry,
+		mutex:        &sync.Mutex{},
+		statuses:     make(map[string]*Status),
+	}
+}
+
+// Check compares the running configuration of a device with its generated configuration.
+// Errors about the generated configuration (e.g. unknown device) are returned without updating the status.
+func (d *Detector) Check(hostname string, source Source, runningJSON string) (*Status, error) {
+	generatedJSON, err := d.configs.GetOpenConfigJSON(hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	differences, err := Diff(generatedJSON, runningJSON, d.managedPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{
+		Hostname:    hostname,
+		Source:      source,
+		CheckedAt:   time.Now(),
+		Drifted:     len(differences) > 0,
+		Differences: differences,
+	}
+	d.setStatus(status)
+	d.metrics.SetDifferences(hostname, len(differences), float64(status.CheckedAt.Unix()))
+
+	return status, nil
+}
+
+// CollectFailed records a failure to get the running configuration of a device.
+// The differences of the previous check are kept.
+func (d *Detector) CollectFailed(hostname string, source Source, err error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	status, ok := d.statuses[hostname]
+	if !ok {
+		status = &Status{Hostname: hostname, Differences: []*Difference{}}
+		d.statuses[hostname] = status
+	}
+	status.Source = source
+	status.Error = err.Error()
+
+	d.metrics.CollectFailed(hostname)
+}
+
+func (d *Detector) setStatus(status *Status) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.statuses[status.Hostname] = status
+}
+
+// CheckRunningJSON checks a running configuration pushed to the API.
+func (d *Detector) CheckRunningJSON(hostname string, runningJSON []byte) ([]byte, error) {
+	status, err := d.Check(hostname, PushSource, string(runningJSON))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(status)
+}
+
+// GetDriftJSON returns the last drift status of a device.
+func (d *Detector) GetDriftJSON(hostname string) ([]byte, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	status, ok := d.statuses[hostname]
+	if !ok {
+		return nil, ErrNotChecked
+	}
+	return json.Marshal(status)
+}
+
+// GetAllDriftJSON returns the last drift status of all checked devices, by hostname.
+func (d *Detector) GetAllDriftJSON() ([]byte, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return json.Marshal(d.statuses)
+}
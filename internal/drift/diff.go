@@ -0,0 +1,184 @@
+// Package drift compares the generated OpenConfig of the devices with their running configuration.
+package drift
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+type DifferenceType string
+
+const (
+	// Missing leaves are generated but not configured on the device.
+	Missing DifferenceType = "missing"
+	// Unexpected leaves are configured on the device but not generated.
+	Unexpected DifferenceType = "unexpected"
+	// Changed leaves have a different value on the device.
+	Changed DifferenceType = "changed"
+)
+
+// Difference is a leaf, or leaf-list, differing between the generated and the running configuration.
+type Difference struct {
+	Path      string         `json:"path"`
+	Type      DifferenceType `json:"type"`
+	Generated any            `json:"generated,omitempty"`
+	Running   any            `json:"running,omitempty"`
+}
+
+// identityPrefix matches the module prefix of RFC7951 identity values ("openconfig-policy-types:BGP").
+var identityPrefix = regexp.MustCompile(`^[a-z][a-z-]*:`)
+
+// orderedByUser are the schema paths of the lists whose entries order is meaningful (YANG "ordered-by user").
+var orderedByUser = map[string]bool{
+	"/routing-policy/policy-definitions/policy-definition/statements/statement": true,
+}
+
+type leaf struct {
+	schemaPath string
+	value      any
+}
+
+func stripModule(name string) string {
+	if _, after, found := strings.Cut(name, ":"); found {
+		return after
+	}
+	return name
+}
+
+// normalizeScalar formats a value as a string, without module prefix, as devices may encode numbers and identities differently.
+func normalizeScalar(value any) string {
+	return identityPrefix.ReplaceAllString(fmt.Sprint(value), "")
+}
+
+func isLeafList(values []any) bool {
+	for _, value := range values {
+		if _, isObject := value.(map[string]any); isObject {
+			return false
+		}
+	}
+	return true
+}
+
+// entryKeys formats the keys of an OpenConfig list entry, which are its scalar members ("[name=SERVERS]").
+func entryKeys(entry map[string]any) string {
+	var keys []string
+	for member, value := range entry {
+		switch value.(type) {
+		case map[string]any, []any:
+			continue
+		}
+		keys = append(keys, fmt.Sprintf("[%s=%s]", stripModule(member), normalizeScalar(value)))
+	}
+	slices.Sort(keys)
+	return strings.Join(keys, "")
+}
+
+// flatten indexes the leaves of a RFC7951 tree by path.
+// Leaves directly under a list entry are its keys, they are already part of the path.
+// The entries order of ordered-by-user lists is indexed as a leaf-list at the path of the list.
+func flatten(members map[string]any, path string, schemaPath string, isEntry bool, leaves map[string]*leaf) {
+	for member, value := range members {
+		name := stripModule(member)
+		childPath := path + "/" + name
+		childSchemaPath := schemaPath + "/" + name
+
+		switch v := value.(type) {
+		case map[string]any:
+			flatten(v, childPath, childSchemaPath, false, leaves)
+		case []any:
+			if !isLeafList(v) {
+				var order []string
+				for _, item := range v {
+					entry, ok := item.(map[string]any)
+					if !ok {
+						continue
+					}
+					keys := entryKeys(entry)
+					order = append(order, keys)
+					flatten(entry, childPath+keys, childSchemaPath, true, leaves)
+				}
+				if orderedByUser[childSchemaPath] {
+					leaves[childPath] = &leaf{schemaPath: childSchemaPath, value: order}
+				}
+				continue
+			}
+			values := make([]string, 0, len(v))
+			for _, item := range v {
+				values = append(values, normalizeScalar(item))
+			}
+			slices.Sort(values)
+			leaves[childPath] = &leaf{schemaPath: childSchemaPath, value: values}
+		default:
+			if !isEntry {
+				leaves[childPath] = &leaf{schemaPath: childSchemaPath, value: normalizeScalar(v)}
+			}
+		}
+	}
+}
+
+func isManaged(schemaPath string, managedPaths []string) bool {
+	for _, managed := range managedPaths {
+		if schemaPath == managed || strings.HasPrefix(schemaPath, managed+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func parseTree(configJSON string) (map[string]any, error) {
+	decoder := json.NewDecoder(strings.NewReader(configJSON))
+	decoder.UseNumber()
+
+	var tree map[string]any
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// Diff compares the leaves of two RFC7951 JSON configurations under the managed schema paths
+// ("/routing-policy"), list keys excluded. Leaf-lists are compared regardless of the order of their values,
+// while the entries order of ordered-by-user lists (e.g. policy statements) is compared.
+func Diff(generatedJSON string, runningJSON string, managedPaths []string) ([]*Difference, error) {
+	generatedTree, err := parseTree(generatedJSON)
+	if err != nil {
+		return nil, fmt.Errorf("invalid generated configuration: %w", err)
+	}
+	runningTree, err := parseTree(runningJSON)
+	if err != nil {
+		return nil, fmt.Errorf("invalid running configuration: %w", err)
+	}
+
+	generated := make(map[string]*leaf)
+	flatten(generatedTree, "", "", false, generated)
+	running := make(map[string]*leaf)
+	flatten(runningTree, "", "", false, running)
+
+	differences := []*Difference{}
+	for path, generatedLeaf := range generated {
+		if !isManaged(generatedLeaf.schemaPath, managedPaths) {
+			continue
+		}
+
+		runningLeaf, ok := running[path]
+		switch {
+		case !ok:
+			differences = append(differences, &Difference{Path: path, Type: Missing, Generated: generatedLeaf.value})
+		case fmt.Sprint(runningLeaf.value) != fmt.Sprint(generatedLeaf.value):
+			differences = append(differences, &Difference{Path: path, Type: Changed, Generated: generatedLeaf.value, Running: runningLeaf.value})
+		}
+	}
+	for path, runningLeaf := range running {
+		if _, ok := generated[path]; !ok && isManaged(runningLeaf.schemaPath, managedPaths) {
+			differences = append(differences, &Difference{Path: path, Type: Unexpected, Running: runningLeaf.value})
+		}
+	}
+
+	slices.SortFunc(differences, func(a, b *Difference) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return differences, nil
+}
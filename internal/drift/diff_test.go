@@ -0,0 +1,161 @@
+package drift_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/criteo/data-aggregation-api/internal/drift"
+)
+
+const generatedConfig = `
+{
+	"openconfig-network-instance:network-instances": {
+		"network-instance": [
+			{
+				"name": "default",
+				"config": {"name": "default"},
+				"protocols": {
+					"protocol": [
+						{
+							"identifier": "openconfig-policy-types:BGP",
+							"name": "bgp",
+							"config": {"identifier": "openconfig-policy-types:BGP", "name": "bgp"},
+							"bgp": {
+								"global": {"config": {"as": 65000}},
+								"neighbors": {
+									"neighbor": [
+										{
+											"neighbor-address": "192.0.2.1",
+											"config": {"neighbor-address": "192.0.2.1", "peer-as": 65001},
+											"apply-policy": {"config": {"import-policy": ["SPINE:IN", "DEFAULT:IN"]}}
+										}
+									]
+								}
+							}
+						}
+					]
+				}
+			}
+		]
+	},
+	"openconfig-routing-policy:routing-policy": {
+		"policy-definitions": {
+			"policy-definition": [
+				{"name": "SPINE:IN", "config": {"name": "SPINE:IN"}}
+			]
+		}
+	}
+}`
+
+// runningConfig has its leaf-list in another order and identities without module prefix, as some devices return them.
+const runningConfig = `
+{
+	"openconfig-network-instance:network-instances": {
+		"network-instance": [
+			{
+				"name": "default",
+				"config": {"name": "default", "description": "not managed"},
+				"protocols": {
+					"protocol": [
+						{
+							"identifier": "BGP",
+							"name": "bgp",
+							"config": {"identifier": "BGP", "name": "bgp"},
+							"bgp": {
+								"global": {"config": {"as": "65000"}},
+								"neighbors": {
+									"neighbor": [
+										{
+											"neighbor-address": "192.0.2.1",
+											"config": {"neighbor-address": "192.0.2.1", "peer-as": 65002},
+											"apply-policy": {"config": {"import-policy": ["DEFAULT:IN", "SPINE:IN"]}}
+										},
+										{
+											"neighbor-address": "192.0.2.3",
+											"config": {"neighbor-address": "192.0.2.3", "peer-as": 65003}
+										}
+									]
+								}
+							}
+						}
+					]
+				}
+			}
+		]
+	}
+}`
+
+var managedPaths = []string{"/network-instances/network-instance/protocols/protocol/bgp", "/routing-policy"}
+
+func TestDiff(t *testing.T) {
+	bgpPath := "/network-instances/network-instance[name=default]/protocols/protocol[identifier=BGP][name=bgp]/bgp"
+	want := []*drift.Difference{
+		{
+			Path:      bgpPath + "/neighbors/neighbor[neighbor-address=192.0.2.1]/config/peer-as",
+			Type:      drift.Changed,
+			Generated: "65001",
+			Running:   "65002",
+		},
+		{
+			Path:    bgpPath + "/neighbors/neighbor[neighbor-address=192.0.2.3]/config/neighbor-address",
+			Type:    drift.Unexpected,
+			Running: "192.0.2.3",
+		},
+		{
+			Path:    bgpPath + "/neighbors/neighbor[neighbor-address=192.0.2.3]/config/peer-as",
+			Type:    drift.Unexpected,
+			Running: "65003",
+		},
+		{
+			Path:      "/routing-policy/policy-definitions/policy-definition[name=SPINE:IN]/config/name",
+			Type:      drift.Missing,
+			Generated: "SPINE:IN",
+		},
+	}
+
+	out, err := drift.Diff(generatedConfig, runningConfig, managedPaths)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff := cmp.Diff(out, want); diff != "" {
+		t.Errorf("unexpected diff: %s\n", diff)
+	}
+}
+
+func TestDiffStatementsOrder(t *testing.T) {
+	newPolicy := func(statements ...string) string {
+		var entries []string
+		for _, statement := range statements {
+			entries = append(entries, `{"name": "`+statement+`", "config": {"name": "`+statement+`"}}`)
+		}
+		return `{"openconfig-routing-policy:routing-policy": {"policy-definitions": {"policy-definition": [
+			{"name": "SPINE:IN", "config": {"name": "SPINE:IN"}, "statements": {"statement": [` + strings.Join(entries, ", ") + `]}}
+		]}}}`
+	}
+
+	// the statements are evaluated in order, a reordering changes the policy
+	out, err := drift.Diff(newPolicy("DENY", "ACCEPT"), newPolicy("ACCEPT", "DENY"), managedPaths)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []*drift.Difference{
+		{
+			Path:      "/routing-policy/policy-definitions/policy-definition[name=SPINE:IN]/statements/statement",
+			Type:      drift.Changed,
+			Generated: []string{"[name=DENY]", "[name=ACCEPT]"},
+			Running:   []string{"[name=ACCEPT]", "[name=DENY]"},
+		},
+	}
+	if diff := cmp.Diff(out, want); diff != "" {
+		t.Errorf("unexpected diff: %s\n", diff)
+	}
+}
+
+func TestDiffInvalidJSON(t *testing.T) {
+	if _, err := drift.Diff(generatedConfig, "{", managedPaths); err == nil {
+		t.Error("expected an error for an invalid running configuration")
+	}
+}
@@ -0,0 +1,231 @@
+package drift
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/criteo/data-aggregation-api/internal/config"
+)
+
+// AFKEnabledDevices lists the devices whose running configuration is collected.
+type AFKEnabledDevices interface {
+	ListAFKEnabledDevices() []string
+}
+
+// Collector fetches the running OpenConfig of the devices with gNMI Get and checks it for drift.
+type Collector struct {
+	detector *Detector
+	devices  AFKEnabledDevices
+	settings config.GNMIConfig
+	paths    []*gnmi.Path
+}
+
+// NewCollector creates a collector getting the top-level containers of the managed paths.
+func NewCollector(detector *Detector, devices AFKEnabledDevices, settings config.GNMIConfig) *Collector {
+	collector := &Collector{detector: detector, devices: devices, settings: settings}
+
+	seen := make(map[string]bool)
+	for _, managed := range detector.managedPaths {
+		container, _, _ := strings.Cut(strings.TrimPrefix(managed, "/"), "/")
+		if container == "" || seen[container] {
+			continue
+		}
+		seen[container] = true
+		collector.paths = append(collector.paths, &gnmi.Path{Elem: []*gnmi.PathElem{{Name: container}}})
+	}
+
+	return collector
+}
+
+func (c *Collector) transportCredentials() credentials.TransportCredentials {
+	if c.settings.Plaintext {
+		return insecure.NewCredentials()
+	}
+	return credentials.NewTLS(&tls.Config{InsecureSkipVerify: c.settings.InsecureSkipVerify}) //nolint:gosec // opt-in for lab devices
+}
+
+// mergeMembers merges the members of a RFC7951 object into a container, the nested containers being merged too.
+func mergeMembers(container map[string]any, members map[string]any) {
+	for member, value := range members {
+		child, isContainer := container[member].(map[string]any)
+		valueMembers, isObject := value.(map[string]any)
+		if isContainer && isObject {
+			mergeMembers(child, valueMembers)
+			continue
+		}
+		container[member] = value
+	}
+}
+
+// childContainer returns the named container of the current one, creating it if needed.
+func childContainer(current map[string]any, name string) map[string]any {
+	child, _ := current[name].(map[string]any)
+	if child == nil {
+		child = make(map[string]any)
+		current[name] = child
+	}
+	return child
+}
+
+// setAtPath sets a RFC7951 value at a gNMI path of the tree, creating the containers and list entries.
+// An empty path is the root of the tree, whose value is merged like any other container.
+func setAtPath(tree map[string]any, elems []*gnmi.PathElem, value any) error {
+	if len(elems) == 0 {
+		members, ok := value.(map[string]any)
+		if !ok {
+			return errors.New("the value at the root path must be a JSON object")
+		}
+		mergeMembers(tree, members)
+		return nil
+	}
+
+	current := tree
+	for i, elem := range elems {
+		last := i == len(elems)-1
+
+		if len(elem.GetKey()) == 0 {
+			if !last {
+				current = childContainer(current, elem.GetName())
+				continue
+			}
+			if members, ok := value.(map[string]any); ok {
+				mergeMembers(childContainer(current, elem.GetName()), members)
+			} else {
+				current[elem.GetName()] = value
+			}
+			return nil
+		}
+
+		entries, _ := current[elem.GetName()].([]any)
+		var entry map[string]any
+		for _, item := range entries {
+			candidate, _ := item.(map[string]any)
+			if candidate != nil && hasKeys(candidate, elem.GetKey()) {
+				entry = candidate
+				break
+			}
+		}
+		if entry == nil {
+			entry = make(map[string]any, len(elem.GetKey()))
+			for key, keyValue := range elem.GetKey() {
+				entry[key] = keyValue
+			}
+			current[elem.GetName()] = append(entries, entry)
+		}
+		if !last {
+			current = entry
+			continue
+		}
+		members, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("the value of the %s list entry must be a JSON object", elem.GetName())
+		}
+		mergeMembers(entry, members)
+	}
+
+	return nil
+}
+
+func hasKeys(entry map[string]any, keys map[string]string) bool {
+	for key, value := range keys {
+		if fmt.Sprint(entry[key]) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Fetch gets the running configuration of the managed containers from a gNMI target, as RFC7951 JSON.
+func (c *Collector) Fetch(ctx context.Context, address string) (string, error) {
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(c.transportCredentials()))
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, c.settings.Timeout)
+	defer cancel()
+	if c.settings.Username != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "username", c.settings.Username, "password", c.settings.Password)
+	}
+
+	response, err := gnmi.NewGNMIClient(conn).Get(ctx, &gnmi.GetRequest{
+		Path:     c.paths,
+		Type:     gnmi.GetRequest_CONFIG,
+		Encoding: gnmi.Encoding_JSON_IETF,
+	})
+	if err != nil {
+		return "", fmt.Errorf("gNMI Get failed on %s: %w", address, err)
+	}
+
+	tree := make(map[string]any)
+	for _, notification := range response.GetNotification() {
+		for _, update := range notification.GetUpdate() {
+			raw := update.GetVal().GetJsonIetfVal()
+			if raw == nil {
+				raw = update.GetVal().GetJsonVal()
+			}
+
+			decoder := json.NewDecoder(strings.NewReader(string(raw)))
+			decoder.UseNumber()
+			var value any
+			if err := decoder.Decode(&value); err != nil {
+				return "", fmt.Errorf("invalid JSON value from %s: %w", address, err)
+			}
+
+			// copy the prefix, appending to it could overwrite the elements shared by the updates
+			elems := slices.Concat(notification.GetPrefix().GetElem(), update.GetPath().GetElem())
+			if err := setAtPath(tree, elems, value); err != nil {
+				return "", fmt.Errorf("invalid update from %s: %w", address, err)
+			}
+		}
+	}
+
+	out, err := json.Marshal(tree)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// Collect fetches and checks the running configuration of a device.
+func (c *Collector) Collect(ctx context.Context, hostname string) (*Status, error) {
+	running, err := c.Fetch(ctx, net.JoinHostPort(hostname, strconv.Itoa(c.settings.Port)))
+	if err != nil {
+		c.detector.CollectFailed(hostname, GNMISource, err)
+		return nil, err
+	}
+	return c.detector.Check(hostname, GNMISource, running)
+}
+
+// Run collects the running configuration of every AFK enabled device at each interval, until the context is done.
+func (c *Collector) Run(ctx context.Context) {
+	for {
+		for _, hostname := range c.devices.ListAFKEnabledDevices() {
+			if _, err := c.Collect(ctx, hostname); err != nil {
+				log.Warn().Err(err).Str("hostname", hostname).Msg("drift check failed")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.settings.Interval):
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package drift_test
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/criteo/data-aggregation-api/internal/config"
+	"github.com/criteo/data-aggregation-api/internal/convertor/device"
+	"github.com/criteo/data-aggregation-api/internal/drift"
+	"github.com/criteo/data-aggregation-api/internal/metrics"
+)
+
+var registry = metrics.NewDriftRegistry()
+
+// fakeTarget is a gNMI target answering Get requests with a fixed value per top-level container,
+// or with a single update at the root path when root is set.
+type fakeTarget struct {
+	gnmi.UnimplementedGNMIServer
+	containers map[string]string
+	root       string
+	username   string
+}
+
+func (f *fakeTarget) Get(ctx context.Context, request *gnmi.GetRequest) (*gnmi.GetResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if usernames := md.Get("username"); len(usernames) == 0 || usernames[0] != f.username {
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+	if request.GetEncoding() != gnmi.Encoding_JSON_IETF {
+		return nil, status.Error(codes.Unimplemented, "unsupported encoding")
+	}
+
+	notification := &gnmi.Notification{Prefix: &gnmi.Path{}}
+	if f.root != "" {
+		notification.Update = append(notification.Update, &gnmi.Update{
+			Path: &gnmi.Path{},
+			Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonIetfVal{JsonIetfVal: []byte(f.root)}},
+		})
+		return &gnmi.GetResponse{Notification: []*gnmi.Notification{notification}}, nil
+	}
+	for _, path := range request.GetPath() {
+		value, ok := f.containers[path.GetElem()[0].GetName()]
+		if !ok {
+			continue
+		}
+		notification.Update = append(notification.Update, &gnmi.Update{
+			Path: path,
+			Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonIetfVal{JsonIetfVal: []byte(value)}},
+		})
+	}
+	return &gnmi.GetResponse{Notification: []*gnmi.Notification{notification}}, nil
+}
+
+func startFakeTarget(t *testing.T, target *fakeTarget) int {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %s", err)
+	}
+	server := grpc.NewServer()
+	gnmi.RegisterGNMIServer(server, target)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// generatedConfigs serves the generated configuration of the devices of the test.
+type generatedConfigs map[string]string
+
+func (g generatedConfigs) GetOpenConfigJSON(hostname string) (string, error) {
+	if config, ok := g[hostname]; ok {
+		return config, nil
+	}
+	return "", device.ErrNotFound
+}
+
+func (g generatedConfigs) ListAFKEnabledDevices() []string {
+	var hostnames []string
+	for hostname := range g {
+		hostnames = append(hostnames, hostname)
+	}
+	return hostnames
+}
+
+func TestCollect(t *testing.T) {
+	// the running configuration only differs by a neighbor peer-as
+	target := &fakeTarget{
+		username: "afk",
+		containers: map[string]string{
+			"network-instances": `{"network-instance": [{"name": "default", "protocols": {"protocol": [{"identifier": "openconfig-policy-types:BGP", "name": "bgp",
+				"bgp": {"global": {"config": {"as": 65000}}, "neighbors": {"neighbor": [{"neighbor-address": "192.0.2.1",
+				"config": {"neighbor-address": "192.0.2.1", "peer-as": 65002}, "apply-policy": {"config": {"import-policy": ["SPINE:IN", "DEFAULT:IN"]}}}]}}}]}}]}`,
+			"routing-policy": `{"policy-definitions": {"policy-definition": [{"name": "SPINE:IN", "config": {"name": "SPINE:IN"}}]}}`,
+		},
+	}
+	port := startFakeTarget(t, target)
+
+	detector := drift.NewDetector(generatedConfigs{"127.0.0.1": generatedConfig}, managedPaths, registry)
+	settings := config.GNMIConfig{Port: port, Username: "afk", Password: "secret", Plaintext: true, Timeout: 5 * time.Second}
+	collector := drift.NewCollector(detector, generatedConfigs{"127.0.0.1": generatedConfig}, settings)
+
+	result, err := collector.Collect(context.Background(), "127.0.0.1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if result.Source != drift.GNMISource || !result.Drifted || len(result.Differences) != 1 {
+		t.Fatalf("expected one difference from gNMI, got %+v", result)
+	}
+	if !strings.HasSuffix(result.Differences[0].Path, "/config/peer-as") || result.Differences[0].Type != drift.Changed {
+		t.Errorf("unexpected difference: %+v", result.Differences[0])
+	}
+
+	// the previous differences are kept when the collect fails
+	settings.Username = "unknown"
+	failingCollector := drift.NewCollector(detector, generatedConfigs{}, settings)
+	if _, err := failingCollector.Collect(context.Background(), "127.0.0.1"); err == nil {
+		t.Fatal("expected an authentication error")
+	}
+
+	out, err := detector.GetDriftJSON("127.0.0.1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(string(out), "invalid credentials") || !strings.Contains(string(out), "peer-as") {
+		t.Errorf("unexpected drift status: %s", out)
+	}
+}
+
+func TestCollectRootUpdate(t *testing.T) {
+	// some targets answer with a single update at the root path, holding every requested container
+	target := &fakeTarget{
+		username: "afk",
+		root: `{"openconfig-network-instance:network-instances": {"network-instance": [{"name": "default", "protocols": {"protocol": [{"identifier": "openconfig-policy-types:BGP", "name": "bgp",
+			"bgp": {"global": {"config": {"as": 65000}}, "neighbors": {"neighbor": [{"neighbor-address": "192.0.2.1",
+			"config": {"neighbor-address": "192.0.2.1", "peer-as": 65002}, "apply-policy": {"config": {"import-policy": ["SPINE:IN", "DEFAULT:IN"]}}}]}}}]}}]},
+			"openconfig-routing-policy:routing-policy": {"policy-definitions": {"policy-definition": [{"name": "SPINE:IN", "config": {"name": "SPINE:IN"}}]}}}`,
+	}
+	port := startFakeTarget(t, target)
+
+	detector := drift.NewDetector(generatedConfigs{"127.0.0.1": generatedConfig}, managedPaths, registry)
+	settings := config.GNMIConfig{Port: port, Username: "afk", Plaintext: true, Timeout: 5 * time.Second}
+	collector := drift.NewCollector(detector, generatedConfigs{"127.0.0.1": generatedConfig}, settings)
+
+	result, err := collector.Collect(context.Background(), "127.0.0.1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !result.Drifted || len(result.Differences) != 1 || !strings.HasSuffix(result.Differences[0].Path, "/config/peer-as") {
+		t.Fatalf("expected only the peer-as difference from the root update, got %+v", result)
+	}
+
+	// a root value which is not a JSON object cannot be merged, it must not be silently dropped
+	settings.Port = startFakeTarget(t, &fakeTarget{username: "afk", root: `42`})
+	scalarCollector := drift.NewCollector(detector, generatedConfigs{"127.0.0.1": generatedConfig}, settings)
+	if _, err := scalarCollector.Collect(context.Background(), "127.0.0.1"); err == nil {
+		t.Error("expected an error for a scalar root value")
+	}
+}
+
+func TestCheckRunningJSON(t *testing.T) {
+	detector := drift.NewDetector(generatedConfigs{"tor01-01": generatedConfig}, managedPaths, registry)
+
+	if _, err := detector.CheckRunningJSON("unknown", []byte(runningConfig)); err == nil {
+		t.Error("expected an error for an unknown device")
+	}
+	if _, err := detector.GetDriftJSON("tor01-01"); err == nil {
+		t.Error("expected an error for a device never checked")
+	}
+	if _, err := detector.CheckRunningJSON("tor01-01", []byte(generatedConfig)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	out, err := detector.GetDriftJSON("tor01-01")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(string(out), `"drifted":false`) || !strings.Contains(string(out), `"source":"push"`) {
+		t.Errorf("unexpected drift status: %s", out)
+	}
+}
@@ -0,0 +1,117 @@
+package evaluator
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/criteo/data-aggregation-api/internal/model/openconfig"
+)
+
+// updateCommunities applies a set-community option to the route communities.
+// Removed communities can be regular expressions.
+func updateCommunities(option openconfig.E_BgpPolicy_BgpSetCommunityOptionType, current []string, communities []string) ([]string, error) {
+	switch option {
+	case openconfig.BgpPolicy_BgpSetCommunityOptionType_ADD:
+		for _, community := range communities {
+			if !slices.Contains(current, community) {
+				current = append(current, community)
+			}
+		}
+		return current, nil
+	case openconfig.BgpPolicy_BgpSetCommunityOptionType_REMOVE:
+		var kept []string
+		for _, community := range current {
+			matched, err := matchAny(communities, []string{community})
+			if err != nil {
+				return nil, err
+			}
+			if !matched {
+				kept = append(kept, community)
+			}
+		}
+		return kept, nil
+	default:
+		return slices.Clone(communities), nil
+	}
+}
+
+func (e *evaluation) setCommunities(action *openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions_SetCommunity, route *Route) error {
+	var communities []string
+	switch action.Method {
+	case openconfig.SetCommunity_Method_REFERENCE:
+		if action.Reference == nil || action.Reference.CommunitySetRef == nil {
+			return errors.New("set-community by reference without community-set")
+		}
+		set, ok := e.bgpDefinedSets().CommunitySet[*action.Reference.CommunitySetRef]
+		if !ok {
+			return fmt.Errorf("unknown community-set %s", *action.Reference.CommunitySetRef)
+		}
+		communities = communityMembers(set.CommunityMember)
+	default:
+		if action.Inline != nil {
+			communities = communityMembers(action.Inline.Communities)
+		}
+	}
+
+	var err error
+	route.Communities, err = updateCommunities(action.Options, route.Communities, communities)
+	return err
+}
+
+// applyActions updates the route attributes with the BGP actions of a matched statement.
+func (e *evaluation) applyActions(actions *openconfig.RoutingPolicy_PolicyDefinition_Statement_Actions_BgpActions, route *Route) error {
+	if actions == nil {
+		return nil
+	}
+
+	if origin, ok := origins[actions.SetRouteOrigin]; ok {
+		route.Origin = origin
+	}
+
+	if actions.SetLocalPref != nil {
+		localPref := *actions.SetLocalPref
+		route.LocalPref = &localPref
+	}
+
+	if med, ok := actions.SetMed.(openconfig.UnionUint32); ok {
+		value := uint32(med)
+		route.MED = &value
+	}
+
+	switch nextHop := actions.SetNextHop.(type) {
+	case openconfig.UnionString:
+		route.NextHop = string(nextHop)
+	case openconfig.E_BgpPolicy_BgpNextHopType_Enum:
+		if nextHop == openconfig.BgpPolicy_BgpNextHopType_Enum_SELF {
+			route.NextHop = "self"
+		}
+	}
+
+	if prepend := actions.SetAsPathPrepend; prepend != nil && prepend.Asn != nil {
+		repeat := 1
+		if prepend.RepeatN != nil {
+			repeat = int(*prepend.RepeatN)
+		}
+		route.ASPath = append(slices.Repeat([]uint32{*prepend.Asn}, repeat), route.ASPath...)
+	}
+
+	if actions.SetCommunity != nil {
+		if err := e.setCommunities(actions.SetCommunity, route); err != nil {
+			return err
+		}
+	}
+
+	if action := actions.SetExtCommunity; action != nil && action.Inline != nil {
+		var communities []string
+		for _, community := range communityMembers(action.Inline.Communities) {
+			communities = append(communities, normalizeExtCommunity(community))
+		}
+		var err error
+		if route.ExtCommunities, err = updateCommunities(action.Options, route.ExtCommunities, communities); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
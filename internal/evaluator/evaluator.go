@@ -0,0 +1,387 @@
+// Package evaluator simulates routes against the generated OpenConfig route-policies.
+package evaluator
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/routingpolicy"
+	"github.com/criteo/data-aggregation-api/internal/model/openconfig"
+)
+
+// maxCallDepth bounds nested call-policy conditions.
+const maxCallDepth = 16
+
+var ErrPolicyNotFound = errors.New("route-policy not found")
+var ErrInvalidRoute = errors.New("invalid route")
+
+type Decision string
+
+const (
+	Accept Decision = "accept"
+	Reject Decision = "reject"
+	// NoDecision means no statement accepted or rejected the route: the default policy of the session applies.
+	NoDecision Decision = "none"
+)
+
+// Result is the outcome of a route evaluation.
+type Result struct {
+	Policy   string   `json:"policy"`
+	Decision Decision `json:"decision"`
+	// Statement is the statement which accepted or rejected the route.
+	Statement string `json:"statement,omitempty"`
+	// MatchedStatements lists every statement whose conditions matched, in evaluation order.
+	MatchedStatements []string `json:"matched_statements"`
+	// Route holds the route attributes once the actions of the matched statements are applied.
+	Route *Route `json:"route"`
+}
+
+var installProtocols = map[openconfig.E_PolicyTypes_INSTALL_PROTOCOL_TYPE]routingpolicy.RoutingProtocols{
+	openconfig.PolicyTypes_INSTALL_PROTOCOL_TYPE_BGP:                routingpolicy.BGP,
+	openconfig.PolicyTypes_INSTALL_PROTOCOL_TYPE_STATIC:             routingpolicy.Static,
+	openconfig.PolicyTypes_INSTALL_PROTOCOL_TYPE_DIRECTLY_CONNECTED: routingpolicy.Connected,
+	openconfig.PolicyTypes_INSTALL_PROTOCOL_TYPE_ISIS:               routingpolicy.ISIS,
+	openconfig.PolicyTypes_INSTALL_PROTOCOL_TYPE_OSPF:               routingpolicy.OSPF,
+}
+
+var origins = map[openconfig.E_BgpTypes_BgpOriginAttrType]routingpolicy.RouteProtocolOrigin{
+	openconfig.BgpTypes_BgpOriginAttrType_IGP:        routingpolicy.OriginIGP,
+	openconfig.BgpTypes_BgpOriginAttrType_EGP:        routingpolicy.OriginEGP,
+	openconfig.BgpTypes_BgpOriginAttrType_INCOMPLETE: routingpolicy.OriginIncomplete,
+}
+
+// evaluation holds the state of one route evaluation.
+type evaluation struct {
+	config *openconfig.RoutingPolicy
+	prefix *net.IPNet
+}
+
+// Evaluate runs the route through the named policy definition, statement by statement,
+// until one of them accepts or rejects the route.
+func Evaluate(config *openconfig.RoutingPolicy, policyName string, route *Route) (*Result, error) {
+	_, prefix, err := net.ParseCIDR(route.Prefix)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRoute, err)
+	}
+
+	working := route.clone()
+	working.normalize()
+
+	e := &evaluation{config: config, prefix: prefix}
+	return e.evaluatePolicy(policyName, working, 0)
+}
+
+func (e *evaluation) evaluatePolicy(policyName string, route *Route, depth int) (*Result, error) {
+	if depth > maxCallDepth {
+		return nil, fmt.Errorf("route-policy %s: too many nested call-policy", policyName)
+	}
+
+	if e.config == nil {
+		return nil, fmt.Errorf("%w: %s", ErrPolicyNotFound, policyName)
+	}
+	policy, ok := e.config.PolicyDefinition[policyName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPolicyNotFound, policyName)
+	}
+
+	result := &Result{Policy: policyName, Decision: NoDecision, MatchedStatements: []string{}, Route: route}
+	if policy.Statement == nil {
+		return result, nil
+	}
+
+	for _, name := range policy.Statement.Keys() {
+		statement := policy.Statement.Get(name)
+
+		candidate := route.clone()
+		matched, err := e.matchConditions(statement.Conditions, candidate, depth)
+		if err != nil {
+			return nil, fmt.Errorf("route-policy %s statement %s: %w", policyName, name, err)
+		}
+		if !matched {
+			continue
+		}
+
+		result.MatchedStatements = append(result.MatchedStatements, name)
+		if statement.Actions == nil {
+			route = candidate
+			continue
+		}
+
+		if err := e.applyActions(statement.Actions.BgpActions, candidate); err != nil {
+			return nil, fmt.Errorf("route-policy %s statement %s: %w", policyName, name, err)
+		}
+		route = candidate
+
+		switch statement.Actions.PolicyResult {
+		case openconfig.RoutingPolicy_PolicyResultType_ACCEPT_ROUTE:
+			result.Decision, result.Statement = Accept, name
+		case openconfig.RoutingPolicy_PolicyResultType_REJECT_ROUTE:
+			result.Decision, result.Statement = Reject, name
+		}
+		if result.Decision != NoDecision {
+			break
+		}
+	}
+
+	result.Route = route
+	return result, nil
+}
+
+// matchConditions tells if the route matches every condition of a statement.
+// A called policy may update the route, which is why the caller passes a copy.
+func (e *evaluation) matchConditions(conditions *openconfig.RoutingPolicy_PolicyDefinition_Statement_Conditions, route *Route, depth int) (bool, error) {
+	if conditions == nil {
+		return true, nil
+	}
+
+	if protocol, ok := installProtocols[conditions.InstallProtocolEq]; ok && protocol != route.SourceProtocol {
+		return false, nil
+	}
+
+	if conditions.MatchPrefixSet != nil && conditions.MatchPrefixSet.PrefixSet != nil {
+		matched, err := e.matchPrefixSet(*conditions.MatchPrefixSet.PrefixSet)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	if conditions.MatchTagSet != nil && conditions.MatchTagSet.TagSet != nil {
+		matched, err := e.matchTagSet(*conditions.MatchTagSet.TagSet, route)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	if conditions.BgpConditions != nil {
+		matched, err := e.matchBGPConditions(conditions.BgpConditions, route)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	// The called policy is evaluated last: its actions only apply once every other condition matched
+	if conditions.CallPolicy != nil {
+		called, err := e.evaluatePolicy(*conditions.CallPolicy, route, depth+1)
+		if err != nil {
+			return false, err
+		}
+		if called.Decision != Accept {
+			return false, nil
+		}
+		*route = *called.Route
+	}
+
+	return true, nil
+}
+
+// parseMaskLengthRange parses the OpenConfig mask length range ("exact" or "min..max").
+func parseMaskLengthRange(maskLengthRange string, prefixLength int) (int, int, error) {
+	if maskLengthRange == "exact" {
+		return prefixLength, prefixLength, nil
+	}
+
+	minLength, maxLength, found := strings.Cut(maskLengthRange, "..")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid mask length range: %s", maskLengthRange)
+	}
+	lower, err := strconv.Atoi(minLength)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid mask length range: %s", maskLengthRange)
+	}
+	upper, err := strconv.Atoi(maxLength)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid mask length range: %s", maskLengthRange)
+	}
+	return lower, upper, nil
+}
+
+func (e *evaluation) matchPrefixSet(name string) (bool, error) {
+	if e.config.DefinedSets == nil || e.config.DefinedSets.PrefixSet[name] == nil {
+		return false, fmt.Errorf("unknown prefix-set %s", name)
+	}
+
+	routeLength, routeBits := e.prefix.Mask.Size()
+	for _, entry := range e.config.DefinedSets.PrefixSet[name].Prefix {
+		if entry.IpPrefix == nil {
+			continue
+		}
+		_, setPrefix, err := net.ParseCIDR(*entry.IpPrefix)
+		if err != nil {
+			return false, fmt.Errorf("prefix-set %s: %w", name, err)
+		}
+
+		setLength, setBits := setPrefix.Mask.Size()
+		if setBits != routeBits || setLength > routeLength || !setPrefix.Contains(e.prefix.IP) {
+			continue
+		}
+
+		maskLengthRange := "exact"
+		if entry.MasklengthRange != nil {
+			maskLengthRange = *entry.MasklengthRange
+		}
+		minLength, maxLength, err := parseMaskLengthRange(maskLengthRange, setLength)
+		if err != nil {
+			return false, fmt.Errorf("prefix-set %s: %w", name, err)
+		}
+		if minLength <= routeLength && routeLength <= maxLength {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (e *evaluation) matchTagSet(name string, route *Route) (bool, error) {
+	if e.config.DefinedSets == nil || e.config.DefinedSets.TagSet[name] == nil {
+		return false, fmt.Errorf("unknown tag-set %s", name)
+	}
+	if route.Tag == nil {
+		return false, nil
+	}
+
+	for _, value := range e.config.DefinedSets.TagSet[name].TagValue {
+		if tag, ok := value.(openconfig.UnionUint32); ok && uint32(tag) == *route.Tag {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (e *evaluation) bgpDefinedSets() *openconfig.RoutingPolicy_DefinedSets_BgpDefinedSets {
+	if e.config.DefinedSets == nil || e.config.DefinedSets.BgpDefinedSets == nil {
+		return &openconfig.RoutingPolicy_DefinedSets_BgpDefinedSets{}
+	}
+	return e.config.DefinedSets.BgpDefinedSets
+}
+
+func (e *evaluation) matchBGPConditions(conditions *openconfig.RoutingPolicy_PolicyDefinition_Statement_Conditions_BgpConditions, route *Route) (bool, error) {
+	// BGP conditions never match routes learnt from another protocol
+	hasBGPCondition := conditions.MedEq != nil || conditions.LocalPrefEq != nil || conditions.OriginEq != openconfig.BgpTypes_BgpOriginAttrType_UNSET ||
+		conditions.CommunitySet != nil || conditions.ExtCommunitySet != nil ||
+		conditions.MatchAsPathSet != nil || len(conditions.NextHopIn) > 0 || len(conditions.AfiSafiIn) > 0
+	if hasBGPCondition && route.SourceProtocol != routingpolicy.BGP {
+		return false, nil
+	}
+
+	if conditions.MedEq != nil && (route.MED == nil || *route.MED != *conditions.MedEq) {
+		return false, nil
+	}
+	if conditions.LocalPrefEq != nil && (route.LocalPref == nil || *route.LocalPref != *conditions.LocalPrefEq) {
+		return false, nil
+	}
+	if origin, ok := origins[conditions.OriginEq]; ok && origin != route.Origin {
+		return false, nil
+	}
+	if len(conditions.NextHopIn) > 0 && !slices.Contains(conditions.NextHopIn, route.NextHop) {
+		return false, nil
+	}
+	if len(conditions.AfiSafiIn) > 0 && !slices.Contains(conditions.AfiSafiIn, e.afiSafi()) {
+		return false, nil
+	}
+
+	sets := e.bgpDefinedSets()
+
+	if conditions.CommunitySet != nil {
+		set, ok := sets.CommunitySet[*conditions.CommunitySet]
+		if !ok {
+			return false, fmt.Errorf("unknown community-set %s", *conditions.CommunitySet)
+		}
+		if matched, err := matchAny(communityMembers(set.CommunityMember), route.Communities); err != nil || !matched {
+			return false, err
+		}
+	}
+
+	if conditions.ExtCommunitySet != nil {
+		set, ok := sets.ExtCommunitySet[*conditions.ExtCommunitySet]
+		if !ok {
+			return false, fmt.Errorf("unknown ext-community-set %s", *conditions.ExtCommunitySet)
+		}
+		if matched, err := matchAny(set.ExtCommunityMember, route.ExtCommunities); err != nil || !matched {
+			return false, err
+		}
+	}
+
+	if conditions.MatchAsPathSet != nil && conditions.MatchAsPathSet.AsPathSet != nil {
+		set, ok := sets.AsPathSet[*conditions.MatchAsPathSet.AsPathSet]
+		if !ok {
+			return false, fmt.Errorf("unknown as-path-set %s", *conditions.MatchAsPathSet.AsPathSet)
+		}
+		if matched, err := matchASPath(set.AsPathSetMember, route.ASPath); err != nil || !matched {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func (e *evaluation) afiSafi() openconfig.E_BgpTypes_AFI_SAFI_TYPE {
+	if e.prefix.IP.To4() != nil {
+		return openconfig.BgpTypes_AFI_SAFI_TYPE_IPV4_UNICAST
+	}
+	return openconfig.BgpTypes_AFI_SAFI_TYPE_IPV6_UNICAST
+}
+
+// communityMembers returns the community-set members, numeric communities being formatted as "asn:value".
+func communityMembers[T any](members []T) []string {
+	var values []string
+	for _, member := range members {
+		switch value := any(member).(type) {
+		case openconfig.UnionString:
+			values = append(values, string(value))
+		case openconfig.UnionUint32:
+			values = append(values, fmt.Sprintf("%d:%d", uint32(value)>>16, uint32(value)&0xffff))
+		}
+	}
+	return values
+}
+
+// matchMember tells if a community matches a set member, which can be a regular expression.
+func matchMember(member string, community string) (bool, error) {
+	if member == community {
+		return true, nil
+	}
+	re, err := regexp.Compile("^(?:" + member + ")$")
+	if err != nil {
+		return false, fmt.Errorf("invalid community member %s: %w", member, err)
+	}
+	return re.MatchString(community), nil
+}
+
+// matchAny tells if any route community matches any member of the set.
+func matchAny(members []string, communities []string) (bool, error) {
+	for _, member := range members {
+		for _, community := range communities {
+			matched, err := matchMember(member, community)
+			if err != nil || matched {
+				return matched, err
+			}
+		}
+	}
+	return false, nil
+}
+
+// matchASPath matches the AS-path against the set regular expressions,
+// where "_" stands for an AS boundary (start, end or space between two AS).
+func matchASPath(members []string, asPath []uint32) (bool, error) {
+	path := make([]string, 0, len(asPath))
+	for _, asn := range asPath {
+		path = append(path, strconv.FormatUint(uint64(asn), 10))
+	}
+	pathString := strings.Join(path, " ")
+
+	for _, member := range members {
+		re, err := regexp.Compile(strings.ReplaceAll(member, "_", "(?:^| |$)"))
+		if err != nil {
+			return false, fmt.Errorf("invalid as-path-set member %s: %w", member, err)
+		}
+		if re.MatchString(pathString) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
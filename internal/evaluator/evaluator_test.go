@@ -0,0 +1,153 @@
+package evaluator_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	rpconvertors "github.com/criteo/data-aggregation-api/internal/convertor/routingpolicy"
+	"github.com/criteo/data-aggregation-api/internal/evaluator"
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/routingpolicy"
+	"github.com/criteo/data-aggregation-api/internal/model/openconfig"
+)
+
+const prefixLists = `
+[
+	{
+		"name": "SERVERS",
+		"device": {"name": "tor01-01"},
+		"ip_version": "ipv4",
+		"terms": [{"prefix": "192.0.2.0/24", "le": 32, "ge": null}]
+	}
+]`
+
+const communityLists = `
+[
+	{
+		"name": "BLACKHOLE",
+		"device": {"name": "tor01-01"},
+		"terms": [{"community": "65535:666"}]
+	}
+]`
+
+const routePolicies = `
+[
+	{
+		"name": "SERVERS:IN",
+		"device": {"name": "tor01-01"},
+		"default_result": "deny",
+		"terms": [
+			{
+				"sequence": 10,
+				"decision": "deny",
+				"from_route_type": "",
+				"from_bgp_community_list": {"name": "BLACKHOLE"}
+			},
+			{
+				"sequence": 20,
+				"decision": "permit",
+				"from_route_type": "",
+				"from_prefix_list": {"name": "SERVERS"},
+				"set_local_pref": 200,
+				"set_community": "65000:100",
+				"set_community_option": "add",
+				"set_as_path_prepend_asn": {"number": 65000},
+				"set_as_path_prepend_repeat": 2
+			}
+		]
+	}
+]`
+
+func loadRoutingPolicy(t *testing.T) *openconfig.RoutingPolicy {
+	t.Helper()
+
+	var prefixListsData []*routingpolicy.PrefixList
+	var communityListsData []*routingpolicy.CommunityList
+	var routePoliciesData []*routingpolicy.RoutePolicy
+	for data, out := range map[string]any{prefixLists: &prefixListsData, communityLists: &communityListsData, routePolicies: &routePoliciesData} {
+		if err := json.Unmarshal([]byte(data), out); err != nil {
+			t.Fatalf("unable to load test data: %s", err)
+		}
+	}
+
+	config, err := rpconvertors.RoutingPolicyToOpenconfig(prefixListsData, communityListsData, nil, routePoliciesData)
+	if err != nil {
+		t.Fatalf("unable to generate the routing policy: %s", err)
+	}
+	return config
+}
+
+func TestEvaluate(t *testing.T) {
+	config := loadRoutingPolicy(t)
+	var localPref200 uint32 = 200
+
+	tests := []struct {
+		name  string
+		route *evaluator.Route
+		want  *evaluator.Result
+	}{
+		{
+			name:  "accepted with actions applied",
+			route: &evaluator.Route{Prefix: "192.0.2.64/26", Communities: []string{"65001:1"}, ASPath: []uint32{65001}},
+			want: &evaluator.Result{
+				Policy:            "SERVERS:IN",
+				Decision:          evaluator.Accept,
+				Statement:         "20",
+				MatchedStatements: []string{"20"},
+				Route: &evaluator.Route{
+					Prefix:         "192.0.2.64/26",
+					SourceProtocol: routingpolicy.BGP,
+					Communities:    []string{"65001:1", "65000:100"},
+					ASPath:         []uint32{65000, 65000, 65001},
+					LocalPref:      &localPref200,
+				},
+			},
+		},
+		{
+			name:  "rejected by community",
+			route: &evaluator.Route{Prefix: "192.0.2.64/26", Communities: []string{"65535:666"}},
+			want: &evaluator.Result{
+				Policy:            "SERVERS:IN",
+				Decision:          evaluator.Reject,
+				Statement:         "10",
+				MatchedStatements: []string{"10"},
+				Route:             &evaluator.Route{Prefix: "192.0.2.64/26", SourceProtocol: routingpolicy.BGP, Communities: []string{"65535:666"}},
+			},
+		},
+		{
+			name:  "rejected by default result",
+			route: &evaluator.Route{Prefix: "198.51.100.0/24"},
+			want: &evaluator.Result{
+				Policy:            "SERVERS:IN",
+				Decision:          evaluator.Reject,
+				Statement:         "21",
+				MatchedStatements: []string{"21"},
+				Route:             &evaluator.Route{Prefix: "198.51.100.0/24", SourceProtocol: routingpolicy.BGP},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		out, err := evaluator.Evaluate(config, "SERVERS:IN", test.route)
+		if err != nil {
+			t.Errorf("unexpected error for '%s': %s", test.name, err)
+			continue
+		}
+		if diff := cmp.Diff(out, test.want); diff != "" {
+			t.Errorf("unexpected diff for '%s': %s\n", test.name, diff)
+		}
+	}
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	config := loadRoutingPolicy(t)
+
+	if _, err := evaluator.Evaluate(config, "UNKNOWN", &evaluator.Route{Prefix: "192.0.2.0/24"}); !errors.Is(err, evaluator.ErrPolicyNotFound) {
+		t.Errorf("expected a policy not found error, got %v", err)
+	}
+	if _, err := evaluator.Evaluate(config, "SERVERS:IN", &evaluator.Route{Prefix: "192.0.2.0"}); !errors.Is(err, evaluator.ErrInvalidRoute) {
+		t.Errorf("expected an invalid route error, got %v", err)
+	}
+}
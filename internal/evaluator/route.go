@@ -0,0 +1,67 @@
+package evaluator
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/routingpolicy"
+)
+
+var extCommunityTypes = map[string]string{
+	"rt:":  "route-target:",
+	"soo:": "route-origin:",
+}
+
+// Route is a simulated route evaluated against a route-policy.
+type Route struct {
+	Prefix         string                            `json:"prefix"`
+	SourceProtocol routingpolicy.RoutingProtocols    `json:"source_protocol,omitempty"`
+	Communities    []string                          `json:"communities,omitempty"`
+	ExtCommunities []string                          `json:"ext_communities,omitempty"`
+	ASPath         []uint32                          `json:"as_path,omitempty"`
+	LocalPref      *uint32                           `json:"local_pref,omitempty"`
+	MED            *uint32                           `json:"med,omitempty"`
+	Origin         routingpolicy.RouteProtocolOrigin `json:"origin,omitempty"`
+	NextHop        string                            `json:"next_hop,omitempty"`
+	Tag            *uint32                           `json:"tag,omitempty"`
+}
+
+// normalizeExtCommunity converts the CMDB extended community format (rt:65000:1) to the OpenConfig one.
+func normalizeExtCommunity(community string) string {
+	for prefix, ocPrefix := range extCommunityTypes {
+		if strings.HasPrefix(community, prefix) {
+			return ocPrefix + strings.TrimPrefix(community, prefix)
+		}
+	}
+	return community
+}
+
+// clone returns a deep copy of the route, so actions of unmatched statements do not leak.
+func (r *Route) clone() *Route {
+	out := *r
+	out.Communities = slices.Clone(r.Communities)
+	out.ExtCommunities = slices.Clone(r.ExtCommunities)
+	out.ASPath = slices.Clone(r.ASPath)
+	if r.LocalPref != nil {
+		localPref := *r.LocalPref
+		out.LocalPref = &localPref
+	}
+	if r.MED != nil {
+		med := *r.MED
+		out.MED = &med
+	}
+	if r.Tag != nil {
+		tag := *r.Tag
+		out.Tag = &tag
+	}
+	return &out
+}
+
+func (r *Route) normalize() {
+	for i, community := range r.ExtCommunities {
+		r.ExtCommunities[i] = normalizeExtCommunity(community)
+	}
+	if r.SourceProtocol == routingpolicy.Unset {
+		r.SourceProtocol = routingpolicy.BGP
+	}
+}
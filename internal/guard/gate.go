@@ -0,0 +1,127 @@
+package guard
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/criteo/data-aggregation-api/internal/config"
+	"github.com/criteo/data-aggregation-api/internal/convertor/device"
+	"github.com/criteo/data-aggregation-api/internal/report"
+)
+
+var ErrNoHeldBuild = errors.New("no held build with this ID")
+
+// Publisher serves the devices configuration of a build.
+type Publisher interface {
+	Set(buildID string, devices map[string]*device.Device)
+}
+
+// Baseline gives the devices configuration currently served, the builds are measured against it.
+type Baseline interface {
+	GetServedDevices() map[string]*device.Device
+}
+
+// HeldBuild is a build waiting for approval before being published.
+type HeldBuild struct {
+	BuildID string    `json:"build_id"`
+	HeldAt  time.Time `json:"held_at"`
+	Impact  *Impact   `json:"impact"`
+	Reasons []string  `json:"reasons"`
+
+	devices map[string]*device.Device
+}
+
+// Gate publishes the builds, unless their blast radius exceeds the thresholds.
+// Only the last held build is kept, a newer build supersedes it. Its methods are concurrent-safe.
+type Gate struct {
+	publisher Publisher
+	baseline  Baseline
+	reports   *report.Repository
+	settings  config.BlastRadiusConfig
+
+	mutex *sync.Mutex
+	held  *HeldBuild
+}
+
+func NewGate(publisher Publisher, baseline Baseline, reports *report.Repository, settings config.BlastRadiusConfig) *Gate {
+	return &Gate{
+		publisher: publisher,
+		baseline:  baseline,
+		reports:   reports,
+		settings:  settings,
+		mutex:     &sync.Mutex{},
+	}
+}
+
+// supersede marks the held build as replaced by a newer build.
+// It must be called with the mutex locked.
+func (g *Gate) supersede() {
+	if g.held == nil {
+		return
+	}
+	g.reports.SetBuildStatus(g.held.BuildID, report.Superseded)
+	g.held = nil
+}
+
+// Submit publishes a build, or holds it when its blast radius exceeds the thresholds.
+// The build is measured against the configuration currently served, so rollout aborts, pins and rollbacks are
+// taken into account. The first build is always published, as there is nothing to compare it with.
+// It returns the held build, nil if published.
+func (g *Gate) Submit(buildID string, devices map[string]*device.Device) *HeldBuild {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.supersede()
+	if g.settings.Enabled {
+		if served := g.baseline.GetServedDevices(); len(served) > 0 {
+			impact := Measure(served, devices)
+			if reasons := impact.Exceeded(g.settings); len(reasons) > 0 {
+				g.held = &HeldBuild{BuildID: buildID, HeldAt: time.Now(), Impact: impact, Reasons: reasons, devices: devices}
+				return g.held
+			}
+		}
+	}
+
+	g.publisher.Set(buildID, devices)
+	return nil
+}
+
+// Approve publishes the held build.
+func (g *Gate) Approve(buildID string) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.held == nil || g.held.BuildID != buildID {
+		return ErrNoHeldBuild
+	}
+	g.publisher.Set(buildID, g.held.devices)
+	g.held = nil
+
+	g.reports.SetBuildStatus(buildID, report.Success)
+	g.reports.MarkBuildAsSuccessful(buildID)
+	return nil
+}
+
+// Reject drops the held build, the last published build keeps being served.
+func (g *Gate) Reject(buildID string) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.held == nil || g.held.BuildID != buildID {
+		return ErrNoHeldBuild
+	}
+	g.held = nil
+
+	g.reports.SetBuildStatus(buildID, report.Rejected)
+	return nil
+}
+
+// GetHeldJSON returns the held build, null if none.
+func (g *Gate) GetHeldJSON() ([]byte, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	return json.Marshal(g.held)
+}
@@ -0,0 +1,185 @@
+package guard_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/criteo/data-aggregation-api/internal/config"
+	"github.com/criteo/data-aggregation-api/internal/convertor/device"
+	"github.com/criteo/data-aggregation-api/internal/guard"
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/bgp"
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/routingpolicy"
+	"github.com/criteo/data-aggregation-api/internal/report"
+	"github.com/criteo/data-aggregation-api/internal/types"
+)
+
+var thresholds = config.BlastRadiusConfig{
+	Enabled:             true,
+	MaxChangedDevices:   0.5,
+	MaxMissingDevices:   0.1,
+	MaxRemovedNeighbors: 0.2,
+	MaxRemovedPolicies:  0.2,
+}
+
+// newDevice creates a built device with one BGP session per peer address.
+func newDevice(t *testing.T, hash string, peers ...string) *device.Device {
+	t.Helper()
+
+	dev := &device.Device{
+		Config:        &device.GeneratedConfig{Hash: hash},
+		RoutePolicies: []*routingpolicy.RoutePolicy{{Name: "SPINE:IN"}},
+	}
+	local, _ := types.ParseCIDR("192.0.2.0/31")
+	for _, peer := range peers {
+		remote, err := types.ParseCIDR(peer)
+		if err != nil {
+			t.Fatalf("invalid peer address: %s", err)
+		}
+		dev.Sessions = append(dev.Sessions, &bgp.Session{
+			PeerA: bgp.DeviceSession{LocalAddress: bgp.Address{Address: local}},
+			PeerB: bgp.DeviceSession{LocalAddress: bgp.Address{Address: remote}},
+		})
+	}
+	return dev
+}
+
+func TestMeasure(t *testing.T) {
+	previous := map[string]*device.Device{
+		"tor01-01": newDevice(t, "a", "192.0.2.1/31", "192.0.2.3/31"),
+		"tor01-02": newDevice(t, "b", "192.0.2.1/31", "192.0.2.3/31"),
+		"tor01-03": newDevice(t, "c"),
+		"tor01-04": newDevice(t, "d"),
+		"tor01-05": nil,
+	}
+	next := map[string]*device.Device{
+		"tor01-01": newDevice(t, "a", "192.0.2.1/31", "192.0.2.3/31"),
+		"tor01-02": newDevice(t, "b2", "192.0.2.1/31"),
+		"tor01-03": nil,
+		"tor01-04": newDevice(t, "d"),
+		"tor01-05": newDevice(t, "e"),
+	}
+
+	want := &guard.Impact{
+		ChangedDevices:   guard.Change{Count: 1, Previous: 4, Ratio: 0.25},
+		MissingDevices:   guard.Change{Count: 1, Previous: 4, Ratio: 0.25},
+		RemovedNeighbors: guard.Change{Count: 1, Previous: 4, Ratio: 0.25},
+		RemovedPolicies:  guard.Change{Count: 1, Previous: 4, Ratio: 0.25},
+	}
+	impact := guard.Measure(previous, next)
+	if diff := cmp.Diff(impact, want); diff != "" {
+		t.Errorf("unexpected diff: %s\n", diff)
+	}
+
+	reasons := impact.Exceeded(thresholds)
+	if len(reasons) != 3 {
+		t.Errorf("expected missing devices, neighbors and policies to exceed the thresholds, got %v", reasons)
+	}
+}
+
+// publisher records the published builds and serves the last one.
+type publisher struct {
+	buildIDs []string
+	served   map[string]*device.Device
+}
+
+func (p *publisher) Set(buildID string, devices map[string]*device.Device) {
+	p.buildIDs = append(p.buildIDs, buildID)
+	p.served = devices
+}
+
+func (p *publisher) GetServedDevices() map[string]*device.Device {
+	return p.served
+}
+
+func TestGate(t *testing.T) {
+	reports := report.NewRepository()
+	published := &publisher{}
+	gate := guard.NewGate(published, published, &reports, thresholds)
+
+	fleet := func(count int) map[string]*device.Device {
+		devices := make(map[string]*device.Device, count)
+		for i := range count {
+			devices[fmt.Sprintf("tor01-%02d", i)] = newDevice(t, "a", "192.0.2.1/31")
+		}
+		return devices
+	}
+
+	// the first build is always published, then half of the fleet disappears
+	if held := gate.Submit("build-1", fleet(10)); held != nil {
+		t.Fatalf("unexpected held build: %+v", held)
+	}
+	if held := gate.Submit("build-2", fleet(5)); held == nil {
+		t.Fatal("expected the build to be held")
+	}
+	if err := gate.Approve("build-1"); err == nil {
+		t.Error("expected an error approving a build not held")
+	}
+	if err := gate.Reject("build-2"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// the rejected build is not used as reference
+	if held := gate.Submit("build-3", fleet(5)); held == nil {
+		t.Fatal("expected the build to be held")
+	}
+	if err := gate.Approve("build-3"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if diff := cmp.Diff(published.buildIDs, []string{"build-1", "build-3"}); diff != "" {
+		t.Errorf("unexpected diff for published builds: %s\n", diff)
+	}
+}
+
+func TestGateBaseline(t *testing.T) {
+	reports := report.NewRepository()
+	published := &publisher{}
+	gate := guard.NewGate(published, published, &reports, thresholds)
+
+	fleet := func(count int) map[string]*device.Device {
+		devices := make(map[string]*device.Device, count)
+		for i := range count {
+			devices[fmt.Sprintf("tor01-%02d", i)] = newDevice(t, "a", "192.0.2.1/31")
+		}
+		return devices
+	}
+
+	if held := gate.Submit("build-1", fleet(5)); held != nil {
+		t.Fatalf("unexpected held build: %+v", held)
+	}
+	if held := gate.Submit("build-2", fleet(10)); held != nil {
+		t.Fatalf("unexpected held build: %+v", held)
+	}
+
+	// the fleet is rolled back to the first build, the next build is measured against it
+	published.served = fleet(5)
+	if held := gate.Submit("build-3", fleet(10)); held != nil {
+		t.Fatalf("unexpected held build: %+v", held)
+	}
+
+	// a newer build supersedes the held build
+	reports.StartNewReport("build-4")
+	if held := gate.Submit("build-4", fleet(2)); held == nil {
+		t.Fatal("expected the build to be held")
+	}
+	reports.UpdateStatus(report.Held)
+	reports.MarkAsComplete()
+	reports.StartNewReport("build-5")
+	if held := gate.Submit("build-5", fleet(10)); held != nil {
+		t.Fatalf("unexpected held build: %+v", held)
+	}
+	if err := gate.Approve("build-4"); err == nil {
+		t.Error("expected an error approving a superseded build")
+	}
+
+	out, err := reports.GetLastCompleteJSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(string(out), string(report.Superseded)) {
+		t.Errorf("expected the held build to be superseded, got %s", out)
+	}
+}
@@ -0,0 +1,123 @@
+// Package guard holds the builds whose blast radius is too large, compared with the last published build, until approved.
+package guard
+
+import (
+	"fmt"
+
+	"github.com/criteo/data-aggregation-api/internal/config"
+	"github.com/criteo/data-aggregation-api/internal/convertor/device"
+)
+
+// Change counts the items of the previous build changed or removed by a new build.
+type Change struct {
+	Count    int     `json:"count"`
+	Previous int     `json:"previous"`
+	Ratio    float64 `json:"ratio"`
+}
+
+func newChange(count int, previous int) Change {
+	change := Change{Count: count, Previous: previous}
+	if previous > 0 {
+		change.Ratio = float64(count) / float64(previous)
+	}
+	return change
+}
+
+// Impact is the blast radius of a new build compared with the previous one.
+type Impact struct {
+	ChangedDevices   Change `json:"changed_devices"`
+	MissingDevices   Change `json:"missing_devices"`
+	RemovedNeighbors Change `json:"removed_neighbors"`
+	RemovedPolicies  Change `json:"removed_policies"`
+}
+
+func isBuilt(dev *device.Device) bool {
+	return dev != nil && dev.Config != nil
+}
+
+// neighbors identifies the BGP sessions of the built devices by hostname and peer addresses.
+func neighbors(devices map[string]*device.Device) map[string]bool {
+	keys := make(map[string]bool)
+	for hostname, dev := range devices {
+		if !isBuilt(dev) {
+			continue
+		}
+		for _, session := range dev.Sessions {
+			keys[fmt.Sprintf("%s %s-%s", hostname, session.PeerA.LocalAddress.Address.IP, session.PeerB.LocalAddress.Address.IP)] = true
+		}
+	}
+	return keys
+}
+
+// policies identifies the route-policies of the built devices by hostname and name.
+func policies(devices map[string]*device.Device) map[string]bool {
+	keys := make(map[string]bool)
+	for hostname, dev := range devices {
+		if !isBuilt(dev) {
+			continue
+		}
+		for _, policy := range dev.RoutePolicies {
+			keys[hostname+" "+policy.Name] = true
+		}
+	}
+	return keys
+}
+
+func countRemoved(previous map[string]bool, next map[string]bool) Change {
+	removed := 0
+	for key := range previous {
+		if !next[key] {
+			removed++
+		}
+	}
+	return newChange(removed, len(previous))
+}
+
+// Measure compares a new build with the previous one.
+// Devices are changed when their configuration hash differs, and missing when they are no longer built.
+func Measure(previous map[string]*device.Device, next map[string]*device.Device) *Impact {
+	built, changed, missing := 0, 0, 0
+	for hostname, previousDev := range previous {
+		if !isBuilt(previousDev) {
+			continue
+		}
+		built++
+
+		nextDev := next[hostname]
+		switch {
+		case !isBuilt(nextDev):
+			missing++
+		case nextDev.Config.Hash != previousDev.Config.Hash:
+			changed++
+		}
+	}
+
+	return &Impact{
+		ChangedDevices:   newChange(changed, built),
+		MissingDevices:   newChange(missing, built),
+		RemovedNeighbors: countRemoved(neighbors(previous), neighbors(next)),
+		RemovedPolicies:  countRemoved(policies(previous), policies(next)),
+	}
+}
+
+func exceeded(name string, change Change, threshold float64) string {
+	return fmt.Sprintf("%d/%d %s (%.1f%%) above the %.1f%% threshold", change.Count, change.Previous, name, change.Ratio*100, threshold*100)
+}
+
+// Exceeded explains which thresholds are exceeded by the impact, if any.
+func (i *Impact) Exceeded(settings config.BlastRadiusConfig) []string {
+	var reasons []string
+	if i.ChangedDevices.Ratio > settings.MaxChangedDevices {
+		reasons = append(reasons, exceeded("devices changed", i.ChangedDevices, settings.MaxChangedDevices))
+	}
+	if i.MissingDevices.Ratio > settings.MaxMissingDevices {
+		reasons = append(reasons, exceeded("devices missing", i.MissingDevices, settings.MaxMissingDevices))
+	}
+	if i.RemovedNeighbors.Ratio > settings.MaxRemovedNeighbors {
+		reasons = append(reasons, exceeded("BGP neighbors removed", i.RemovedNeighbors, settings.MaxRemovedNeighbors))
+	}
+	if i.RemovedPolicies.Ratio > settings.MaxRemovedPolicies {
+		reasons = append(reasons, exceeded("route-policies removed", i.RemovedPolicies, settings.MaxRemovedPolicies))
+	}
+	return reasons
+}
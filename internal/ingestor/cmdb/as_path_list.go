@@ -0,0 +1,72 @@
+package cmdb
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/criteo/data-aggregation-api/internal/ingestor/netbox"
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/routingpolicy"
+)
+
+const asPathListsEndpoint = "/api/plugins/cmdb/bgp-as-path-lists/"
+
+// GetASPathLists returns all AS-path-lists from the Network CMDB.
+func GetASPathLists() ([]*routingpolicy.ASPathList, []*netbox.InvalidObject, error) {
+	response := netbox.NetboxResponse[routingpolicy.ASPathList]{}
+	params := deviceDatacenterFilter()
+
+	err := netbox.Get(asPathListsEndpoint, &response, params)
+	if err != nil {
+		return nil, nil, fmt.Errorf("BGP AS-path Lists fetching failure: %w", err)
+	}
+
+	if response.Count != len(response.Results)+len(response.Invalid) {
+		log.Warn().Msg("some AS-path-lists have not been fetched")
+	}
+
+	netbox.ExcludeInvalid(asPathListsEndpoint, &response, ValidateASPathList)
+
+	return response.Results, response.Invalid, nil
+}
+
+// ValidateASPathList checks that every AS-path-list term is a syntactically valid regular expression.
+func ValidateASPathList(asPathList *routingpolicy.ASPathList) error {
+	for _, term := range asPathList.Terms {
+		if _, err := regexp.Compile(term.Regex); err != nil {
+			return fmt.Errorf("invalid regex '%s' in AS-path-list %s (device %s): %w", term.Regex, asPathList.Name, asPathList.Device.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// PrecomputeASPathLists associates each found AS-path-lists to the matching devices.
+// Duplicate regular expressions of an AS-path-list are dropped, only the first one is kept.
+func PrecomputeASPathLists(asPathLists []*routingpolicy.ASPathList) (map[string][]*routingpolicy.ASPathList, []*Conflict) {
+	var conflicts []*Conflict
+	for _, asPathList := range asPathLists {
+		var duplicates []string
+		asPathList.Terms, duplicates = uniqueTerms(asPathList.Terms, func(t *routingpolicy.ASPathListTerm) string {
+			return t.Regex
+		})
+		for _, duplicate := range duplicates {
+			conflicts = append(conflicts, &Conflict{
+				Device: asPathList.Device.Name,
+				Kind:   "AS-path-list",
+				Key:    asPathList.Name,
+				IDs:    []*int{asPathList.ID},
+				Reason: "has duplicate regex " + duplicate,
+			})
+		}
+	}
+
+	asPathListsPerDevice, nameConflicts := precomputePerDevice("AS-path-list", asPathLists,
+		func(a *routingpolicy.ASPathList) string { return a.Device.Name },
+		func(a *routingpolicy.ASPathList) string { return a.Name },
+		func(a *routingpolicy.ASPathList) *int { return a.ID },
+	)
+
+	return asPathListsPerDevice, append(conflicts, nameConflicts...)
+}
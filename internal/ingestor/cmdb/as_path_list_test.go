@@ -0,0 +1,98 @@
+package cmdb_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/criteo/data-aggregation-api/internal/ingestor/cmdb"
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/routingpolicy"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPrecomputeASPathLists(t *testing.T) {
+	tests := []struct {
+		name string
+		args string
+		want map[string][]*routingpolicy.ASPathList
+	}{
+		{
+			name: "valid AS-path list",
+			args: `
+			[
+				{
+					"id": 1,
+					"device": {
+						"id": 1,
+						"name": "tor01-01"
+					},
+					"terms": [
+						{
+							"regex": "^65000_"
+						},
+						{
+							"regex": "_6500[1-9]$"
+						}
+					],
+					"created": "2023-06-20T12:23:50.955067Z",
+					"last_updated": "2023-06-20T12:23:50.955078Z",
+					"name": "TRANSIT"
+				}
+			]
+			`,
+			want: map[string][]*routingpolicy.ASPathList{
+				"tor01-01": {
+					&routingpolicy.ASPathList{
+						ID:   &id1,
+						Name: "TRANSIT",
+						Device: struct {
+							Name string `json:"name" validate:"required"`
+						}{
+							Name: "tor01-01",
+						},
+						Terms: []*routingpolicy.ASPathListTerm{
+							{
+								Regex: "^65000_",
+							},
+							{
+								Regex: "_6500[1-9]$",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		var cmdbOutput []*routingpolicy.ASPathList
+		if err := json.Unmarshal([]byte(test.args), &cmdbOutput); err != nil {
+			t.Errorf("unable to load test data for '%s': %s", test.name, err)
+			continue
+		}
+
+		for _, asPathList := range cmdbOutput {
+			if err := cmdb.ValidateASPathList(asPathList); err != nil {
+				t.Errorf("unexpected validation error for '%s': %s", test.name, err)
+			}
+		}
+
+		out, conflicts := cmdb.PrecomputeASPathLists(cmdbOutput)
+		if len(conflicts) > 0 {
+			t.Errorf("unexpected conflicts for '%s': %v", test.name, conflicts)
+		}
+		if diff := cmp.Diff(out, test.want); diff != "" {
+			t.Errorf("unexpected diff for '%s': %s\n", test.name, diff)
+		}
+	}
+}
+
+func TestValidateASPathListInvalidRegex(t *testing.T) {
+	asPathList := &routingpolicy.ASPathList{
+		Name:  "BROKEN",
+		Terms: []*routingpolicy.ASPathListTerm{{Regex: "^65000_("}},
+	}
+
+	if err := cmdb.ValidateASPathList(asPathList); err == nil {
+		t.Errorf("expected an error for an invalid regex")
+	}
+}
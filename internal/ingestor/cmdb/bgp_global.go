@@ -10,28 +10,35 @@ import (
 )
 
 // GetBGPGlobal returns all BGP global configuration from the Network CMDB.
-func GetBGPGlobal() ([]*bgp.BGPGlobal, error) {
+func GetBGPGlobal() ([]*bgp.BGPGlobal, []*netbox.InvalidObject, error) {
 	response := netbox.NetboxResponse[bgp.BGPGlobal]{}
 	params := deviceDatacenterFilter()
 
 	err := netbox.Get("/api/plugins/cmdb/bgp-global/", &response, params)
 	if err != nil {
-		return nil, fmt.Errorf("BGP Global fetching failure: %w", err)
+		return nil, nil, fmt.Errorf("BGP Global fetching failure: %w", err)
 	}
 
-	if response.Count != len(response.Results) {
+	if response.Count != len(response.Results)+len(response.Invalid) {
 		log.Warn().Msg("no BGP global configuration found")
 	}
 
-	return response.Results, nil
+	return response.Results, response.Invalid, nil
 }
 
 // PrecomputeBGPGlobal associates each found BGP global configuration to the matching devices.
-func PrecomputeBGPGlobal(globalConfigs []*bgp.BGPGlobal) map[string]*bgp.BGPGlobal {
+// Only one BGP global configuration is expected per device.
+func PrecomputeBGPGlobal(globalConfigs []*bgp.BGPGlobal) (map[string]*bgp.BGPGlobal, []*Conflict) {
+	configsPerDevice, conflicts := precomputePerDevice("BGP global configuration", globalConfigs,
+		func(c *bgp.BGPGlobal) string { return c.Device.Name },
+		func(c *bgp.BGPGlobal) string { return c.Device.Name },
+		func(c *bgp.BGPGlobal) *int { return c.ID },
+	)
+
 	var bgpGlobalPerDevice = make(map[string]*bgp.BGPGlobal)
-	for _, config := range globalConfigs {
-		bgpGlobalPerDevice[config.Device.Name] = config
+	for device, configs := range configsPerDevice {
+		bgpGlobalPerDevice[device] = configs[0]
 	}
 
-	return bgpGlobalPerDevice
+	return bgpGlobalPerDevice, conflicts
 }
@@ -53,6 +53,7 @@ func TestPrecomputeBGPGlobal(t *testing.T) {
 			`,
 			want: map[string]*bgp.BGPGlobal{
 				"tor01-01": {
+					ID: &id1,
 					Device: struct {
 						Name string "json:\"name\" validate:\"required\""
 					}{
@@ -101,6 +102,7 @@ func TestPrecomputeBGPGlobal(t *testing.T) {
 			`,
 			want: map[string]*bgp.BGPGlobal{
 				"spine01-01": {
+					ID: &id2,
 					Device: struct {
 						Name string "json:\"name\" validate:\"required\""
 					}{
@@ -129,7 +131,10 @@ func TestPrecomputeBGPGlobal(t *testing.T) {
 			continue
 		}
 
-		out := cmdb.PrecomputeBGPGlobal(cmdbOutput)
+		out, conflicts := cmdb.PrecomputeBGPGlobal(cmdbOutput)
+		if len(conflicts) > 0 {
+			t.Errorf("unexpected conflicts for '%s': %v", test.name, conflicts)
+		}
 		if diff := cmp.Diff(out, test.want); diff != "" {
 			t.Errorf("unexpected diff for '%s': %s\n", test.name, diff)
 		}
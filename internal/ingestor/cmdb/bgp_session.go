@@ -10,28 +10,40 @@ import (
 )
 
 // GetBGPSessions returns all BGP sessions from the Network CMDB.
-func GetBGPSessions() ([]*bgp.Session, error) {
+func GetBGPSessions() ([]*bgp.Session, []*netbox.InvalidObject, error) {
 	response := netbox.NetboxResponse[bgp.Session]{}
 	params := deviceDatacenterFilter()
 
 	err := netbox.Get("/api/plugins/cmdb/bgp-sessions/", &response, params)
 	if err != nil {
-		return nil, fmt.Errorf("BGP Sessions fetching failure: %w", err)
+		return nil, nil, fmt.Errorf("BGP Sessions fetching failure: %w", err)
 	}
 
-	if response.Count != len(response.Results) {
+	if response.Count != len(response.Results)+len(response.Invalid) {
 		log.Warn().Msg("some BGP session have not been fetched")
 	}
 
-	return response.Results, nil
+	return response.Results, response.Invalid, nil
+}
+
+// neighborKey returns the neighbor of the remote side on the local device.
+// Sessions without a valid neighbor have no key, they are reported when the device is built.
+func neighborKey(local *bgp.DeviceSession, remote *bgp.DeviceSession) string {
+	key, err := bgp.NeighborKey(local, remote)
+	if err != nil {
+		return ""
+	}
+	return key
 }
 
 // PrecomputeBGPSessions links each BGP sessions to the two matching devices.
-func PrecomputeBGPSessions(sessions []*bgp.Session) map[string][]*bgp.Session {
-	var sessionsPerDevice = make(map[string][]*bgp.Session)
+// A device keeps only the first session to a given neighbor, whichever side of the sessions the device is.
+func PrecomputeBGPSessions(sessions []*bgp.Session) (map[string][]*bgp.Session, []*Conflict) {
+	index := newPerDeviceIndex("BGP neighbor", func(s *bgp.Session) *int { return s.ID })
 	for _, session := range sessions {
-		sessionsPerDevice[session.PeerA.Device.Name] = append(sessionsPerDevice[session.PeerA.Device.Name], session)
-		sessionsPerDevice[session.PeerB.Device.Name] = append(sessionsPerDevice[session.PeerB.Device.Name], session)
+		index.add(session.PeerA.Device.Name, neighborKey(&session.PeerA, &session.PeerB), session)
+		index.add(session.PeerB.Device.Name, neighborKey(&session.PeerB, &session.PeerA), session)
 	}
-	return sessionsPerDevice
+
+	return index.objects, index.conflicts
 }
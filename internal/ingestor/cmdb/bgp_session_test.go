@@ -135,6 +135,7 @@ const ipv4BGPSession = `
 var ipv4Ingested = map[string][]*bgp.Session{
 	"tor01-01": {
 		&bgp.Session{
+			ID: &id1,
 			PeerA: bgp.DeviceSession{
 				Device: struct {
 					Name string `json:"name" validate:"required"`
@@ -222,6 +223,7 @@ var ipv4Ingested = map[string][]*bgp.Session{
 	},
 	"spine01-01": {
 		&bgp.Session{
+			ID: &id1,
 			PeerA: bgp.DeviceSession{
 				Device: struct {
 					Name string `json:"name" validate:"required"`
@@ -418,6 +420,7 @@ const ipv6BGPSession = `
 var ipv6Ingested = map[string][]*bgp.Session{
 	"tor01-01": {
 		&bgp.Session{
+			ID: &id2,
 			PeerA: bgp.DeviceSession{
 				Device: struct {
 					Name string `json:"name" validate:"required"`
@@ -503,6 +506,7 @@ var ipv6Ingested = map[string][]*bgp.Session{
 	},
 	"spine01-01": {
 		&bgp.Session{
+			ID: &id2,
 			PeerA: bgp.DeviceSession{
 				Device: struct {
 					Name string `json:"name" validate:"required"`
@@ -617,9 +621,97 @@ func TestPrecomputeBGPSessions(t *testing.T) {
 			continue
 		}
 
-		out := cmdb.PrecomputeBGPSessions(cmdbOutput)
+		out, _ := cmdb.PrecomputeBGPSessions(cmdbOutput)
 		if diff := cmp.Diff(out, test.want); diff != "" {
 			t.Errorf("unexpected diff for '%s': %s\n", test.name, diff)
 		}
 	}
 }
+
+func TestPrecomputeBGPSessionsConflicts(t *testing.T) {
+	newSession := func(id *int, remote string, localAddress string, remoteAddress string, iface string) *bgp.Session {
+		session := &bgp.Session{ID: id}
+		session.PeerA.Device.Name = "spine01-01"
+		session.PeerA.LocalAddress.Address = types.CIDR{IP: net.ParseIP(localAddress), Netmask: 64}
+		session.PeerB.Device.Name = remote
+		session.PeerB.LocalAddress.Address = types.CIDR{IP: net.ParseIP(remoteAddress), Netmask: 64}
+		if iface != "" {
+			session.PeerA.Interface = &bgp.InterfaceLite{Name: iface}
+		}
+		return session
+	}
+
+	var id3 = 3
+	var id4 = 4
+	sessions := []*bgp.Session{
+		newSession(&id1, "tor01-01", "2001:db8::", "2001:db8::1", ""),
+		newSession(&id2, "tor01-02", "2001:db8::", "2001:db8::1", ""),
+		// the same link-local address behind different interfaces is not a conflict
+		newSession(&id3, "tor01-03", "fe80::", "fe80::1", "Ethernet1"),
+		newSession(&id4, "tor01-04", "fe80::", "fe80::1", "Ethernet2"),
+	}
+
+	out, conflicts := cmdb.PrecomputeBGPSessions(sessions)
+
+	want := []*cmdb.Conflict{
+		{
+			Device: "spine01-01",
+			Kind:   "BGP neighbor",
+			Key:    "2001:db8::1",
+			IDs:    []*int{&id1, &id2},
+			Reason: "is defined more than once",
+		},
+	}
+	if diff := cmp.Diff(conflicts, want); diff != "" {
+		t.Errorf("unexpected diff: %s\n", diff)
+	}
+
+	if len(out["spine01-01"]) != 3 {
+		t.Errorf("expected 3 sessions to be kept for spine01-01, got %d", len(out["spine01-01"]))
+	}
+	// the remote device of the dropped session still gets it
+	if len(out["tor01-02"]) != 1 {
+		t.Errorf("expected the session of tor01-02 to be kept, got %v", out["tor01-02"])
+	}
+}
+
+func TestPrecomputeBGPSessionsCrossSideConflicts(t *testing.T) {
+	var id3 = 3
+
+	// spine01-01 is peer A of the first session and peer B of the second one, both to 2001:db8::1
+	first := &bgp.Session{ID: &id1}
+	first.PeerA.Device.Name = "spine01-01"
+	first.PeerA.LocalAddress.Address = types.CIDR{IP: net.ParseIP("2001:db8::"), Netmask: 127}
+	first.PeerB.Device.Name = "tor01-01"
+	first.PeerB.LocalAddress.Address = types.CIDR{IP: net.ParseIP("2001:db8::1"), Netmask: 127}
+
+	second := &bgp.Session{ID: &id2}
+	second.PeerA.Device.Name = "tor01-02"
+	second.PeerA.LocalAddress.Address = types.CIDR{IP: net.ParseIP("2001:db8::1"), Netmask: 127}
+	second.PeerB.Device.Name = "spine01-01"
+	second.PeerB.LocalAddress.Address = types.CIDR{IP: net.ParseIP("2001:db8::"), Netmask: 127}
+
+	// sessions without neighbor are never in conflict, they are reported when the device is built
+	third := &bgp.Session{ID: &id3}
+	third.PeerA.Device.Name = "spine01-01"
+	third.PeerB.Device.Name = "tor01-03"
+
+	out, conflicts := cmdb.PrecomputeBGPSessions([]*bgp.Session{first, second, third})
+
+	want := []*cmdb.Conflict{
+		{
+			Device: "spine01-01",
+			Kind:   "BGP neighbor",
+			Key:    "2001:db8::1",
+			IDs:    []*int{&id1, &id2},
+			Reason: "is defined more than once",
+		},
+	}
+	if diff := cmp.Diff(conflicts, want); diff != "" {
+		t.Errorf("unexpected diff: %s\n", diff)
+	}
+
+	if diff := cmp.Diff(out["spine01-01"], []*bgp.Session{first, third}); diff != "" {
+		t.Errorf("unexpected sessions kept for spine01-01: %s\n", diff)
+	}
+}
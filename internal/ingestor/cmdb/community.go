@@ -0,0 +1,125 @@
+package cmdb
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/routingpolicy"
+)
+
+const communityListSeparator = " "
+
+// errLargeCommunityUnsupported rejects large communities at ingestion, so only the devices using them fail to build.
+var errLargeCommunityUnsupported = errors.New("large communities are not supported by the OpenConfig model")
+
+var wellKnownCommunities = map[string]struct{}{
+	"NO_EXPORT":           {},
+	"NO_ADVERTISE":        {},
+	"NO_EXPORT_SUBCONFED": {},
+	"NOPEER":              {},
+}
+
+// isCommunityRegex tells if the community contains regular expression metacharacters.
+func isCommunityRegex(community string) bool {
+	return regexp.QuoteMeta(community) != community
+}
+
+func parseCommunityField(field string, bitSize int) (uint64, error) {
+	value, err := strconv.ParseUint(field, 10, bitSize)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not a %d-bit integer", field, bitSize)
+	}
+	return value, nil
+}
+
+// validateStandardCommunity checks the `asn:nn` format, both fields being 16-bit integers.
+func validateStandardCommunity(community string) error {
+	if _, ok := wellKnownCommunities[community]; ok {
+		return nil
+	}
+
+	fields := strings.Split(community, ":")
+	if len(fields) != 2 {
+		return fmt.Errorf("standard community '%s' must be formatted as asn:nn", community)
+	}
+	for _, field := range fields {
+		if _, err := parseCommunityField(field, 16); err != nil {
+			return fmt.Errorf("invalid standard community '%s': %w", community, err)
+		}
+	}
+	return nil
+}
+
+// validateExtCommunity checks the `rt:admin:nn` and `soo:admin:nn` formats.
+// The administrator is an IPv4 address or an ASN, 6 bytes being shared between both fields.
+func validateExtCommunity(community string) error {
+	fields := strings.Split(community, ":")
+	if len(fields) != 3 || (fields[0] != "rt" && fields[0] != "soo") {
+		return fmt.Errorf("extended community '%s' must be formatted as rt:admin:nn or soo:admin:nn", community)
+	}
+
+	assignedBitSize := 16
+	if ip := net.ParseIP(fields[1]); ip == nil || ip.To4() == nil {
+		asn, err := parseCommunityField(fields[1], 32)
+		if err != nil {
+			return fmt.Errorf("invalid extended community '%s': administrator must be an IPv4 address or an ASN", community)
+		}
+		if asn <= 0xFFFF {
+			assignedBitSize = 32
+		}
+	}
+
+	if _, err := parseCommunityField(fields[2], assignedBitSize); err != nil {
+		return fmt.Errorf("invalid extended community '%s': %w", community, err)
+	}
+	return nil
+}
+
+func validateCommunity(communityType routingpolicy.CommunityType, community string) error {
+	switch communityType {
+	case routingpolicy.StandardCommunity, routingpolicy.UnsetCommunity:
+		return validateStandardCommunity(community)
+	case routingpolicy.ExtendedCommunity:
+		return validateExtCommunity(community)
+	case routingpolicy.LargeCommunity:
+		return errLargeCommunityUnsupported
+	default:
+		return fmt.Errorf("unsupported community type '%s'", communityType)
+	}
+}
+
+// validateCommunityMatch validates a community-list term, which is either a literal community or a regular expression.
+func validateCommunityMatch(communityType routingpolicy.CommunityType, community string) error {
+	if isCommunityRegex(community) {
+		if _, err := regexp.Compile(community); err != nil {
+			return fmt.Errorf("invalid community regex '%s': %w", community, err)
+		}
+		return nil
+	}
+	return validateCommunity(communityType, community)
+}
+
+// validateCommunities applies validate to each community of a space separated list.
+func validateCommunities(communities string, validate func(string) error) error {
+	var errs []error
+	for _, community := range strings.Split(communities, communityListSeparator) {
+		if community == "" {
+			continue
+		}
+		if err := validate(community); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateCommunityAction validates a space separated list of literal communities set by a route-policy term.
+func validateCommunityAction(communityType routingpolicy.CommunityType, communities string) error {
+	return validateCommunities(communities, func(community string) error {
+		return validateCommunity(communityType, community)
+	})
+}
@@ -9,29 +9,68 @@ import (
 	"github.com/criteo/data-aggregation-api/internal/model/cmdb/routingpolicy"
 )
 
+const communityListsEndpoint = "/api/plugins/cmdb/bgp-community-lists/"
+
 // GetCommunityLists returns all community-lists from the Network CMDB.
-func GetCommunityLists() ([]*routingpolicy.CommunityList, error) {
+func GetCommunityLists() ([]*routingpolicy.CommunityList, []*netbox.InvalidObject, error) {
 	response := netbox.NetboxResponse[routingpolicy.CommunityList]{}
 	params := deviceDatacenterFilter()
 
-	err := netbox.Get("/api/plugins/cmdb/bgp-community-lists/", &response, params)
+	err := netbox.Get(communityListsEndpoint, &response, params)
 	if err != nil {
-		return nil, fmt.Errorf("BGP Community Lists fetching failure: %w", err)
+		return nil, nil, fmt.Errorf("BGP Community Lists fetching failure: %w", err)
 	}
 
-	if response.Count != len(response.Results) {
+	if response.Count != len(response.Results)+len(response.Invalid) {
 		log.Warn().Msg("some community-lists have not been fetched")
 	}
 
-	return response.Results, nil
+	netbox.ExcludeInvalid(communityListsEndpoint, &response, ValidateCommunityList)
+
+	return response.Results, response.Invalid, nil
+}
+
+// ValidateCommunityList checks that every community-list term matches the format of the community-list type.
+// Large community-lists are rejected, as they cannot be rendered.
+func ValidateCommunityList(communityList *routingpolicy.CommunityList) error {
+	if communityList.Type == routingpolicy.LargeCommunity {
+		return fmt.Errorf("community-list %s (device %s): %w", communityList.Name, communityList.Device.Name, errLargeCommunityUnsupported)
+	}
+
+	for _, term := range communityList.Terms {
+		if err := validateCommunityMatch(communityList.Type, term.Community); err != nil {
+			return fmt.Errorf("community-list %s (device %s): %w", communityList.Name, communityList.Device.Name, err)
+		}
+	}
+
+	return nil
 }
 
 // PrecomputeCommunityLists associates each found community-lists to the matching devices.
-func PrecomputeCommunityLists(communityLists []*routingpolicy.CommunityList) map[string][]*routingpolicy.CommunityList {
-	var communityListsPerDevice = make(map[string][]*routingpolicy.CommunityList)
+// Duplicate communities of a community-list are dropped, only the first one is kept.
+func PrecomputeCommunityLists(communityLists []*routingpolicy.CommunityList) (map[string][]*routingpolicy.CommunityList, []*Conflict) {
+	var conflicts []*Conflict
 	for _, communityList := range communityLists {
-		communityListsPerDevice[communityList.Device.Name] = append(communityListsPerDevice[communityList.Device.Name], communityList)
+		var duplicates []string
+		communityList.Terms, duplicates = uniqueTerms(communityList.Terms, func(t *routingpolicy.CommunityListTerm) string {
+			return t.Community
+		})
+		for _, duplicate := range duplicates {
+			conflicts = append(conflicts, &Conflict{
+				Device: communityList.Device.Name,
+				Kind:   "community-list",
+				Key:    communityList.Name,
+				IDs:    []*int{communityList.ID},
+				Reason: "has duplicate community " + duplicate,
+			})
+		}
 	}
 
-	return communityListsPerDevice
+	communityListsPerDevice, nameConflicts := precomputePerDevice("community-list", communityLists,
+		func(c *routingpolicy.CommunityList) string { return c.Device.Name },
+		func(c *routingpolicy.CommunityList) string { return c.Name },
+		func(c *routingpolicy.CommunityList) *int { return c.ID },
+	)
+
+	return communityListsPerDevice, append(conflicts, nameConflicts...)
 }
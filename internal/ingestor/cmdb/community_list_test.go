@@ -2,8 +2,11 @@ package cmdb_test
 
 import (
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/criteo/data-aggregation-api/internal/config"
 	"github.com/criteo/data-aggregation-api/internal/ingestor/cmdb"
 	"github.com/criteo/data-aggregation-api/internal/model/cmdb/routingpolicy"
 	"github.com/google/go-cmp/cmp"
@@ -42,6 +45,7 @@ func TestPrecomputeCommunityLists(t *testing.T) {
 			want: map[string][]*routingpolicy.CommunityList{
 				"tor01-01": {
 					&routingpolicy.CommunityList{
+						ID:   &id1,
 						Name: "SERVERS",
 						Device: struct {
 							Name string `json:"name" validate:"required"`
@@ -69,9 +73,85 @@ func TestPrecomputeCommunityLists(t *testing.T) {
 			continue
 		}
 
-		out := cmdb.PrecomputeCommunityLists(cmdbOutput)
+		out, conflicts := cmdb.PrecomputeCommunityLists(cmdbOutput)
+		if len(conflicts) > 0 {
+			t.Errorf("unexpected conflicts for '%s': %v", test.name, conflicts)
+		}
 		if diff := cmp.Diff(out, test.want); diff != "" {
 			t.Errorf("unexpected diff for '%s': %s\n", test.name, diff)
 		}
 	}
 }
+
+func TestValidateCommunityList(t *testing.T) {
+	tests := []struct {
+		name      string
+		listType  routingpolicy.CommunityType
+		community string
+		valid     bool
+	}{
+		{"standard community", routingpolicy.StandardCommunity, "65000:100", true},
+		{"untyped community", routingpolicy.UnsetCommunity, "65000:100", true},
+		{"well-known community", routingpolicy.StandardCommunity, "NO_EXPORT", true},
+		{"standard community regex", routingpolicy.StandardCommunity, "650..:999", true},
+		{"standard community out of range", routingpolicy.StandardCommunity, "65536:100", false},
+		{"standard community with large format", routingpolicy.StandardCommunity, "65000:1:2", false},
+		{"invalid regex", routingpolicy.StandardCommunity, "650(:999", false},
+		{"large community", routingpolicy.LargeCommunity, "4200000000:1:2", false},
+		{"large community regex", routingpolicy.LargeCommunity, "65000:.*", false},
+		{"route-target with ASN", routingpolicy.ExtendedCommunity, "rt:65000:100", true},
+		{"route-target with 4-byte ASN", routingpolicy.ExtendedCommunity, "rt:4200000000:100", true},
+		{"route-target with 4-byte ASN and large value", routingpolicy.ExtendedCommunity, "rt:4200000000:70000", false},
+		{"site-of-origin with IPv4", routingpolicy.ExtendedCommunity, "soo:192.0.2.1:100", true},
+		{"unknown extended community type", routingpolicy.ExtendedCommunity, "color:0:100", false},
+	}
+
+	for _, test := range tests {
+		communityList := &routingpolicy.CommunityList{
+			Name:  "TEST",
+			Type:  test.listType,
+			Terms: []*routingpolicy.CommunityListTerm{{Community: test.community}},
+		}
+
+		err := cmdb.ValidateCommunityList(communityList)
+		if test.valid && err != nil {
+			t.Errorf("unexpected error for '%s': %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("expected an error for '%s'", test.name)
+		}
+	}
+}
+
+func TestGetCommunityListsExcludesLargeCommunityLists(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`
+		{
+			"next": "",
+			"count": 2,
+			"results": [
+				{"id": 1, "device": {"name": "tor01-01"}, "name": "LARGE", "community_type": "large", "terms": [{"community": "65000:1:2"}]},
+				{"id": 2, "device": {"name": "tor01-02"}, "name": "SERVERS", "community_type": "standard", "terms": [{"community": "65000:1"}]}
+			]
+		}`))
+	}))
+	defer server.Close()
+	config.Cfg.NetBox.URL = server.URL
+	config.Cfg.NetBox.DatacenterFilterKey = config.SiteFilter
+
+	communityLists, invalid, err := cmdb.GetCommunityLists()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// only the device using the large community-list fails, the other one keeps building
+	if len(communityLists) != 1 || communityLists[0].Device.Name != "tor01-02" {
+		t.Errorf("expected only the standard community-list to be kept, got %v", communityLists)
+	}
+	if len(invalid) != 1 {
+		t.Fatalf("expected 1 invalid object, got %d", len(invalid))
+	}
+	if diff := cmp.Diff(invalid[0].Devices, []string{"tor01-01"}); diff != "" {
+		t.Errorf("unexpected diff: %s\n", diff)
+	}
+}
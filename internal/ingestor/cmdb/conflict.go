@@ -0,0 +1,109 @@
+package cmdb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Conflict is a CMDB object colliding with a previous object of the same device.
+// Only the first object is kept during precompute, the conflicting one is dropped.
+type Conflict struct {
+	Device string
+	// Kind is the kind of the conflicting object (e.g. "prefix-list").
+	Kind string
+	// Key identifies the object within the device (e.g. its name).
+	Key string
+	// IDs are the NetBox IDs of the kept object, then of the dropped one.
+	IDs    []*int
+	Reason string
+}
+
+func formatID(id *int) string {
+	if id == nil {
+		return "unknown"
+	}
+	return strconv.Itoa(*id)
+}
+
+func (c *Conflict) Error() string {
+	ids := make([]string, 0, len(c.IDs))
+	for _, id := range c.IDs {
+		ids = append(ids, formatID(id))
+	}
+	return fmt.Sprintf("%s: %s %s %s (NetBox IDs: %s)", c.Device, c.Kind, c.Key, c.Reason, strings.Join(ids, ", "))
+}
+
+// perDeviceIndex associates objects to their device, keeping only the first object for each key.
+// Objects without key are always kept.
+type perDeviceIndex[T any] struct {
+	kind      string
+	id        func(*T) *int
+	objects   map[string][]*T
+	seen      map[string]map[string]*T
+	conflicts []*Conflict
+}
+
+func newPerDeviceIndex[T any](kind string, id func(*T) *int) *perDeviceIndex[T] {
+	return &perDeviceIndex[T]{
+		kind:    kind,
+		id:      id,
+		objects: make(map[string][]*T),
+		seen:    make(map[string]map[string]*T),
+	}
+}
+
+// add associates the object to the device, unless the device already has an object with the same key.
+func (i *perDeviceIndex[T]) add(deviceName string, objectKey string, object *T) {
+	if objectKey == "" {
+		i.objects[deviceName] = append(i.objects[deviceName], object)
+		return
+	}
+
+	if i.seen[deviceName] == nil {
+		i.seen[deviceName] = make(map[string]*T)
+	}
+
+	if first, ok := i.seen[deviceName][objectKey]; ok {
+		i.conflicts = append(i.conflicts, &Conflict{
+			Device: deviceName,
+			Kind:   i.kind,
+			Key:    objectKey,
+			IDs:    []*int{i.id(first), i.id(object)},
+			Reason: "is defined more than once",
+		})
+		return
+	}
+
+	i.seen[deviceName][objectKey] = object
+	i.objects[deviceName] = append(i.objects[deviceName], object)
+}
+
+// precomputePerDevice associates objects to their device, keeping only the first object for each key.
+func precomputePerDevice[T any](kind string, objects []*T, device func(*T) string, key func(*T) string, id func(*T) *int) (map[string][]*T, []*Conflict) {
+	index := newPerDeviceIndex(kind, id)
+	for _, object := range objects {
+		index.add(device(object), key(object), object)
+	}
+
+	return index.objects, index.conflicts
+}
+
+// uniqueTerms returns the terms with a unique key, keeping the first one, and the keys of the dropped terms.
+func uniqueTerms[T any](terms []*T, key func(*T) string) ([]*T, []string) {
+	var unique = make([]*T, 0, len(terms))
+	var seen = make(map[string]struct{}, len(terms))
+	var duplicates []string
+
+	for _, term := range terms {
+		termKey := key(term)
+		if _, ok := seen[termKey]; ok {
+			duplicates = append(duplicates, termKey)
+			continue
+		}
+		seen[termKey] = struct{}{}
+		unique = append(unique, term)
+	}
+
+	return unique, duplicates
+}
@@ -0,0 +1,85 @@
+package cmdb_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/criteo/data-aggregation-api/internal/ingestor/cmdb"
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/routingpolicy"
+	"github.com/google/go-cmp/cmp"
+)
+
+var id1 = 1
+var id2 = 2
+
+func TestPrecomputeConflicts(t *testing.T) {
+	var prefixLists []*routingpolicy.PrefixList
+	data := `
+	[
+		{
+			"id": 1,
+			"name": "SERVERS",
+			"device": {"name": "tor01-01"},
+			"ip_version": "ipv4",
+			"terms": [
+				{"prefix": "192.0.2.0/28", "le": null, "ge": null},
+				{"prefix": "192.0.2.0/28", "le": null, "ge": null},
+				{"prefix": "192.0.2.0/28", "le": 32, "ge": null}
+			]
+		},
+		{
+			"id": 2,
+			"name": "SERVERS",
+			"device": {"name": "tor01-01"},
+			"ip_version": "ipv4",
+			"terms": []
+		},
+		{
+			"id": 3,
+			"name": "SERVERS",
+			"device": {"name": "tor01-02"},
+			"ip_version": "ipv4",
+			"terms": []
+		}
+	]`
+	if err := json.Unmarshal([]byte(data), &prefixLists); err != nil {
+		t.Fatalf("unable to load test data: %s", err)
+	}
+
+	out, conflicts := cmdb.PrecomputePrefixLists(prefixLists)
+
+	want := []*cmdb.Conflict{
+		{
+			Device: "tor01-01",
+			Kind:   "prefix-list",
+			Key:    "SERVERS",
+			IDs:    []*int{&id1},
+			Reason: "has duplicate entry 192.0.2.0/28 ge 0 le 0",
+		},
+		{
+			Device: "tor01-01",
+			Kind:   "prefix-list",
+			Key:    "SERVERS",
+			IDs:    []*int{&id1, &id2},
+			Reason: "is defined more than once",
+		},
+	}
+	if diff := cmp.Diff(conflicts, want); diff != "" {
+		t.Errorf("unexpected diff: %s\n", diff)
+	}
+
+	if len(out["tor01-01"]) != 1 || *out["tor01-01"][0].ID != 1 {
+		t.Errorf("expected only the first prefix-list to be kept for tor01-01, got %v", out["tor01-01"])
+	}
+	if len(out["tor01-01"][0].Terms) != 2 {
+		t.Errorf("expected 2 unique entries, got %d", len(out["tor01-01"][0].Terms))
+	}
+	if len(out["tor01-02"]) != 1 {
+		t.Errorf("expected the prefix-list of tor01-02 to be kept, got %v", out["tor01-02"])
+	}
+
+	wantText := "tor01-01: prefix-list SERVERS is defined more than once (NetBox IDs: 1, 2)"
+	if conflicts[1].Error() != wantText {
+		t.Errorf("unexpected error text: %q", conflicts[1].Error())
+	}
+}
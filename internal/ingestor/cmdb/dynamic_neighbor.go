@@ -0,0 +1,62 @@
+package cmdb
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/criteo/data-aggregation-api/internal/ingestor/netbox"
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/bgp"
+)
+
+const dynamicNeighborsEndpoint = "/api/plugins/cmdb/bgp-dynamic-neighbors/"
+
+// GetDynamicNeighbors returns all BGP dynamic neighbor prefixes from the Network CMDB.
+func GetDynamicNeighbors() ([]*bgp.DynamicNeighbor, []*netbox.InvalidObject, error) {
+	response := netbox.NetboxResponse[bgp.DynamicNeighbor]{}
+	params := deviceDatacenterFilter()
+
+	err := netbox.Get(dynamicNeighborsEndpoint, &response, params)
+	if err != nil {
+		return nil, nil, fmt.Errorf("BGP dynamic neighbors fetching failure: %w", err)
+	}
+
+	if response.Count != len(response.Results)+len(response.Invalid) {
+		log.Warn().Msg("some BGP dynamic neighbors have not been fetched")
+	}
+
+	netbox.ExcludeInvalid(dynamicNeighborsEndpoint, &response, ValidateDynamicNeighbor)
+
+	return response.Results, response.Invalid, nil
+}
+
+// ValidateDynamicNeighbor checks the allowed remote ASN can be rendered.
+// OpenConfig cannot express an ASN range, the remote ASN of dynamic neighbors is the peer-group one:
+// a range is rejected instead of being narrowed, only a single ASN is accepted.
+func ValidateDynamicNeighbor(dynamicNeighbor *bgp.DynamicNeighbor) error {
+	minASN, maxASN := dynamicNeighbor.RemoteAsnMin, dynamicNeighbor.RemoteAsnMax
+	if minASN == nil && maxASN == nil {
+		return nil
+	}
+
+	if minASN == nil || maxASN == nil || minASN.Number == nil || maxASN.Number == nil {
+		return errors.New("remote_asn_min and remote_asn_max must be defined together")
+	}
+
+	if *minASN.Number != *maxASN.Number {
+		return fmt.Errorf("remote ASN range %d-%d of dynamic neighbor %s (device %s) is not supported by OpenConfig, only a single ASN is",
+			*minASN.Number, *maxASN.Number, dynamicNeighbor.Prefix.String(), dynamicNeighbor.Device.Name)
+	}
+
+	return nil
+}
+
+// PrecomputeDynamicNeighbors associates each found BGP dynamic neighbor prefix to the matching devices.
+func PrecomputeDynamicNeighbors(dynamicNeighbors []*bgp.DynamicNeighbor) (map[string][]*bgp.DynamicNeighbor, []*Conflict) {
+	return precomputePerDevice("dynamic neighbor prefix", dynamicNeighbors,
+		func(d *bgp.DynamicNeighbor) string { return d.Device.Name },
+		func(d *bgp.DynamicNeighbor) string { return d.Prefix.String() },
+		func(d *bgp.DynamicNeighbor) *int { return d.ID },
+	)
+}
@@ -0,0 +1,124 @@
+package cmdb_test
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/criteo/data-aggregation-api/internal/ingestor/cmdb"
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/bgp"
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/common"
+	"github.com/criteo/data-aggregation-api/internal/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPrecomputeDynamicNeighbors(t *testing.T) {
+	var as65100 uint32 = 65100
+
+	tests := []struct {
+		name string
+		args string
+		want map[string][]*bgp.DynamicNeighbor
+	}{
+		{
+			name: "server-facing listen range",
+			args: `
+			[
+				{
+					"id": 1,
+					"device": {
+						"id": 1,
+						"name": "tor01-01"
+					},
+					"prefix": "192.0.2.0/26",
+					"peer_group": {
+						"id": 1,
+						"name": "SERVERS"
+					},
+					"remote_asn_min": {
+						"id": 10,
+						"number": 65100,
+						"organization_name": "Servers"
+					},
+					"remote_asn_max": {
+						"id": 11,
+						"number": 65100,
+						"organization_name": "Servers"
+					},
+					"description": "rack servers"
+				}
+			]
+			`,
+			want: map[string][]*bgp.DynamicNeighbor{
+				"tor01-01": {
+					&bgp.DynamicNeighbor{
+						ID: &id1,
+						Device: struct {
+							Name string `json:"name" validate:"required"`
+						}{
+							Name: "tor01-01",
+						},
+						Prefix: types.CIDR{
+							IP:      net.ParseIP("192.0.2.0"),
+							Netmask: 26,
+						},
+						PeerGroup:    bgp.PeerGroupLite{Name: "SERVERS"},
+						RemoteAsnMin: &common.ASN{Number: &as65100, Organization: "Servers"},
+						RemoteAsnMax: &common.ASN{Number: &as65100, Organization: "Servers"},
+						Description:  "rack servers",
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		var cmdbOutput []*bgp.DynamicNeighbor
+		if err := json.Unmarshal([]byte(test.args), &cmdbOutput); err != nil {
+			t.Errorf("unable to load test data for '%s': %s", test.name, err)
+			continue
+		}
+
+		out, conflicts := cmdb.PrecomputeDynamicNeighbors(cmdbOutput)
+		if len(conflicts) > 0 {
+			t.Errorf("unexpected conflicts for '%s': %v", test.name, conflicts)
+		}
+		if diff := cmp.Diff(out, test.want); diff != "" {
+			t.Errorf("unexpected diff for '%s': %s\n", test.name, diff)
+		}
+	}
+}
+
+func TestValidateDynamicNeighbor(t *testing.T) {
+	var as65100 uint32 = 65100
+	var as65199 uint32 = 65199
+
+	tests := []struct {
+		name   string
+		minASN *common.ASN
+		maxASN *common.ASN
+		valid  bool
+	}{
+		{"no remote ASN", nil, nil, true},
+		{"single remote ASN", &common.ASN{Number: &as65100}, &common.ASN{Number: &as65100}, true},
+		{"remote ASN range", &common.ASN{Number: &as65100}, &common.ASN{Number: &as65199}, false},
+		{"minimum remote ASN only", &common.ASN{Number: &as65100}, nil, false},
+		{"maximum remote ASN only", nil, &common.ASN{Number: &as65199}, false},
+	}
+
+	for _, test := range tests {
+		dynamicNeighbor := &bgp.DynamicNeighbor{
+			Prefix:       types.CIDR{IP: net.ParseIP("192.0.2.0"), Netmask: 26},
+			PeerGroup:    bgp.PeerGroupLite{Name: "SERVERS"},
+			RemoteAsnMin: test.minASN,
+			RemoteAsnMax: test.maxASN,
+		}
+		err := cmdb.ValidateDynamicNeighbor(dynamicNeighbor)
+		if test.valid && err != nil {
+			t.Errorf("unexpected error for '%s': %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("expected an error for '%s'", test.name)
+		}
+	}
+}
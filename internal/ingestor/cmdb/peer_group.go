@@ -13,31 +13,30 @@ import (
 //
 // Deprecated: peer-groups will be removed from the CMDB in future releases.
 // You should migrate to configuration without using peer-groups.
-func GetPeerGroups() ([]*bgp.PeerGroup, error) {
+func GetPeerGroups() ([]*bgp.PeerGroup, []*netbox.InvalidObject, error) {
 	response := netbox.NetboxResponse[bgp.PeerGroup]{}
 	params := deviceDatacenterFilter()
 
 	err := netbox.Get("/api/plugins/cmdb/peer-groups/", &response, params)
 	if err != nil {
-		return nil, fmt.Errorf("peer-groups fetching failure: %w", err)
+		return nil, nil, fmt.Errorf("peer-groups fetching failure: %w", err)
 	}
 
-	if response.Count != len(response.Results) {
+	if response.Count != len(response.Results)+len(response.Invalid) {
 		log.Warn().Msg("some peer-groups have not been fetched")
 	}
 
-	return response.Results, nil
+	return response.Results, response.Invalid, nil
 }
 
 // PrecomputePeerGroups associates each found peer-groups to the matching devices.
 //
 // Deprecated: peer-groups will be removed from the CMDB in future releases.
 // You should migrate to configuration without using peer-groups.
-func PrecomputePeerGroups(peerGroups []*bgp.PeerGroup) map[string][]*bgp.PeerGroup {
-	var peerGroupsPerDevice = make(map[string][]*bgp.PeerGroup)
-	for _, peerGroup := range peerGroups {
-		peerGroupsPerDevice[peerGroup.Device.Name] = append(peerGroupsPerDevice[peerGroup.Device.Name], peerGroup)
-	}
-
-	return peerGroupsPerDevice
+func PrecomputePeerGroups(peerGroups []*bgp.PeerGroup) (map[string][]*bgp.PeerGroup, []*Conflict) {
+	return precomputePerDevice("peer-group", peerGroups,
+		func(p *bgp.PeerGroup) string { return p.Device.Name },
+		func(p *bgp.PeerGroup) string { return p.Name },
+		func(p *bgp.PeerGroup) *int { return p.ID },
+	)
 }
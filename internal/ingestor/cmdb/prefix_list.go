@@ -2,6 +2,7 @@ package cmdb
 
 import (
 	"fmt"
+	"net"
 
 	"github.com/rs/zerolog/log"
 
@@ -9,29 +10,98 @@ import (
 	"github.com/criteo/data-aggregation-api/internal/model/cmdb/routingpolicy"
 )
 
+const prefixListsEndpoint = "/api/plugins/cmdb/prefix-lists/"
+
 // GetPrefixLists returns all prefix-lists from the Network CMDB.
-func GetPrefixLists() ([]*routingpolicy.PrefixList, error) {
+func GetPrefixLists() ([]*routingpolicy.PrefixList, []*netbox.InvalidObject, error) {
 	response := netbox.NetboxResponse[routingpolicy.PrefixList]{}
 	params := deviceDatacenterFilter()
 
-	err := netbox.Get("/api/plugins/cmdb/prefix-lists/", &response, params)
+	err := netbox.Get(prefixListsEndpoint, &response, params)
 	if err != nil {
-		return nil, fmt.Errorf("prefix-lists fetching failure: %w", err)
+		return nil, nil, fmt.Errorf("prefix-lists fetching failure: %w", err)
 	}
 
-	if response.Count != len(response.Results) {
+	if response.Count != len(response.Results)+len(response.Invalid) {
 		log.Warn().Msg("some prefix-lists have not been fetched")
 	}
 
-	return response.Results, nil
+	netbox.ExcludeInvalid(prefixListsEndpoint, &response, ValidatePrefixList)
+
+	return response.Results, response.Invalid, nil
+}
+
+// validatePrefixListTerm checks the term prefix belongs to the prefix-list address family,
+// has no host bits set and has a mask length range within the prefix and address lengths.
+func validatePrefixListTerm(ipVersion routingpolicy.IPVersion, term *routingpolicy.PrefixListTerm) error {
+	maxLength := net.IPv6len * 8
+	if term.Prefix.IP.To4() != nil {
+		maxLength = net.IPv4len * 8
+	}
+
+	switch {
+	case ipVersion == routingpolicy.IPv4 && maxLength != net.IPv4len*8,
+		ipVersion == routingpolicy.IPv6 && maxLength != net.IPv6len*8:
+		return fmt.Errorf("prefix %s does not match the prefix-list address family %s", term.Prefix.String(), ipVersion)
+	case term.Prefix.Zone != "":
+		return fmt.Errorf("prefix %s cannot have a scope interface", term.Prefix.String())
+	case !term.Prefix.IP.Mask(net.CIDRMask(term.Prefix.Netmask, maxLength)).Equal(term.Prefix.IP):
+		return fmt.Errorf("prefix %s has host bits set", term.Prefix.String())
+	case term.GreaterOrEqual < 0 || term.LessOrEqual < 0:
+		return fmt.Errorf("prefix %s has a negative mask length range", term.Prefix.String())
+	case term.GreaterOrEqual > 0 && term.GreaterOrEqual < term.Prefix.Netmask:
+		return fmt.Errorf("prefix %s: ge %d is smaller than the prefix length", term.Prefix.String(), term.GreaterOrEqual)
+	case term.LessOrEqual > 0 && term.LessOrEqual < term.Prefix.Netmask:
+		return fmt.Errorf("prefix %s: le %d is smaller than the prefix length", term.Prefix.String(), term.LessOrEqual)
+	case term.GreaterOrEqual > maxLength || term.LessOrEqual > maxLength:
+		return fmt.Errorf("prefix %s: mask length range exceeds %d", term.Prefix.String(), maxLength)
+	case term.GreaterOrEqual > 0 && term.LessOrEqual > 0 && term.GreaterOrEqual > term.LessOrEqual:
+		return fmt.Errorf("prefix %s: ge %d is greater than le %d", term.Prefix.String(), term.GreaterOrEqual, term.LessOrEqual)
+	}
+
+	return nil
+}
+
+// ValidatePrefixList checks each term of the prefix-list is semantically valid.
+func ValidatePrefixList(prefixList *routingpolicy.PrefixList) error {
+	if prefixList.IPVersion != routingpolicy.IPv4 && prefixList.IPVersion != routingpolicy.IPv6 {
+		return fmt.Errorf("prefix-list %s (device %s): unknown IP version %s", prefixList.Name, prefixList.Device.Name, prefixList.IPVersion)
+	}
+
+	for i, term := range prefixList.Terms {
+		if err := validatePrefixListTerm(prefixList.IPVersion, term); err != nil {
+			return fmt.Errorf("prefix-list %s (device %s) term %d: %w", prefixList.Name, prefixList.Device.Name, i, err)
+		}
+	}
+
+	return nil
 }
 
 // PrecomputePrefixLists associates each found prefix-lists to the matching devices.
-func PrecomputePrefixLists(prefixLists []*routingpolicy.PrefixList) map[string][]*routingpolicy.PrefixList {
-	var prefixListsPerDevice = make(map[string][]*routingpolicy.PrefixList)
+// Duplicate entries of a prefix-list are dropped, only the first one is kept.
+func PrecomputePrefixLists(prefixLists []*routingpolicy.PrefixList) (map[string][]*routingpolicy.PrefixList, []*Conflict) {
+	var conflicts []*Conflict
 	for _, prefixList := range prefixLists {
-		prefixListsPerDevice[prefixList.Device.Name] = append(prefixListsPerDevice[prefixList.Device.Name], prefixList)
+		var duplicates []string
+		prefixList.Terms, duplicates = uniqueTerms(prefixList.Terms, func(t *routingpolicy.PrefixListTerm) string {
+			return fmt.Sprintf("%s ge %d le %d", t.Prefix.String(), t.GreaterOrEqual, t.LessOrEqual)
+		})
+		for _, duplicate := range duplicates {
+			conflicts = append(conflicts, &Conflict{
+				Device: prefixList.Device.Name,
+				Kind:   "prefix-list",
+				Key:    prefixList.Name,
+				IDs:    []*int{prefixList.ID},
+				Reason: "has duplicate entry " + duplicate,
+			})
+		}
 	}
 
-	return prefixListsPerDevice
+	prefixListsPerDevice, nameConflicts := precomputePerDevice("prefix-list", prefixLists,
+		func(p *routingpolicy.PrefixList) string { return p.Device.Name },
+		func(p *routingpolicy.PrefixList) string { return p.Name },
+		func(p *routingpolicy.PrefixList) *int { return p.ID },
+	)
+
+	return prefixListsPerDevice, append(conflicts, nameConflicts...)
 }
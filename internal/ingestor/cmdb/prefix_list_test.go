@@ -47,6 +47,7 @@ func TestPrecomputePrefixLists(t *testing.T) {
 			want: map[string][]*routingpolicy.PrefixList{
 				"tor01-01": {
 					&routingpolicy.PrefixList{
+						ID:   &id1,
 						Name: "SERVER:VLAN:PROD",
 						Device: struct {
 							Name string `json:"name" validate:"required"`
@@ -89,9 +90,54 @@ func TestPrecomputePrefixLists(t *testing.T) {
 			continue
 		}
 
-		out := cmdb.PrecomputePrefixLists(cmdbOutput)
+		out, conflicts := cmdb.PrecomputePrefixLists(cmdbOutput)
+		if len(conflicts) > 0 {
+			t.Errorf("unexpected conflicts for '%s': %v", test.name, conflicts)
+		}
 		if diff := cmp.Diff(out, test.want); diff != "" {
 			t.Errorf("unexpected diff for '%s': %s\n", test.name, diff)
 		}
 	}
 }
+
+func TestValidatePrefixList(t *testing.T) {
+	tests := []struct {
+		name      string
+		ipVersion routingpolicy.IPVersion
+		prefix    string
+		ge        int
+		le        int
+		valid     bool
+	}{
+		{name: "exact", ipVersion: routingpolicy.IPv4, prefix: "192.0.2.0/24", valid: true},
+		{name: "range", ipVersion: routingpolicy.IPv4, prefix: "192.0.2.0/24", ge: 26, le: 32, valid: true},
+		{name: "ipv6 range", ipVersion: routingpolicy.IPv6, prefix: "2001:db8::/32", le: 48, valid: true},
+		{name: "address family mismatch", ipVersion: routingpolicy.IPv6, prefix: "192.0.2.0/24"},
+		{name: "host bits", ipVersion: routingpolicy.IPv4, prefix: "192.0.2.1/24"},
+		{name: "ge below prefix length", ipVersion: routingpolicy.IPv4, prefix: "192.0.2.0/24", ge: 16},
+		{name: "le above address length", ipVersion: routingpolicy.IPv4, prefix: "192.0.2.0/24", le: 33},
+		{name: "ge above le", ipVersion: routingpolicy.IPv4, prefix: "192.0.2.0/24", ge: 30, le: 28},
+	}
+
+	for _, test := range tests {
+		prefix, err := types.ParseCIDR(test.prefix)
+		if err != nil {
+			t.Errorf("unable to load test data for '%s': %s", test.name, err)
+			continue
+		}
+
+		prefixList := &routingpolicy.PrefixList{
+			Name:      "TEST",
+			IPVersion: test.ipVersion,
+			Terms:     []*routingpolicy.PrefixListTerm{{Prefix: prefix, GreaterOrEqual: test.ge, LessOrEqual: test.le}},
+		}
+
+		err = cmdb.ValidatePrefixList(prefixList)
+		if test.valid && err != nil {
+			t.Errorf("unexpected error for '%s': %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("expected an error for '%s'", test.name)
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package cmdb
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 
@@ -9,29 +12,146 @@ import (
 	"github.com/criteo/data-aggregation-api/internal/model/cmdb/routingpolicy"
 )
 
+const routePoliciesEndpoint = "/api/plugins/cmdb/route-policies/"
+
 // GetRoutePolicies returns all route-policies defined in the CDMB.
-func GetRoutePolicies() ([]*routingpolicy.RoutePolicy, error) {
+func GetRoutePolicies() ([]*routingpolicy.RoutePolicy, []*netbox.InvalidObject, error) {
 	response := netbox.NetboxResponse[routingpolicy.RoutePolicy]{}
 	params := deviceDatacenterFilter()
 
-	err := netbox.Get("/api/plugins/cmdb/route-policies/", &response, params)
+	err := netbox.Get(routePoliciesEndpoint, &response, params)
 	if err != nil {
-		return nil, fmt.Errorf("route-policies fetching failure: %w", err)
+		return nil, nil, fmt.Errorf("route-policies fetching failure: %w", err)
 	}
 
-	if response.Count != len(response.Results) {
+	if response.Count != len(response.Results)+len(response.Invalid) {
 		log.Warn().Msg("some route-policies have not been fetched")
 	}
 
-	return response.Results, nil
+	netbox.ExcludeInvalid(routePoliciesEndpoint, &response, ValidateRoutePolicy)
+
+	return response.Results, response.Invalid, nil
+}
+
+// validateSetCommunity validates the standard communities set by a term.
+// Removed communities may be regular expressions, as they are matched against the route communities.
+func validateSetCommunity(term *routingpolicy.RoutePolicyTerm) error {
+	if term.SetCommunityOption != routingpolicy.CommunityRemove {
+		return validateCommunityAction(routingpolicy.StandardCommunity, term.SetCommunity)
+	}
+
+	return validateCommunities(term.SetCommunity, func(community string) error {
+		return validateCommunityMatch(routingpolicy.StandardCommunity, community)
+	})
+}
+
+// validateNoLargeCommunity rejects the large community actions and matches, which cannot be rendered.
+func validateNoLargeCommunity(term *routingpolicy.RoutePolicyTerm) error {
+	if term.SetLargeCommunity != "" {
+		return fmt.Errorf("set_large_community: %w", errLargeCommunityUnsupported)
+	}
+	if term.FromBGPLargeCommunityList != nil {
+		return fmt.Errorf("from_bgp_large_community_list: %w", errLargeCommunityUnsupported)
+	}
+	return nil
+}
+
+// ValidateRoutePolicy checks the format of the communities set by the route-policy terms.
+// Large communities are rejected, as they cannot be rendered.
+func ValidateRoutePolicy(routePolicy *routingpolicy.RoutePolicy) error {
+	for _, term := range routePolicy.Terms {
+		if err := errors.Join(
+			validateSetCommunity(term),
+			validateCommunityAction(routingpolicy.ExtendedCommunity, term.SetExtCommunity),
+			validateNoLargeCommunity(term),
+		); err != nil {
+			return fmt.Errorf("route-policy %s (device %s) term %d: %w", routePolicy.Name, routePolicy.Device.Name, term.Sequence, err)
+		}
+	}
+
+	return nil
 }
 
 // PrecomputeRoutePolicies associates each found route-policies to the matching devices.
-func PrecomputeRoutePolicies(routePolicies []*routingpolicy.RoutePolicy) map[string][]*routingpolicy.RoutePolicy {
-	var routePoliciesPerDevice = make(map[string][]*routingpolicy.RoutePolicy)
+// Terms sharing the sequence of a previous term are dropped, only the first one is kept.
+func PrecomputeRoutePolicies(routePolicies []*routingpolicy.RoutePolicy) (map[string][]*routingpolicy.RoutePolicy, []*Conflict) {
+	var conflicts []*Conflict
+	for _, routePolicy := range routePolicies {
+		var duplicates []string
+		routePolicy.Terms, duplicates = uniqueTerms(routePolicy.Terms, func(t *routingpolicy.RoutePolicyTerm) string {
+			return strconv.Itoa(t.Sequence)
+		})
+		for _, duplicate := range duplicates {
+			conflicts = append(conflicts, &Conflict{
+				Device: routePolicy.Device.Name,
+				Kind:   "route-policy",
+				Key:    routePolicy.Name,
+				IDs:    []*int{routePolicy.ID},
+				Reason: "has duplicate term sequence " + duplicate,
+			})
+		}
+	}
+
+	routePoliciesPerDevice, nameConflicts := precomputePerDevice("route-policy", routePolicies,
+		func(r *routingpolicy.RoutePolicy) string { return r.Device.Name },
+		func(r *routingpolicy.RoutePolicy) string { return r.Name },
+		func(r *routingpolicy.RoutePolicy) *int { return r.ID },
+	)
+
+	return routePoliciesPerDevice, append(conflicts, nameConflicts...)
+}
+
+type callState int
+
+const (
+	notVisited callState = iota
+	visiting
+	visited
+)
+
+// CheckRoutePolicyCalls ensures the route-policies called by a device terms are not recursively called.
+// The device route-policies must be given, as a term can only call a route-policy of the same device.
+// Calls to unknown route-policies are left to the reference validation, which honors Build.FailOnMissingReferences.
+func CheckRoutePolicyCalls(routePolicies []*routingpolicy.RoutePolicy) error {
+	var policies = make(map[string]*routingpolicy.RoutePolicy, len(routePolicies))
+	for _, routePolicy := range routePolicies {
+		policies[routePolicy.Name] = routePolicy
+	}
+
+	var states = make(map[string]callState, len(routePolicies))
+	var visit func(routePolicy *routingpolicy.RoutePolicy, path []string) error
+	visit = func(routePolicy *routingpolicy.RoutePolicy, path []string) error {
+		path = append(path, routePolicy.Name)
+		switch states[routePolicy.Name] {
+		case visiting:
+			return fmt.Errorf("recursive route-policy call: %s", strings.Join(path, " -> "))
+		case visited:
+			return nil
+		}
+
+		states[routePolicy.Name] = visiting
+		for _, term := range routePolicy.Terms {
+			if term.CallPolicy == nil {
+				continue
+			}
+			called, ok := policies[term.CallPolicy.Name]
+			if !ok {
+				continue
+			}
+			if err := visit(called, path); err != nil {
+				return err
+			}
+		}
+		states[routePolicy.Name] = visited
+
+		return nil
+	}
+
 	for _, routePolicy := range routePolicies {
-		routePoliciesPerDevice[routePolicy.Device.Name] = append(routePoliciesPerDevice[routePolicy.Device.Name], routePolicy)
+		if err := visit(routePolicy, nil); err != nil {
+			return err
+		}
 	}
 
-	return routePoliciesPerDevice
+	return nil
 }
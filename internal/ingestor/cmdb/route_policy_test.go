@@ -58,6 +58,7 @@ func TestPrecomputeRoutePolicies(t *testing.T) {
 			want: map[string][]*routingpolicy.RoutePolicy{
 				"tor01-01": {
 					&routingpolicy.RoutePolicy{
+						ID:   &id1,
 						Name: "SERVER:PROD:OUT",
 						Device: struct {
 							Name string `json:"name" validate:"required"`
@@ -158,6 +159,7 @@ func TestPrecomputeRoutePolicies(t *testing.T) {
 			want: map[string][]*routingpolicy.RoutePolicy{
 				"tor01-01": {
 					&routingpolicy.RoutePolicy{
+						ID:   &id2,
 						Name: "LAN:IN",
 						Device: struct {
 							Name string `json:"name" validate:"required"`
@@ -229,9 +231,92 @@ func TestPrecomputeRoutePolicies(t *testing.T) {
 			continue
 		}
 
-		out := cmdb.PrecomputeRoutePolicies(cmdbOutput)
+		out, conflicts := cmdb.PrecomputeRoutePolicies(cmdbOutput)
+		if len(conflicts) > 0 {
+			t.Errorf("unexpected conflicts for '%s': %v", test.name, conflicts)
+		}
 		if diff := cmp.Diff(out, test.want); diff != "" {
 			t.Errorf("unexpected diff for '%s': %s\n", test.name, diff)
 		}
 	}
 }
+
+func TestValidateRoutePolicy(t *testing.T) {
+	valid := &routingpolicy.RoutePolicyTerm{
+		Sequence:        1,
+		SetCommunity:    "65000:1 65000:2",
+		SetExtCommunity: "rt:65000:100",
+	}
+	if err := cmdb.ValidateRoutePolicy(&routingpolicy.RoutePolicy{Name: "VALID", Terms: []*routingpolicy.RoutePolicyTerm{valid}}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	// a set action does not accept regular expressions
+	invalid := &routingpolicy.RoutePolicyTerm{Sequence: 1, SetCommunity: "650..:999"}
+	if err := cmdb.ValidateRoutePolicy(&routingpolicy.RoutePolicy{Name: "INVALID", Terms: []*routingpolicy.RoutePolicyTerm{invalid}}); err == nil {
+		t.Errorf("expected an error for a community regex in a set action")
+	}
+
+	// OpenConfig has no large communities, the route-policy is excluded instead of failing the build
+	setLarge := &routingpolicy.RoutePolicyTerm{Sequence: 1, SetLargeCommunity: "65000:1:2"}
+	if err := cmdb.ValidateRoutePolicy(&routingpolicy.RoutePolicy{Name: "SET-LARGE", Terms: []*routingpolicy.RoutePolicyTerm{setLarge}}); err == nil {
+		t.Errorf("expected an error for a set-large-community action")
+	}
+
+	matchLarge := &routingpolicy.RoutePolicyTerm{Sequence: 1}
+	matchLarge.FromBGPLargeCommunityList = &struct {
+		Name string `json:"name" validate:"required"`
+	}{Name: "LARGE"}
+	if err := cmdb.ValidateRoutePolicy(&routingpolicy.RoutePolicy{Name: "MATCH-LARGE", Terms: []*routingpolicy.RoutePolicyTerm{matchLarge}}); err == nil {
+		t.Errorf("expected an error for a large community-list match")
+	}
+}
+
+func TestValidateRoutePolicyRemoveCommunity(t *testing.T) {
+	// removed communities are matched against the route, so they can be regular expressions
+	term := &routingpolicy.RoutePolicyTerm{
+		Sequence:           1,
+		SetCommunity:       "650..:666",
+		SetCommunityOption: routingpolicy.CommunityRemove,
+	}
+	if err := cmdb.ValidateRoutePolicy(&routingpolicy.RoutePolicy{Name: "REMOVE", Terms: []*routingpolicy.RoutePolicyTerm{term}}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestCheckRoutePolicyCalls(t *testing.T) {
+	newPolicy := func(name string, calls ...string) *routingpolicy.RoutePolicy {
+		policy := &routingpolicy.RoutePolicy{Name: name}
+		for i, call := range calls {
+			term := &routingpolicy.RoutePolicyTerm{Sequence: i + 1, Decision: routingpolicy.NextStatement}
+			term.CallPolicy = &struct {
+				Name string `json:"name" validate:"required"`
+			}{Name: call}
+			policy.Terms = append(policy.Terms, term)
+		}
+		return policy
+	}
+
+	tests := []struct {
+		name     string
+		policies []*routingpolicy.RoutePolicy
+		valid    bool
+	}{
+		{"no call", []*routingpolicy.RoutePolicy{newPolicy("A")}, true},
+		{"nested calls", []*routingpolicy.RoutePolicy{newPolicy("A", "B", "C"), newPolicy("B", "C"), newPolicy("C")}, true},
+		// missing route-policies are reported by the reference validation
+		{"missing policy", []*routingpolicy.RoutePolicy{newPolicy("A", "B")}, true},
+		{"self call", []*routingpolicy.RoutePolicy{newPolicy("A", "A")}, false},
+		{"call cycle", []*routingpolicy.RoutePolicy{newPolicy("A", "B"), newPolicy("B", "C"), newPolicy("C", "A")}, false},
+	}
+
+	for _, test := range tests {
+		err := cmdb.CheckRoutePolicyCalls(test.policies)
+		if test.valid && err != nil {
+			t.Errorf("unexpected error for '%s': %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("expected an error for '%s'", test.name)
+		}
+	}
+}
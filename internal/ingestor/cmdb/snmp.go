@@ -10,28 +10,35 @@ import (
 )
 
 // GetSNMP returns all Snmp configuration from the Network CMDB.
-func GetSNMP() ([]*snmp.SNMP, error) {
+func GetSNMP() ([]*snmp.SNMP, []*netbox.InvalidObject, error) {
 	response := netbox.NetboxResponse[snmp.SNMP]{}
 	params := deviceDatacenterFilter()
 
 	err := netbox.Get("/api/plugins/cmdb/snmp/", &response, params)
 	if err != nil {
-		return nil, fmt.Errorf("SNMP fetching failure: %w", err)
+		return nil, nil, fmt.Errorf("SNMP fetching failure: %w", err)
 	}
 
 	if len(response.Results) == 0 {
 		log.Warn().Msg("no SNMP configuration found")
 	}
 
-	return response.Results, nil
+	return response.Results, response.Invalid, nil
 }
 
 // PrecomputeSNMP associates each found Snmp configuration to the matching devices.
-func PrecomputeSNMP(globalConfigs []*snmp.SNMP) map[string]*snmp.SNMP {
+// Only one SNMP configuration is expected per device.
+func PrecomputeSNMP(globalConfigs []*snmp.SNMP) (map[string]*snmp.SNMP, []*Conflict) {
+	configsPerDevice, conflicts := precomputePerDevice("SNMP configuration", globalConfigs,
+		func(c *snmp.SNMP) string { return c.Device.Name },
+		func(c *snmp.SNMP) string { return c.Device.Name },
+		func(c *snmp.SNMP) *int { return c.ID },
+	)
+
 	var SNMPPerDevice = make(map[string]*snmp.SNMP)
-	for _, config := range globalConfigs {
-		SNMPPerDevice[config.Device.Name] = config
+	for device, configs := range configsPerDevice {
+		SNMPPerDevice[device] = configs[0]
 	}
 
-	return SNMPPerDevice
+	return SNMPPerDevice, conflicts
 }
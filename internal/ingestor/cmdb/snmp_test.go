@@ -42,6 +42,7 @@ func TestPrecomputeSNMP(t *testing.T) {
       ]`,
 			want: map[string]*snmp.SNMP{
 				"tor01-01": {
+					ID: &id1,
 					Device: struct {
 						Name string "json:\"name\" validate:\"required\""
 					}{
@@ -71,7 +72,10 @@ func TestPrecomputeSNMP(t *testing.T) {
 			continue
 		}
 
-		out := cmdb.PrecomputeSNMP(cmdbOutput)
+		out, conflicts := cmdb.PrecomputeSNMP(cmdbOutput)
+		if len(conflicts) > 0 {
+			t.Errorf("unexpected conflicts for '%s': %v", test.name, conflicts)
+		}
 		if diff := cmp.Diff(out, test.want); diff != "" {
 			t.Errorf("unexpected diff for '%s': %s\n", test.name, diff)
 		}
@@ -12,7 +12,7 @@ import (
 )
 
 // GetNetworkInventory returns network device inventory from NetBox DCIM.
-func GetNetworkInventory() ([]*dcim.NetworkDevice, error) {
+func GetNetworkInventory() ([]*dcim.NetworkDevice, []*netbox.InvalidObject, error) {
 	response := netbox.NetboxResponse[dcim.NetworkDevice]{}
 
 	params := url.Values{}
@@ -21,13 +21,13 @@ func GetNetworkInventory() ([]*dcim.NetworkDevice, error) {
 		params.Add(filter.Filter, filter.Value)
 	}
 
-	if err := netbox.Get("/api/dcim/devices/", &response, params); err != nil {
-		return nil, fmt.Errorf("network inventory fetching failure: %w", err)
+	if err := netbox.Get(netbox.DevicesEndpoint, &response, params); err != nil {
+		return nil, nil, fmt.Errorf("network inventory fetching failure: %w", err)
 	}
 
-	if response.Count != len(response.Results) {
+	if response.Count != len(response.Results)+len(response.Invalid) {
 		log.Warn().Msg("some devices have not been fetched")
 	}
 
-	return response.Results, nil
+	return response.Results, response.Invalid, nil
 }
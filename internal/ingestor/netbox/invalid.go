@@ -0,0 +1,90 @@
+package netbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// DevicesEndpoint is the NetBox DCIM endpoint listing the network devices.
+const DevicesEndpoint = "/api/dcim/devices/"
+
+// InvalidObject is a NetBox object excluded from the ingested data.
+type InvalidObject struct {
+	Endpoint string
+	ID       *int
+	// Devices are the names of the devices referenced by the object.
+	Devices []string
+	// Errors are the decoding or validation errors, one per invalid field.
+	Errors []string
+}
+
+func (o *InvalidObject) Error() string {
+	id := "unknown"
+	if o.ID != nil {
+		id = strconv.Itoa(*o.ID)
+	}
+	return fmt.Sprintf("%s object %s is invalid: %s", o.Endpoint, id, strings.Join(o.Errors, "; "))
+}
+
+// newInvalidObject extracts the NetBox ID and the referenced devices of an object failing validation.
+func newInvalidObject(endpoint string, raw json.RawMessage, err error) *InvalidObject {
+	invalid := &InvalidObject{Endpoint: endpoint}
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		for _, fieldError := range validationErrors {
+			invalid.Errors = append(invalid.Errors, fmt.Sprintf("field '%s' failed on '%s'", fieldError.Namespace(), fieldError.Tag()))
+		}
+	} else {
+		invalid.Errors = append(invalid.Errors, err.Error())
+	}
+
+	var object map[string]any
+	if json.Unmarshal(raw, &object) != nil {
+		return invalid
+	}
+
+	if id, ok := object["id"].(float64); ok {
+		intID := int(id)
+		invalid.ID = &intID
+	}
+	var devices []string
+	if endpoint == DevicesEndpoint {
+		// an inventory item is the device itself
+		if name, ok := object["name"].(string); ok && name != "" {
+			devices = append(devices, name)
+		}
+	} else {
+		devices = referencedDevices(object, nil)
+	}
+	slices.Sort(devices)
+	invalid.Devices = slices.Compact(devices)
+
+	return invalid
+}
+
+// referencedDevices walks the object looking for nested `"device": {"name": ...}` references.
+func referencedDevices(value any, devices []string) []string {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if device, ok := child.(map[string]any); ok && key == "device" {
+				if name, ok := device["name"].(string); ok && name != "" {
+					devices = append(devices, name)
+				}
+			}
+			devices = referencedDevices(child, devices)
+		}
+	case []any:
+		for _, child := range v {
+			devices = referencedDevices(child, devices)
+		}
+	}
+	return devices
+}
@@ -14,10 +14,21 @@ import (
 	"github.com/criteo/data-aggregation-api/internal/config"
 )
 
+const endpointKey = "endpoint"
+
 type NetboxResponse[R any] struct {
 	Next    string `json:"next"`
 	Results []*R   `json:"results" validate:"dive"`
 	Count   int    `json:"count"`
+	// Invalid lists the results excluded because they failed to be decoded or validated.
+	Invalid []*InvalidObject `json:"-"`
+}
+
+// rawResponse is a NetBox page whose results are decoded and validated one by one.
+type rawResponse struct {
+	Next    string            `json:"next"`
+	Results []json.RawMessage `json:"results"`
+	Count   int               `json:"count"`
 }
 
 // NewGetRequest returns a prepared Netbox request with the authentication set.
@@ -33,7 +44,6 @@ func NewGetRequest(url string) (*http.Request, error) {
 
 // Get fetches a Netbox endpoint.
 func Get[R any](endpoint string, out *NetboxResponse[R], params url.Values) error {
-	const endpointKey = "endpoint"
 	client := http.Client{Timeout: 10 * 60 * time.Second}
 
 	params.Set("limit", strconv.Itoa(config.Cfg.NetBox.LimitPerPage))
@@ -47,6 +57,8 @@ func Get[R any](endpoint string, out *NetboxResponse[R], params url.Values) erro
 	url := baseURL + "?" + params.Encode()
 	log.Info().Str(endpointKey, endpoint).Msgf("Get %s", url)
 
+	validate := validator.New()
+
 	// Get all pages
 	for url != "" {
 		req, err := NewGetRequest(url)
@@ -68,13 +80,22 @@ func Get[R any](endpoint string, out *NetboxResponse[R], params url.Values) erro
 			return fmt.Errorf("netbox returned HTTP error code: '%s'", data.Status)
 		}
 
-		var buffer NetboxResponse[R]
+		var buffer rawResponse
 		err = json.NewDecoder(data.Body).Decode(&buffer)
 		if err != nil {
 			return fmt.Errorf("failed to decode netbox response: %w", err)
 		}
 
-		out.Results = append(out.Results, buffer.Results...)
+		for _, raw := range buffer.Results {
+			item, err := decodeItem[R](validate, raw)
+			if err != nil {
+				invalid := newInvalidObject(endpoint, raw, err)
+				log.Warn().Str(endpointKey, endpoint).Err(invalid).Msg("invalid object excluded")
+				out.Invalid = append(out.Invalid, invalid)
+				continue
+			}
+			out.Results = append(out.Results, item)
+		}
 
 		// Print paging status
 		log.Debug().Str(endpointKey, endpoint).Msgf("next: %s", buffer.Next)
@@ -83,11 +104,36 @@ func Get[R any](endpoint string, out *NetboxResponse[R], params url.Values) erro
 		out.Count = buffer.Count
 	}
 
-	// Validate
-	validate := validator.New()
-	if err := validate.Struct(out); err != nil {
-		return err
+	return nil
+}
+
+// ExcludeInvalid moves the results failing a semantic check to the invalid objects,
+// so only the devices referencing them are affected instead of the whole ingestion.
+func ExcludeInvalid[R any](endpoint string, out *NetboxResponse[R], check func(*R) error) {
+	valid := make([]*R, 0, len(out.Results))
+	for _, item := range out.Results {
+		if err := check(item); err != nil {
+			raw, _ := json.Marshal(item)
+			invalid := newInvalidObject(endpoint, raw, err)
+			log.Warn().Str(endpointKey, endpoint).Err(invalid).Msg("invalid object excluded")
+			out.Invalid = append(out.Invalid, invalid)
+			continue
+		}
+		valid = append(valid, item)
 	}
+	out.Results = valid
+}
 
-	return nil
+// decodeItem decodes and validates a single result, so an invalid object does not discard the others.
+func decodeItem[R any](validate *validator.Validate, raw json.RawMessage) (*R, error) {
+	var item R
+	if err := json.Unmarshal(raw, &item); err != nil {
+		return nil, fmt.Errorf("failed to decode: %w", err)
+	}
+
+	if err := validate.Struct(&item); err != nil {
+		return nil, err
+	}
+
+	return &item, nil
 }
@@ -0,0 +1,128 @@
+package netbox_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/criteo/data-aggregation-api/internal/config"
+	"github.com/criteo/data-aggregation-api/internal/ingestor/netbox"
+)
+
+type session struct {
+	ID    int `json:"id"`
+	PeerA struct {
+		Device struct {
+			Name string `json:"name" validate:"required"`
+		} `json:"device"`
+	} `json:"peer_a"`
+	PeerB struct {
+		Device struct {
+			Name string `json:"name" validate:"required"`
+		} `json:"device"`
+	} `json:"peer_b"`
+	Status string `json:"status" validate:"required"`
+}
+
+func TestGetExcludesInvalidObjects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`
+		{
+			"next": "",
+			"count": 3,
+			"results": [
+				{"id": 1, "peer_a": {"device": {"name": "tor01-01"}}, "peer_b": {"device": {"name": "spine01-01"}}, "status": "active"},
+				{"id": 2, "peer_a": {"device": {"name": "tor01-02"}}, "peer_b": {"device": {"name": "spine01-01"}}, "status": ""},
+				{"id": 3, "peer_a": {"device": {"name": "tor01-03"}}, "peer_b": {"device": {"name": "spine01-02"}}, "status": 42}
+			]
+		}`))
+	}))
+	defer server.Close()
+	config.Cfg.NetBox.URL = server.URL
+
+	var response netbox.NetboxResponse[session]
+	if err := netbox.Get("/api/plugins/cmdb/bgp-sessions/", &response, url.Values{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(response.Results) != 1 || response.Results[0].ID != 1 {
+		t.Errorf("expected only the valid session to be kept, got %v", response.Results)
+	}
+
+	if len(response.Invalid) != 2 {
+		t.Fatalf("expected 2 invalid objects, got %d", len(response.Invalid))
+	}
+
+	id2 := 2
+	want := &netbox.InvalidObject{
+		Endpoint: "/api/plugins/cmdb/bgp-sessions/",
+		ID:       &id2,
+		Devices:  []string{"spine01-01", "tor01-02"},
+		Errors:   []string{"field 'session.Status' failed on 'required'"},
+	}
+	if diff := cmp.Diff(response.Invalid[0], want); diff != "" {
+		t.Errorf("unexpected diff: %s\n", diff)
+	}
+
+	if diff := cmp.Diff(response.Invalid[1].Devices, []string{"spine01-02", "tor01-03"}); diff != "" {
+		t.Errorf("unexpected diff for undecodable object: %s\n", diff)
+	}
+}
+
+func TestExcludeInvalid(t *testing.T) {
+	response := netbox.NetboxResponse[session]{Results: []*session{{ID: 1, Status: "active"}, {ID: 2, Status: "planned"}}}
+	response.Results[1].PeerA.Device.Name = "tor01-02"
+	response.Results[1].PeerB.Device.Name = "spine01-01"
+
+	netbox.ExcludeInvalid("/api/plugins/cmdb/bgp-sessions/", &response, func(s *session) error {
+		if s.Status != "active" {
+			return errors.New("the session is not active")
+		}
+		return nil
+	})
+
+	if len(response.Results) != 1 || response.Results[0].ID != 1 {
+		t.Errorf("expected only the active session to be kept, got %v", response.Results)
+	}
+
+	id2 := 2
+	want := []*netbox.InvalidObject{{
+		Endpoint: "/api/plugins/cmdb/bgp-sessions/",
+		ID:       &id2,
+		Devices:  []string{"spine01-01", "tor01-02"},
+		Errors:   []string{"the session is not active"},
+	}}
+	if diff := cmp.Diff(response.Invalid, want); diff != "" {
+		t.Errorf("unexpected diff: %s\n", diff)
+	}
+}
+
+func TestGetInvalidDevice(t *testing.T) {
+	type device struct {
+		Name   string `json:"name"   validate:"required"`
+		Serial string `json:"serial" validate:"required"`
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"next": "", "count": 1, "results": [{"id": 1, "name": "tor01-01", "serial": ""}]}`))
+	}))
+	defer server.Close()
+	config.Cfg.NetBox.URL = server.URL
+
+	var response netbox.NetboxResponse[device]
+	if err := netbox.Get(netbox.DevicesEndpoint, &response, url.Values{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// the invalid device must be reported against itself, not silently dropped
+	if len(response.Invalid) != 1 {
+		t.Fatalf("expected 1 invalid object, got %d", len(response.Invalid))
+	}
+	if diff := cmp.Diff(response.Invalid[0].Devices, []string{"tor01-01"}); diff != "" {
+		t.Errorf("unexpected diff: %s\n", diff)
+	}
+}
@@ -6,10 +6,11 @@ import (
 
 	"github.com/criteo/data-aggregation-api/internal/ingestor/cmdb"
 	"github.com/criteo/data-aggregation-api/internal/ingestor/dcim"
+	"github.com/criteo/data-aggregation-api/internal/ingestor/netbox"
 	"github.com/criteo/data-aggregation-api/internal/report"
 )
 
-const ingestorNumber = 7
+const ingestorNumber = 10
 
 // FetchAssets get data from all ingestors.
 func FetchAssets(reportCh chan report.Message) (*Assets, error) {
@@ -18,6 +19,14 @@ func FetchAssets(reportCh chan report.Message) (*Assets, error) {
 
 	var fetchFailure = make(chan report.Severity, ingestorNumber)
 
+	// Invalid objects are excluded by the ingestors, the devices referencing them are failed during precompute
+	var invalidMu sync.Mutex
+	addInvalid := func(invalid []*netbox.InvalidObject) {
+		invalidMu.Lock()
+		defer invalidMu.Unlock()
+		repo.InvalidObjects = append(repo.InvalidObjects, invalid...)
+	}
+
 	// TODO: severity should be defined by the user via the configuration file
 	// TODO: factorize
 
@@ -25,7 +34,7 @@ func FetchAssets(reportCh chan report.Message) (*Assets, error) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		if v, err := dcim.GetNetworkInventory(); err != nil {
+		if v, invalid, err := dcim.GetNetworkInventory(); err != nil {
 			reportCh <- report.Message{
 				Type:     report.IngestorMessage,
 				Severity: report.Error,
@@ -34,6 +43,7 @@ func FetchAssets(reportCh chan report.Message) (*Assets, error) {
 			fetchFailure <- report.Error
 		} else {
 			repo.DeviceInventory = v
+			addInvalid(invalid)
 		}
 	}()
 
@@ -41,7 +51,7 @@ func FetchAssets(reportCh chan report.Message) (*Assets, error) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		if v, err := cmdb.GetBGPGlobal(); err != nil {
+		if v, invalid, err := cmdb.GetBGPGlobal(); err != nil {
 			reportCh <- report.Message{
 				Type:     report.IngestorMessage,
 				Severity: report.Warning,
@@ -50,6 +60,7 @@ func FetchAssets(reportCh chan report.Message) (*Assets, error) {
 			fetchFailure <- report.Warning
 		} else {
 			repo.CmdbBGPGlobal = v
+			addInvalid(invalid)
 		}
 	}()
 
@@ -57,7 +68,7 @@ func FetchAssets(reportCh chan report.Message) (*Assets, error) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		if v, err := cmdb.GetBGPSessions(); err != nil {
+		if v, invalid, err := cmdb.GetBGPSessions(); err != nil {
 			reportCh <- report.Message{
 				Type:     report.IngestorMessage,
 				Severity: report.Error,
@@ -66,6 +77,7 @@ func FetchAssets(reportCh chan report.Message) (*Assets, error) {
 			fetchFailure <- report.Error
 		} else {
 			repo.CmdbBGPSessions = v
+			addInvalid(invalid)
 		}
 	}()
 
@@ -73,7 +85,7 @@ func FetchAssets(reportCh chan report.Message) (*Assets, error) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		if v, err := cmdb.GetPeerGroups(); err != nil { //nolint:staticcheck // to ignore deprecation notice
+		if v, invalid, err := cmdb.GetPeerGroups(); err != nil { //nolint:staticcheck // to ignore deprecation notice
 			reportCh <- report.Message{
 				Type:     report.IngestorMessage,
 				Severity: report.Warning,
@@ -82,6 +94,24 @@ func FetchAssets(reportCh chan report.Message) (*Assets, error) {
 			fetchFailure <- report.Warning
 		} else {
 			repo.CmdbPeerGroups = v
+			addInvalid(invalid)
+		}
+	}()
+
+	// Dynamic neighbors
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if v, invalid, err := cmdb.GetDynamicNeighbors(); err != nil {
+			reportCh <- report.Message{
+				Type:     report.IngestorMessage,
+				Severity: report.Warning,
+				Text:     err.Error(),
+			}
+			fetchFailure <- report.Warning
+		} else {
+			repo.CmdbDynamicNeighbors = v
+			addInvalid(invalid)
 		}
 	}()
 
@@ -89,7 +119,7 @@ func FetchAssets(reportCh chan report.Message) (*Assets, error) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		if v, err := cmdb.GetRoutePolicies(); err != nil {
+		if v, invalid, err := cmdb.GetRoutePolicies(); err != nil {
 			reportCh <- report.Message{
 				Type:     report.IngestorMessage,
 				Severity: report.Error,
@@ -98,6 +128,7 @@ func FetchAssets(reportCh chan report.Message) (*Assets, error) {
 			fetchFailure <- report.Error
 		} else {
 			repo.CmdbRoutePolicies = v
+			addInvalid(invalid)
 		}
 	}()
 
@@ -105,7 +136,7 @@ func FetchAssets(reportCh chan report.Message) (*Assets, error) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		if v, err := cmdb.GetPrefixLists(); err != nil {
+		if v, invalid, err := cmdb.GetPrefixLists(); err != nil {
 			reportCh <- report.Message{
 				Type:     report.IngestorMessage,
 				Severity: report.Error,
@@ -114,6 +145,7 @@ func FetchAssets(reportCh chan report.Message) (*Assets, error) {
 			fetchFailure <- report.Error
 		} else {
 			repo.CmdbPrefixLists = v
+			addInvalid(invalid)
 		}
 	}()
 
@@ -121,7 +153,7 @@ func FetchAssets(reportCh chan report.Message) (*Assets, error) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		if v, err := cmdb.GetCommunityLists(); err != nil {
+		if v, invalid, err := cmdb.GetCommunityLists(); err != nil {
 			reportCh <- report.Message{
 				Type:     report.IngestorMessage,
 				Severity: report.Error,
@@ -130,13 +162,31 @@ func FetchAssets(reportCh chan report.Message) (*Assets, error) {
 			fetchFailure <- report.Error
 		} else {
 			repo.CmdbCommunityLists = v
+			addInvalid(invalid)
+		}
+	}()
+
+	// AS-path lists
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if v, invalid, err := cmdb.GetASPathLists(); err != nil {
+			reportCh <- report.Message{
+				Type:     report.IngestorMessage,
+				Severity: report.Warning,
+				Text:     err.Error(),
+			}
+			fetchFailure <- report.Warning
+		} else {
+			repo.CmdbASPathLists = v
+			addInvalid(invalid)
 		}
 	}()
 
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		if v, err := cmdb.GetSNMP(); err != nil {
+		if v, invalid, err := cmdb.GetSNMP(); err != nil {
 			reportCh <- report.Message{
 				Type:     report.IngestorMessage,
 				Severity: report.Warning,
@@ -145,6 +195,7 @@ func FetchAssets(reportCh chan report.Message) (*Assets, error) {
 			fetchFailure <- report.Warning
 		} else {
 			repo.CmdbSNMP = v
+			addInvalid(invalid)
 		}
 	}()
 
@@ -160,6 +211,16 @@ func FetchAssets(reportCh chan report.Message) (*Assets, error) {
 			failed = true
 		}
 	}
+
+	// All ingestors are done once fetchFailure is closed
+	for _, invalid := range repo.InvalidObjects {
+		reportCh <- report.Message{
+			Type:     report.IngestorMessage,
+			Severity: report.Warning,
+			Text:     invalid.Error(),
+		}
+	}
+
 	if failed {
 		return &repo, errors.New("ingestors failed to fetch the assets")
 	}
@@ -6,6 +6,7 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/criteo/data-aggregation-api/internal/ingestor/cmdb"
+	"github.com/criteo/data-aggregation-api/internal/ingestor/netbox"
 	"github.com/criteo/data-aggregation-api/internal/model/cmdb/bgp"
 	"github.com/criteo/data-aggregation-api/internal/model/cmdb/routingpolicy"
 	"github.com/criteo/data-aggregation-api/internal/model/cmdb/snmp"
@@ -22,48 +23,86 @@ func statsReport(message string, severity report.Severity) report.Message {
 }
 
 type AssetsPerDevice struct {
-	BGPGlobal      map[string]*bgp.BGPGlobal
-	BGPsessions    map[string][]*bgp.Session
-	PeerGroups     map[string][]*bgp.PeerGroup
-	PrefixLists    map[string][]*routingpolicy.PrefixList
-	CommunityLists map[string][]*routingpolicy.CommunityList
-	RoutePolicies  map[string][]*routingpolicy.RoutePolicy
-	SNMP           map[string]*snmp.SNMP
+	BGPGlobal        map[string]*bgp.BGPGlobal
+	BGPsessions      map[string][]*bgp.Session
+	PeerGroups       map[string][]*bgp.PeerGroup
+	DynamicNeighbors map[string][]*bgp.DynamicNeighbor
+	PrefixLists      map[string][]*routingpolicy.PrefixList
+	CommunityLists   map[string][]*routingpolicy.CommunityList
+	ASPathLists      map[string][]*routingpolicy.ASPathList
+	RoutePolicies    map[string][]*routingpolicy.RoutePolicy
+	SNMP             map[string]*snmp.SNMP
+	// Conflicts lists the colliding CMDB objects dropped during precompute, per device.
+	Conflicts map[string][]*cmdb.Conflict
+	// InvalidObjects lists the objects excluded by the ingestors, per referencing device.
+	InvalidObjects map[string][]*netbox.InvalidObject
 }
 
 type Assets struct {
-	DeviceInventory    []*dcim.NetworkDevice
-	CmdbBGPGlobal      []*bgp.BGPGlobal
-	CmdbBGPSessions    []*bgp.Session
-	CmdbPeerGroups     []*bgp.PeerGroup
-	CmdbRoutePolicies  []*routingpolicy.RoutePolicy
-	CmdbPrefixLists    []*routingpolicy.PrefixList
-	CmdbCommunityLists []*routingpolicy.CommunityList
-	CmdbSNMP           []*snmp.SNMP
+	DeviceInventory      []*dcim.NetworkDevice
+	CmdbBGPGlobal        []*bgp.BGPGlobal
+	CmdbBGPSessions      []*bgp.Session
+	CmdbPeerGroups       []*bgp.PeerGroup
+	CmdbDynamicNeighbors []*bgp.DynamicNeighbor
+	CmdbRoutePolicies    []*routingpolicy.RoutePolicy
+	CmdbPrefixLists      []*routingpolicy.PrefixList
+	CmdbCommunityLists   []*routingpolicy.CommunityList
+	CmdbASPathLists      []*routingpolicy.ASPathList
+	CmdbSNMP             []*snmp.SNMP
+	InvalidObjects       []*netbox.InvalidObject
 }
 
 func (i *Assets) Precompute() *AssetsPerDevice {
 	var precomputed AssetsPerDevice
-	precomputed.BGPGlobal = cmdb.PrecomputeBGPGlobal(i.CmdbBGPGlobal)
-	precomputed.BGPsessions = cmdb.PrecomputeBGPSessions(i.CmdbBGPSessions)
-	precomputed.PeerGroups = cmdb.PrecomputePeerGroups(i.CmdbPeerGroups) //nolint:staticcheck // to ignore deprecation notice
-	precomputed.PrefixLists = cmdb.PrecomputePrefixLists(i.CmdbPrefixLists)
-	precomputed.CommunityLists = cmdb.PrecomputeCommunityLists(i.CmdbCommunityLists)
-	precomputed.RoutePolicies = cmdb.PrecomputeRoutePolicies(i.CmdbRoutePolicies)
-	precomputed.SNMP = cmdb.PrecomputeSNMP(i.CmdbSNMP)
+	var conflicts, c []*cmdb.Conflict
+
+	precomputed.BGPGlobal, c = cmdb.PrecomputeBGPGlobal(i.CmdbBGPGlobal)
+	conflicts = append(conflicts, c...)
+	precomputed.BGPsessions, c = cmdb.PrecomputeBGPSessions(i.CmdbBGPSessions)
+	conflicts = append(conflicts, c...)
+	precomputed.PeerGroups, c = cmdb.PrecomputePeerGroups(i.CmdbPeerGroups) //nolint:staticcheck // to ignore deprecation notice
+	conflicts = append(conflicts, c...)
+	precomputed.DynamicNeighbors, c = cmdb.PrecomputeDynamicNeighbors(i.CmdbDynamicNeighbors)
+	conflicts = append(conflicts, c...)
+	precomputed.PrefixLists, c = cmdb.PrecomputePrefixLists(i.CmdbPrefixLists)
+	conflicts = append(conflicts, c...)
+	precomputed.CommunityLists, c = cmdb.PrecomputeCommunityLists(i.CmdbCommunityLists)
+	conflicts = append(conflicts, c...)
+	precomputed.ASPathLists, c = cmdb.PrecomputeASPathLists(i.CmdbASPathLists)
+	conflicts = append(conflicts, c...)
+	precomputed.RoutePolicies, c = cmdb.PrecomputeRoutePolicies(i.CmdbRoutePolicies)
+	conflicts = append(conflicts, c...)
+	precomputed.SNMP, c = cmdb.PrecomputeSNMP(i.CmdbSNMP)
+	conflicts = append(conflicts, c...)
+
+	precomputed.Conflicts = make(map[string][]*cmdb.Conflict)
+	for _, conflict := range conflicts {
+		precomputed.Conflicts[conflict.Device] = append(precomputed.Conflicts[conflict.Device], conflict)
+	}
+
+	precomputed.InvalidObjects = make(map[string][]*netbox.InvalidObject)
+	for _, invalid := range i.InvalidObjects {
+		for _, device := range invalid.Devices {
+			precomputed.InvalidObjects[device] = append(precomputed.InvalidObjects[device], invalid)
+		}
+	}
+
 	return &precomputed
 }
 
 func (i *Assets) getStats() map[string]int {
 	return map[string]int{
-		"devices":        len(i.DeviceInventory),
-		"bgpGlobal":      len(i.CmdbBGPGlobal),
-		"bgpSessions":    len(i.CmdbBGPSessions),
-		"peerGroups":     len(i.CmdbPeerGroups),
-		"routePolicies":  len(i.CmdbRoutePolicies),
-		"prefixLists":    len(i.CmdbPrefixLists),
-		"communityLists": len(i.CmdbCommunityLists),
-		"SNMP":           len(i.CmdbSNMP),
+		"devices":          len(i.DeviceInventory),
+		"bgpGlobal":        len(i.CmdbBGPGlobal),
+		"bgpSessions":      len(i.CmdbBGPSessions),
+		"peerGroups":       len(i.CmdbPeerGroups),
+		"dynamicNeighbors": len(i.CmdbDynamicNeighbors),
+		"routePolicies":    len(i.CmdbRoutePolicies),
+		"prefixLists":      len(i.CmdbPrefixLists),
+		"communityLists":   len(i.CmdbCommunityLists),
+		"asPathLists":      len(i.CmdbASPathLists),
+		"SNMP":             len(i.CmdbSNMP),
+		"invalidObjects":   len(i.InvalidObjects),
 	}
 }
 
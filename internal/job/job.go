@@ -3,16 +3,20 @@ package job
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/rs/zerolog/log"
 
-	"github.com/criteo/data-aggregation-api/internal/api/router"
 	"github.com/criteo/data-aggregation-api/internal/config"
 	"github.com/criteo/data-aggregation-api/internal/convertor/device"
+	rpconvertors "github.com/criteo/data-aggregation-api/internal/convertor/routingpolicy"
+	"github.com/criteo/data-aggregation-api/internal/guard"
+	"github.com/criteo/data-aggregation-api/internal/ingestor/netbox"
 	"github.com/criteo/data-aggregation-api/internal/ingestor/repository"
+	"github.com/criteo/data-aggregation-api/internal/lint"
 	"github.com/criteo/data-aggregation-api/internal/metrics"
 	"github.com/criteo/data-aggregation-api/internal/report"
 )
@@ -25,7 +29,40 @@ func precompute(reportCh chan report.Message, ingestorRepo *repository.Assets) (
 	var devices = make(map[string]*device.Device)
 	var allPrecomputeErrors error
 
+	conflictSeverity := report.Warning
+	if config.Cfg.Build.OnConflict == config.FailOnConflict {
+		conflictSeverity = report.Error
+	}
+
 	for _, dev := range ingestorRepo.DeviceInventory {
+		// Invalid objects are already reported by the ingestors
+		if len(devicesData.InvalidObjects[dev.Hostname]) > 0 {
+			devices[dev.Hostname] = nil
+			err := fmt.Errorf("%s references %d invalid objects excluded from ingestion", dev.Hostname, len(devicesData.InvalidObjects[dev.Hostname]))
+			reportCh <- report.Message{
+				Type:     report.PrecomputeMessage,
+				Severity: report.Error,
+				Text:     err.Error(),
+			}
+			allPrecomputeErrors = errors.Join(allPrecomputeErrors, err)
+			continue
+		}
+
+		conflicts := devicesData.Conflicts[dev.Hostname]
+		for _, conflict := range conflicts {
+			reportCh <- report.Message{
+				Type:     report.PrecomputeMessage,
+				Severity: conflictSeverity,
+				Text:     conflict.Error(),
+			}
+		}
+
+		if len(conflicts) > 0 && conflictSeverity == report.Error {
+			devices[dev.Hostname] = nil
+			allPrecomputeErrors = errors.Join(allPrecomputeErrors, fmt.Errorf("%s has conflicting CMDB objects", dev.Hostname))
+			continue
+		}
+
 		if newDevice, err := device.NewDevice(dev, devicesData); err != nil {
 			devices[dev.Hostname] = nil
 			reportCh <- report.Message{
@@ -39,9 +76,172 @@ func precompute(reportCh chan report.Message, ingestorRepo *repository.Assets) (
 		}
 	}
 
+	// Invalid inventory items are not part of the inventory, they must still be reported as failed
+	for hostname, invalid := range devicesData.InvalidObjects {
+		if _, ok := devices[hostname]; ok || !slices.ContainsFunc(invalid, isInventoryItem) {
+			continue
+		}
+		devices[hostname] = nil
+		err := fmt.Errorf("%s is excluded from the inventory", hostname)
+		reportCh <- report.Message{
+			Type:     report.PrecomputeMessage,
+			Severity: report.Error,
+			Text:     err.Error(),
+		}
+		allPrecomputeErrors = errors.Join(allPrecomputeErrors, err)
+	}
+
 	return devices, allPrecomputeErrors
 }
 
+func isInventoryItem(invalid *netbox.InvalidObject) bool {
+	return invalid.Endpoint == netbox.DevicesEndpoint
+}
+
+// Validate resolves the name references of each precomputed device.
+// Devices with unresolved references are removed from the build if configured so.
+func validate(reportCh chan<- report.Message, devices map[string]*device.Device) error {
+	severity := report.Warning
+	if config.Cfg.Build.FailOnMissingReferences {
+		severity = report.Error
+	}
+
+	var allValidationErrors error
+	for hostname, dev := range devices {
+		if dev == nil {
+			continue
+		}
+
+		referenceErrors := dev.ValidateReferences()
+		for _, err := range referenceErrors {
+			reportCh <- report.Message{
+				Type:     report.ValidationMessage,
+				Severity: severity,
+				Text:     err.Error(),
+			}
+		}
+
+		if len(referenceErrors) > 0 && severity == report.Error {
+			devices[hostname] = nil
+			allValidationErrors = errors.Join(allValidationErrors, fmt.Errorf("%s has unresolved references", hostname))
+		}
+	}
+
+	return allValidationErrors
+}
+
+// LintSessions runs the BGP session lint rules on each precomputed device.
+// Devices with error findings are removed from the build, findings are counted per rule and severity.
+func lintSessions(reportCh chan<- report.Message, devices map[string]*device.Device) (map[string]map[report.Severity]uint32, error) {
+	var allLintErrors error
+	var findingsCount = make(map[string]map[report.Severity]uint32)
+
+	for hostname, dev := range devices {
+		if dev == nil {
+			continue
+		}
+
+		failed := false
+		for _, finding := range lint.CheckSessions(hostname, dev.Sessions) {
+			reportCh <- report.Message{
+				Type:     report.ValidationMessage,
+				Severity: finding.Severity,
+				Text:     finding.String(),
+			}
+
+			if findingsCount[finding.Rule] == nil {
+				findingsCount[finding.Rule] = make(map[report.Severity]uint32)
+			}
+			findingsCount[finding.Rule][finding.Severity]++
+
+			if finding.Severity == report.Error {
+				failed = true
+			}
+		}
+
+		if failed {
+			devices[hostname] = nil
+			allLintErrors = errors.Join(allLintErrors, fmt.Errorf("%s has BGP session errors", hostname))
+		}
+	}
+
+	return findingsCount, allLintErrors
+}
+
+// LintConfigs runs the configured lint rules on the generated configuration of each device.
+// Devices with error findings are removed from the build if publishing is blocked, findings are counted per rule and severity.
+func lintConfigs(reportCh chan<- report.Message, devices map[string]*device.Device) (map[string]map[report.Severity]uint32, uint32, error) {
+	var allLintErrors error
+	var blocked uint32
+	var findingsCount = make(map[string]map[report.Severity]uint32)
+
+	for hostname, dev := range devices {
+		if dev == nil || dev.Config == nil {
+			continue
+		}
+
+		findings, err := lint.CheckConfig(hostname, dev.Config.JSONOpenConfig, dev.Config.JSONIETF)
+		if err != nil {
+			findings = append(findings, lint.Finding{Rule: "config", Severity: report.Error, Hostname: hostname, Text: err.Error()})
+		}
+
+		failed := false
+		for _, finding := range findings {
+			reportCh <- report.Message{
+				Type:     report.ValidationMessage,
+				Severity: finding.Severity,
+				Text:     finding.String(),
+			}
+
+			if findingsCount[finding.Rule] == nil {
+				findingsCount[finding.Rule] = make(map[report.Severity]uint32)
+			}
+			findingsCount[finding.Rule][finding.Severity]++
+
+			if finding.Severity == report.Error {
+				failed = true
+			}
+		}
+
+		if failed && config.Cfg.Lint.BlockPublishing {
+			devices[hostname] = nil
+			blocked++
+			allLintErrors = errors.Join(allLintErrors, fmt.Errorf("%s has configuration lint errors", hostname))
+		}
+	}
+
+	return findingsCount, blocked, allLintErrors
+}
+
+// OptimizePrefixLists shrinks the prefix-lists of each precomputed device and reports the reduction per list.
+func optimizePrefixLists(reportCh chan<- report.Message, devices map[string]*device.Device) uint32 {
+	var removed uint32
+	for hostname, dev := range devices {
+		if dev == nil {
+			continue
+		}
+
+		for _, prefixList := range dev.PrefixLists {
+			before := len(prefixList.Terms)
+			prefixList.Terms = rpconvertors.OptimizePrefixListTerms(prefixList.Terms)
+			after := len(prefixList.Terms)
+			if after == before {
+				continue
+			}
+
+			removed += uint32(before - after)
+			reportCh <- report.Message{
+				Type:     report.PrecomputeMessage,
+				Severity: report.Info,
+				Text: fmt.Sprintf("%s: prefix-list %s optimized from %d to %d entries (-%d%%)",
+					hostname, prefixList.Name, before, after, (before-after)*100/before),
+			}
+		}
+	}
+
+	return removed
+}
+
 // Compute generates OpenConfig data for each device.
 func compute(reportCh chan<- report.Message, ingestorRepo *repository.Assets, devices map[string]*device.Device) (uint32, error) {
 	wg := sync.WaitGroup{}
@@ -92,10 +292,11 @@ func compute(reportCh chan<- report.Message, ingestorRepo *repository.Assets, de
 }
 
 // RunBuild start the build pipeline to convert CMDB data to OpenConfig for each devices.
-// One build is composed are three steps:
+// One build is composed are four steps:
 //   - fetch data using ingestors (one ingestor = one data source API endpoint)
 //   - precompute data to make them usable
-//   - compute to OpenConfig
+//   - validate the references between precomputed objects and lint BGP sessions
+//   - compute to OpenConfig and lint the generated configuration
 func RunBuild(reportCh chan report.Message) (map[string]*device.Device, report.Stats, error) {
 	stats := report.Stats{}
 	startTime := time.Now()
@@ -112,6 +313,13 @@ func RunBuild(reportCh chan report.Message) (map[string]*device.Device, report.S
 
 	// Precompute data per device
 	devices, precomputeError := precompute(reportCh, ingestorRepo)
+	precomputeError = errors.Join(precomputeError, validate(reportCh, devices))
+	lintFindings, lintError := lintSessions(reportCh, devices)
+	stats.SessionLintFindings = lintFindings
+	precomputeError = errors.Join(precomputeError, lintError)
+	if config.Cfg.Build.OptimizePrefixLists {
+		stats.PrefixListEntriesRemoved = optimizePrefixLists(reportCh, devices)
+	}
 	precomputeFinishTime := time.Now()
 	stats.Performance.PrecomputeDuration = precomputeFinishTime.Sub(ingestorFetchFinishTime)
 
@@ -134,23 +342,65 @@ func RunBuild(reportCh chan report.Message) (map[string]*device.Device, report.S
 	stats.Performance.BuildDuration = computeTime.Sub(startTime)
 
 	stats.BuiltDevicesCount = successfullyBuilt
-	stats.Log()
 
 	if computeError != nil {
+		stats.Log()
 		return nil, stats, computeError
 	}
 
+	configLintFindings, blocked, configLintError := lintConfigs(reportCh, devices)
+	stats.ConfigLintFindings = configLintFindings
+	stats.BuiltDevicesCount -= blocked
+	stats.Log()
+
+	if configLintError != nil && config.Cfg.Build.AllDevicesMustBuild {
+		return nil, stats, errors.New("failed: all devices must build")
+	}
+
 	return devices, stats, nil
 }
 
+// ServingRepository lists what is served regardless of the builds: the devices pinned to a build and the overlays.
+type ServingRepository interface {
+	ListPins() map[string]string
+	ListOverlays(hostname string) []*device.Overlay
+}
+
+// reportOverlays reports the active overlays, and the ones which cannot be merged into the served configuration.
+func reportOverlays(reportCh chan<- report.Message, overlays []*device.Overlay) {
+	for _, overlay := range overlays {
+		reportCh <- report.Message{
+			Type:     report.PublishMessage,
+			Severity: report.Warning,
+			Text: fmt.Sprintf("overlay %s (%s) by %s active on %s until %s: %s",
+				overlay.ID, overlay.Model, overlay.Author, overlay.Hostname, overlay.ExpiresAt.Format(time.RFC3339), overlay.Reason),
+		}
+		if overlay.Error != "" {
+			reportCh <- report.Message{
+				Type:     report.PublishMessage,
+				Severity: report.Error,
+				Text:     fmt.Sprintf("overlay %s not merged into %s: %s", overlay.ID, overlay.Hostname, overlay.Error),
+			}
+		}
+	}
+}
+
+// newBuildID identifies a build by its start time.
+func newBuildID(startTime time.Time) string {
+	return startTime.UTC().Format("20060102T150405Z")
+}
+
 // StartBuildLoop starts the build in an infinite loop.
+// Successful builds are published through the gate, which holds them if their blast radius is too large.
+// The pinned devices keep being served their pinned build, the pins and the active overlays are listed in each report.
 //
 // Closing the triggerNewBuild channel will stop the loop.
-func StartBuildLoop(deviceRepo router.DevicesRepository, reports *report.Repository, triggerNewBuild <-chan struct{}) {
+func StartBuildLoop(gate *guard.Gate, serving ServingRepository, reports *report.Repository, triggerNewBuild <-chan struct{}) {
 	metricsRegistry := metrics.NewRegistry()
 	for {
 		var wg sync.WaitGroup
-		reports.StartNewReport()
+		buildID := newBuildID(time.Now())
+		reports.StartNewReport(buildID)
 		var reportCh = make(chan report.Message, 1)
 
 		wg.Add(1)
@@ -169,9 +419,20 @@ func StartBuildLoop(deviceRepo router.DevicesRepository, reports *report.Reposit
 			reports.UpdateStats(stats)
 
 			log.Error().Err(err).Msg("build failed")
-		} else {
-			deviceRepo.Set(devs)
+		} else if held := gate.Submit(buildID, devs); held != nil {
+			for _, reason := range held.Reasons {
+				reportCh <- report.Message{
+					Type:     report.PublishMessage,
+					Severity: report.Error,
+					Text:     fmt.Sprintf("build held for approval: %s", reason),
+				}
+			}
 
+			reports.UpdateStatus(report.Held)
+			reports.UpdateStats(stats)
+
+			log.Warn().Str("build_id", buildID).Msg("build held, its blast radius needs an approval")
+		} else {
 			metricsRegistry.BuildSuccessful()
 			metricsRegistry.SetBuiltDevices(stats.BuiltDevicesCount)
 
@@ -186,6 +447,11 @@ func StartBuildLoop(deviceRepo router.DevicesRepository, reports *report.Reposit
 		metricsRegistry.SetBuildPrecomputeDuration(stats.Performance.PrecomputeDuration.Seconds())
 		metricsRegistry.SetBuildComputeDuration(stats.Performance.ComputeDuration.Seconds())
 		metricsRegistry.SetBuildTotalDuration(stats.Performance.BuildDuration.Seconds())
+		metricsRegistry.SetSessionLintFindings(stats.SessionLintFindings)
+		metricsRegistry.SetConfigLintFindings(stats.ConfigLintFindings)
+
+		reportOverlays(reportCh, serving.ListOverlays(device.Wildcard))
+		reports.UpdatePins(serving.ListPins())
 
 		reports.MarkAsComplete()
 		close(reportCh)
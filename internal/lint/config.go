@@ -0,0 +1,146 @@
+package lint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/criteo/data-aggregation-api/internal/config"
+	"github.com/criteo/data-aggregation-api/internal/report"
+)
+
+// ConfigRule is a declarative rule checked on the generated configuration of a device.
+// The assertion is evaluated on every node matching the path, optionally restricted to the nodes matching the condition.
+type ConfigRule struct {
+	name      string
+	severity  report.Severity
+	path      *path
+	condition expression
+	assertion expression
+	message   string
+}
+
+var configRules []*ConfigRule
+
+// NewConfigRule compiles a rule from the configuration.
+func NewConfigRule(definition config.LintRule) (*ConfigRule, error) {
+	if definition.Name == "" {
+		return nil, errors.New("lint rule without name")
+	}
+	if definition.Assert == "" {
+		return nil, fmt.Errorf("lint rule %s: assert is required", definition.Name)
+	}
+
+	rule := &ConfigRule{
+		name:     definition.Name,
+		severity: report.Severity(definition.Severity),
+		message:  definition.Message,
+	}
+	switch rule.severity {
+	case "":
+		rule.severity = report.Error
+	case report.Warning, report.Error:
+	default:
+		return nil, fmt.Errorf("lint rule %s: invalid severity %s, expected %s or %s", definition.Name, definition.Severity, report.Warning, report.Error)
+	}
+
+	var err error
+	if rule.path, err = parsePath("/" + strings.TrimPrefix(definition.Path, "/")); err != nil {
+		return nil, fmt.Errorf("lint rule %s: %w", definition.Name, err)
+	}
+	if definition.When != "" {
+		if rule.condition, err = compile(definition.When); err != nil {
+			return nil, fmt.Errorf("lint rule %s: invalid when expression: %w", definition.Name, err)
+		}
+	}
+	if rule.assertion, err = compile(definition.Assert); err != nil {
+		return nil, fmt.Errorf("lint rule %s: invalid assert expression: %w", definition.Name, err)
+	}
+	if rule.message == "" {
+		rule.message = "assertion failed: " + definition.Assert
+	}
+
+	return rule, nil
+}
+
+// LoadConfigRules compiles the rules of the configuration, replacing the previously loaded ones.
+// It must be called before starting the build loop.
+func LoadConfigRules(definitions []config.LintRule) error {
+	rules := make([]*ConfigRule, 0, len(definitions))
+	for _, definition := range definitions {
+		rule, err := NewConfigRule(definition)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, rule)
+	}
+
+	configRules = rules
+	return nil
+}
+
+func (r *ConfigRule) Name() string { return r.name }
+
+// check evaluates the rule on the configuration tree of a device.
+// A rule failing to evaluate on a node is reported as an error.
+func (r *ConfigRule) check(hostname string, root *node) []Finding {
+	var findings []Finding
+	for _, n := range r.path.resolve(root) {
+		if r.condition != nil {
+			matched, err := evalBool(r.condition, n)
+			if err != nil {
+				findings = append(findings, newFinding(r, report.Error, hostname, "%s: when expression failed: %s", location(n), err))
+				continue
+			}
+			if !matched {
+				continue
+			}
+		}
+
+		valid, err := evalBool(r.assertion, n)
+		switch {
+		case err != nil:
+			findings = append(findings, newFinding(r, report.Error, hostname, "%s: assert expression failed: %s", location(n), err))
+		case !valid:
+			findings = append(findings, newFinding(r, r.severity, hostname, "%s: %s", location(n), r.message))
+		}
+	}
+	return findings
+}
+
+func location(n *node) string {
+	if n.location == "" {
+		return "/"
+	}
+	return n.location
+}
+
+// CheckConfig runs every loaded rule on the RFC7951 JSON configurations of a device.
+// The configurations (e.g. OpenConfig and IETF) are merged in a single tree, module prefixes are ignored in paths.
+func CheckConfig(hostname string, configsJSON ...string) ([]Finding, error) {
+	if len(configRules) == 0 {
+		return nil, nil
+	}
+
+	tree := make(map[string]any)
+	for _, configJSON := range configsJSON {
+		decoder := json.NewDecoder(strings.NewReader(configJSON))
+		decoder.UseNumber()
+
+		var members map[string]any
+		if err := decoder.Decode(&members); err != nil {
+			return nil, fmt.Errorf("failed to lint %s: %w", hostname, err)
+		}
+		for member, value := range members {
+			tree[member] = value
+		}
+	}
+
+	root := &node{value: tree}
+	var findings []Finding
+	for _, rule := range configRules {
+		findings = append(findings, rule.check(hostname, root)...)
+	}
+	return findings, nil
+}
@@ -0,0 +1,234 @@
+package lint_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/criteo/data-aggregation-api/internal/config"
+	"github.com/criteo/data-aggregation-api/internal/lint"
+	"github.com/criteo/data-aggregation-api/internal/report"
+)
+
+const openconfigJSON = `
+{
+	"openconfig-network-instance:network-instances": {
+		"network-instance": [
+			{
+				"name": "default",
+				"config": {"name": "default"},
+				"protocols": {
+					"protocol": [
+						{
+							"identifier": "openconfig-policy-types:BGP",
+							"name": "bgp",
+							"config": {"identifier": "openconfig-policy-types:BGP", "name": "bgp"},
+							"bgp": {
+								"global": {"config": {"as": 65000}},
+								"neighbors": {
+									"neighbor": [
+										{
+											"neighbor-address": "192.0.2.1",
+											"config": {"neighbor-address": "192.0.2.1", "peer-as": 65001},
+											"apply-policy": {"config": {"import-policy": ["SPINE:IN"], "export-policy": ["SPINE:OUT"]}}
+										},
+										{
+											"neighbor-address": "192.0.2.3",
+											"config": {"neighbor-address": "192.0.2.3", "peer-as": 65002},
+											"apply-policy": {"config": {"import-policy": ["SPINE:IN"]}}
+										},
+										{
+											"neighbor-address": "192.0.2.5",
+											"config": {"neighbor-address": "192.0.2.5", "peer-as": 65000}
+										}
+									]
+								}
+							}
+						}
+					]
+				}
+			}
+		]
+	},
+	"openconfig-routing-policy:routing-policy": {
+		"policy-definitions": {
+			"policy-definition": [
+				{
+					"name": "SPINE:IN",
+					"config": {"name": "SPINE:IN"},
+					"statements": {
+						"statement": [
+							{"name": "10", "config": {"name": "10"}, "actions": {"config": {"policy-result": "ACCEPT_ROUTE"}}},
+							{"name": "20", "config": {"name": "20"}}
+						]
+					}
+				},
+				{
+					"name": "NEXT-HOP-SELF",
+					"config": {"name": "NEXT-HOP-SELF"},
+					"statements": {
+						"statement": [
+							{"name": "1", "config": {"name": "1"}, "actions": {"bgp-actions": {"config": {"set-next-hop": "SELF"}}}}
+						]
+					}
+				},
+				{
+					"name": "SPINE:OUT",
+					"config": {"name": "SPINE:OUT"},
+					"statements": {
+						"statement": [
+							{"name": "10", "config": {"name": "10"}, "actions": {"config": {"policy-result": "REJECT_ROUTE"}}}
+						]
+					}
+				}
+			]
+		}
+	}
+}`
+
+const ietfJSON = `
+{
+	"ietf-snmp:snmp": {
+		"community": [
+			{"index": "ro", "security-name": "readonly", "text-name": "public"}
+		]
+	}
+}`
+
+const neighborPath = "/network-instances/network-instance/protocols/protocol/bgp/neighbors/neighbor"
+const ebgpCondition = "exists(config/peer-as) && exists(../../global/config/as) && config/peer-as != ../../global/config/as"
+
+func TestCheckConfig(t *testing.T) {
+	rules := []config.LintRule{
+		{
+			Name:    "ebgp-policies",
+			Path:    neighborPath,
+			When:    ebgpCondition,
+			Assert:  "exists(apply-policy/config/import-policy) && exists(apply-policy/config/export-policy)",
+			Message: "eBGP neighbors must have import and export policies",
+		},
+		{
+			Name:     "explicit-policy-result",
+			Path:     "/routing-policy/policy-definitions/policy-definition",
+			When:     "name != 'NEXT-HOP-SELF'",
+			Assert:   "exists(statements/statement[-1]/actions/config/policy-result)",
+			Severity: string(report.Warning),
+		},
+		{
+			Name:   "snmp-public-community",
+			Path:   "snmp/community",
+			Assert: "text-name != 'public' && !matches(security-name, '^admin')",
+		},
+		{
+			Name:   "neighbors-count",
+			Assert: "size(" + neighborPath + ") <= 2 || 'SPINE:OUT' in /routing-policy/policy-definitions/policy-definition/name",
+		},
+	}
+	if err := lint.LoadConfigRules(rules); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	t.Cleanup(func() { _ = lint.LoadConfigRules(nil) })
+
+	bgpPath := "/network-instances/network-instance[name=default]/protocols/protocol[identifier=openconfig-policy-types:BGP][name=bgp]/bgp"
+	want := []lint.Finding{
+		{
+			Rule:     "ebgp-policies",
+			Severity: report.Error,
+			Hostname: "tor01-01",
+			Text:     bgpPath + "/neighbors/neighbor[neighbor-address=192.0.2.3]: eBGP neighbors must have import and export policies",
+		},
+		{
+			Rule:     "explicit-policy-result",
+			Severity: report.Warning,
+			Hostname: "tor01-01",
+			Text:     "/routing-policy/policy-definitions/policy-definition[name=SPINE:IN]: assertion failed: exists(statements/statement[-1]/actions/config/policy-result)",
+		},
+		{
+			Rule:     "snmp-public-community",
+			Severity: report.Error,
+			Hostname: "tor01-01",
+			Text:     "/snmp/community[index=ro]: assertion failed: text-name != 'public' && !matches(security-name, '^admin')",
+		},
+	}
+
+	out, err := lint.CheckConfig("tor01-01", openconfigJSON, ietfJSON)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff := cmp.Diff(out, want); diff != "" {
+		t.Errorf("unexpected diff: %s\n", diff)
+	}
+}
+
+func TestNewConfigRuleErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rule config.LintRule
+	}{
+		{name: "missing name", rule: config.LintRule{Assert: "true"}},
+		{name: "missing assert", rule: config.LintRule{Name: "rule"}},
+		{name: "invalid severity", rule: config.LintRule{Name: "rule", Assert: "true", Severity: "critical"}},
+		{name: "unterminated string", rule: config.LintRule{Name: "rule", Assert: "name == 'public"}},
+		{name: "unknown function", rule: config.LintRule{Name: "rule", Assert: "length(name) > 0"}},
+		{name: "invalid pattern", rule: config.LintRule{Name: "rule", Assert: "matches(name, '(')"}},
+		{name: "invalid index", rule: config.LintRule{Name: "rule", Assert: "exists(statement[last])"}},
+		{name: "trailing tokens", rule: config.LintRule{Name: "rule", Assert: "name == 'a' 'b'"}},
+		{name: "missing parenthesis", rule: config.LintRule{Name: "rule", When: "(true", Assert: "true"}},
+	}
+
+	for _, test := range tests {
+		if _, err := lint.NewConfigRule(test.rule); err == nil {
+			t.Errorf("expected an error for '%s'", test.name)
+		}
+	}
+}
+
+func TestCheckConfigWithoutBGPGlobal(t *testing.T) {
+	rules := []config.LintRule{
+		{
+			Name:   "ebgp-policies",
+			Path:   neighborPath,
+			When:   ebgpCondition,
+			Assert: "exists(apply-policy/config/import-policy)",
+		},
+	}
+	if err := lint.LoadConfigRules(rules); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	t.Cleanup(func() { _ = lint.LoadConfigRules(nil) })
+
+	// without global ASN, the neighbor cannot be told to be eBGP
+	configJSON := `
+	{
+		"openconfig-network-instance:network-instances": {
+			"network-instance": [
+				{
+					"name": "default",
+					"protocols": {
+						"protocol": [
+							{
+								"identifier": "openconfig-policy-types:BGP",
+								"name": "bgp",
+								"bgp": {
+									"neighbors": {
+										"neighbor": [
+											{"neighbor-address": "192.0.2.1", "config": {"neighbor-address": "192.0.2.1", "peer-as": 65001}}
+										]
+									}
+								}
+							}
+						]
+					}
+				}
+			]
+		}
+	}`
+
+	out, err := lint.CheckConfig("tor01-01", configJSON)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(out) > 0 {
+		t.Errorf("unexpected findings: %v", out)
+	}
+}
@@ -0,0 +1,656 @@
+package lint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The config rules use a small CEL-like expression language over RFC7951 JSON trees:
+//   - paths are YANG data paths without module prefixes, relative to the evaluated node ("config/peer-as"),
+//     absolute ("/routing-policy/policy-definitions"), going up with ".." or selecting a list entry with "[0]" or "[-1]"
+//   - literals are strings ("..." or '...', without escape sequences), numbers, true, false and null
+//   - operators are ==, !=, <, <=, >, >=, in, !, && and || with the usual precedence, and parentheses
+//   - functions are exists(path), size(path) and matches(path, 'regexp')
+//
+// A path evaluates to every value it matches, null being no value.
+// Comparisons are true if they hold for at least one pair of values, != being the negation of ==.
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPath
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenLeftParen
+	tokenRightParen
+	tokenComma
+)
+
+type token struct {
+	kind     tokenKind
+	text     string
+	position int
+}
+
+var twoCharsOperators = []string{"&&", "||", "==", "!=", "<=", ">="}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isPathStart(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' || c == '.' || c == '/'
+}
+
+func isPathChar(c byte) bool {
+	return isPathStart(c) || isDigit(c) || c == '-' || c == ':'
+}
+
+func tokenize(expression string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expression); {
+		c := expression[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokenLeftParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokenRightParen, ")", i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokenComma, ",", i})
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(expression[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			tokens = append(tokens, token{tokenString, expression[i+1 : i+1+end], i})
+			i += end + 2
+		case i+1 < len(expression) && slices.Contains(twoCharsOperators, expression[i:i+2]):
+			tokens = append(tokens, token{tokenOperator, expression[i : i+2], i})
+			i += 2
+		case c == '!' || c == '<' || c == '>':
+			tokens = append(tokens, token{tokenOperator, string(c), i})
+			i++
+		case isDigit(c) || (c == '-' && i+1 < len(expression) && isDigit(expression[i+1])):
+			j := i + 1
+			for j < len(expression) && (isDigit(expression[j]) || expression[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{tokenNumber, expression[i:j], i})
+			i = j
+		case isPathStart(c):
+			j := scanPath(expression, i)
+			kind := tokenPath
+			if expression[i:j] == "in" {
+				kind = tokenOperator
+			}
+			tokens = append(tokens, token{kind, expression[i:j], i})
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character '%c' at position %d", c, i)
+		}
+	}
+
+	return append(tokens, token{tokenEOF, "", len(expression)}), nil
+}
+
+// scanPath returns the end of the path starting at i, list indexes included.
+func scanPath(expression string, i int) int {
+	depth := 0
+	for ; i < len(expression); i++ {
+		switch c := expression[i]; {
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case depth == 0 && !isPathChar(c):
+			return i
+		}
+	}
+	return i
+}
+
+// node is a value of the evaluated tree with its parent and location, list entries having the list container as parent.
+type node struct {
+	value    any
+	parent   *node
+	location string
+}
+
+// stripModule removes the RFC7951 module prefix of a member name ("openconfig-bgp:bgp" to "bgp").
+func stripModule(name string) string {
+	if _, after, found := strings.Cut(name, ":"); found {
+		return after
+	}
+	return name
+}
+
+// entryLocation formats a list entry with its keys: the scalar members next to an OpenConfig "config" container,
+// else its name or index member, else its position.
+func entryLocation(entry any, position int) string {
+	members, ok := entry.(map[string]any)
+	if !ok {
+		return "[" + strconv.Itoa(position) + "]"
+	}
+
+	var keys []string
+	_, isOpenConfig := members["config"]
+	for member, value := range members {
+		name := stripModule(member)
+		if _, isObject := value.(map[string]any); isObject {
+			continue
+		}
+		if _, isList := value.([]any); isList {
+			continue
+		}
+		if isOpenConfig || name == "name" || name == "index" {
+			keys = append(keys, fmt.Sprintf("[%s=%v]", name, value))
+		}
+	}
+	if len(keys) == 0 {
+		return "[" + strconv.Itoa(position) + "]"
+	}
+	slices.Sort(keys)
+	return strings.Join(keys, "")
+}
+
+func (n *node) children(name string) []*node {
+	members, ok := n.value.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	var children []*node
+	for member, value := range members {
+		if stripModule(member) != name {
+			continue
+		}
+		if items, isList := value.([]any); isList {
+			for i, item := range items {
+				children = append(children, &node{value: item, parent: n, location: n.location + "/" + name + entryLocation(item, i)})
+			}
+		} else {
+			children = append(children, &node{value: value, parent: n, location: n.location + "/" + name})
+		}
+	}
+	return children
+}
+
+type segment struct {
+	name  string
+	index *int
+}
+
+type path struct {
+	absolute bool
+	segments []segment
+}
+
+func parsePath(text string) (*path, error) {
+	p := &path{absolute: strings.HasPrefix(text, "/")}
+	text = strings.Trim(text, "/")
+	if text == "" {
+		return p, nil
+	}
+
+	for _, part := range strings.Split(text, "/") {
+		name, index, hasIndex := strings.Cut(part, "[")
+		if name == "" {
+			return nil, fmt.Errorf("invalid path %s: empty segment", text)
+		}
+		if name == "." {
+			continue
+		}
+
+		seg := segment{name: name}
+		if hasIndex {
+			position, err := strconv.Atoi(strings.TrimSuffix(index, "]"))
+			if err != nil || !strings.HasSuffix(index, "]") {
+				return nil, fmt.Errorf("invalid path %s: invalid index [%s", text, index)
+			}
+			seg.index = &position
+		}
+		p.segments = append(p.segments, seg)
+	}
+	return p, nil
+}
+
+// resolve returns the nodes matching the path from the current node.
+func (p *path) resolve(current *node) []*node {
+	if p.absolute {
+		for current.parent != nil {
+			current = current.parent
+		}
+	}
+
+	nodes := []*node{current}
+	for _, seg := range p.segments {
+		var next []*node
+		seen := make(map[*node]bool)
+		for _, n := range nodes {
+			if seg.name == ".." {
+				if n.parent != nil && !seen[n.parent] {
+					seen[n.parent] = true
+					next = append(next, n.parent)
+				}
+				continue
+			}
+
+			children := n.children(seg.name)
+			if seg.index == nil {
+				next = append(next, children...)
+				continue
+			}
+			position := *seg.index
+			if position < 0 {
+				position += len(children)
+			}
+			if position >= 0 && position < len(children) {
+				next = append(next, children[position])
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+type expression interface {
+	eval(current *node) ([]any, error)
+}
+
+type literalExpression struct {
+	values []any
+}
+
+func (e literalExpression) eval(_ *node) ([]any, error) {
+	return e.values, nil
+}
+
+type pathExpression struct {
+	path *path
+}
+
+func (e pathExpression) eval(current *node) ([]any, error) {
+	nodes := e.path.resolve(current)
+	values := make([]any, 0, len(nodes))
+	for _, n := range nodes {
+		values = append(values, n.value)
+	}
+	return values, nil
+}
+
+type notExpression struct {
+	operand expression
+}
+
+func (e notExpression) eval(current *node) ([]any, error) {
+	value, err := evalBool(e.operand, current)
+	if err != nil {
+		return nil, err
+	}
+	return []any{!value}, nil
+}
+
+type logicalExpression struct {
+	operator    string
+	left, right expression
+}
+
+func (e logicalExpression) eval(current *node) ([]any, error) {
+	left, err := evalBool(e.left, current)
+	if err != nil {
+		return nil, err
+	}
+	// Short-circuit evaluation
+	if (e.operator == "&&" && !left) || (e.operator == "||" && left) {
+		return []any{left}, nil
+	}
+
+	right, err := evalBool(e.right, current)
+	if err != nil {
+		return nil, err
+	}
+	return []any{right}, nil
+}
+
+type comparisonExpression struct {
+	operator    string
+	left, right expression
+}
+
+func (e comparisonExpression) eval(current *node) ([]any, error) {
+	left, err := e.left.eval(current)
+	if err != nil {
+		return nil, err
+	}
+	right, err := e.right.eval(current)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.operator {
+	case "==":
+		return []any{anyEqual(left, right)}, nil
+	case "!=":
+		return []any{!anyEqual(left, right)}, nil
+	case "in":
+		return []any{len(left) > 0 && anyEqual(left, right)}, nil
+	}
+
+	for _, l := range left {
+		for _, r := range right {
+			order, err := compareValues(l, r)
+			if err != nil {
+				return nil, err
+			}
+			if (e.operator == "<" && order < 0) || (e.operator == "<=" && order <= 0) ||
+				(e.operator == ">" && order > 0) || (e.operator == ">=" && order >= 0) {
+				return []any{true}, nil
+			}
+		}
+	}
+	return []any{false}, nil
+}
+
+type callExpression struct {
+	function string
+	argument expression
+	pattern  *regexp.Regexp
+}
+
+func (e callExpression) eval(current *node) ([]any, error) {
+	values, err := e.argument.eval(current)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.function {
+	case "exists":
+		return []any{len(values) > 0}, nil
+	case "size":
+		return []any{float64(len(values))}, nil
+	default: // matches
+		for _, value := range values {
+			if text, ok := value.(string); ok && e.pattern.MatchString(text) {
+				return []any{true}, nil
+			}
+		}
+		return []any{false}, nil
+	}
+}
+
+func toNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case json.Number:
+		number, err := v.Float64()
+		return number, err == nil
+	}
+	return 0, false
+}
+
+func equalValues(a any, b any) bool {
+	if x, ok := toNumber(a); ok {
+		y, ok := toNumber(b)
+		return ok && x == y
+	}
+
+	switch x := a.(type) {
+	case string:
+		y, ok := b.(string)
+		return ok && x == y
+	case bool:
+		y, ok := b.(bool)
+		return ok && x == y
+	}
+	return false
+}
+
+// anyEqual tells if one value of left equals one value of right, two nulls being equal.
+func anyEqual(left []any, right []any) bool {
+	if len(left) == 0 || len(right) == 0 {
+		return len(left) == len(right)
+	}
+	for _, l := range left {
+		for _, r := range right {
+			if equalValues(l, r) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func compareValues(a any, b any) (int, error) {
+	if x, ok := toNumber(a); ok {
+		if y, ok := toNumber(b); ok {
+			switch {
+			case x < y:
+				return -1, nil
+			case x > y:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	if x, ok := a.(string); ok {
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y), nil
+		}
+	}
+	return 0, fmt.Errorf("cannot compare %v and %v", a, b)
+}
+
+// evalBool evaluates an expression which must be true, false or null (false).
+func evalBool(e expression, current *node) (bool, error) {
+	values, err := e.eval(current)
+	if err != nil {
+		return false, err
+	}
+	if len(values) == 0 {
+		return false, nil
+	}
+
+	for _, value := range values {
+		b, ok := value.(bool)
+		if !ok {
+			return false, fmt.Errorf("%v is not a boolean", value)
+		}
+		if !b {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+type parser struct {
+	tokens   []token
+	position int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.position]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.position]
+	if t.kind != tokenEOF {
+		p.position++
+	}
+	return t
+}
+
+func (p *parser) expect(kind tokenKind, text string) error {
+	if t := p.next(); t.kind != kind {
+		return fmt.Errorf("expected '%s' at position %d", text, t.position)
+	}
+	return nil
+}
+
+func (p *parser) isOperator(operators ...string) bool {
+	t := p.peek()
+	return t.kind == tokenOperator && slices.Contains(operators, t.text)
+}
+
+func (p *parser) parseOr() (expression, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpression{operator: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (expression, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("&&") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpression{operator: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (expression, error) {
+	if p.isOperator("!") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpression{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (expression, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOperator("==", "!=", "<", "<=", ">", ">=", "in") {
+		return left, nil
+	}
+
+	operator := p.next().text
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	return comparisonExpression{operator: operator, left: left, right: right}, nil
+}
+
+func (p *parser) parsePrimary() (expression, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenLeftParen:
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return e, p.expect(tokenRightParen, ")")
+	case tokenString:
+		return literalExpression{values: []any{t.text}}, nil
+	case tokenNumber:
+		number, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s at position %d", t.text, t.position)
+		}
+		return literalExpression{values: []any{number}}, nil
+	case tokenPath:
+		switch t.text {
+		case "true", "false":
+			return literalExpression{values: []any{t.text == "true"}}, nil
+		case "null":
+			return literalExpression{values: []any{}}, nil
+		}
+		if p.peek().kind == tokenLeftParen {
+			return p.parseCall(t)
+		}
+		parsed, err := parsePath(t.text)
+		if err != nil {
+			return nil, err
+		}
+		return pathExpression{path: parsed}, nil
+	case tokenEOF:
+		return nil, errors.New("unexpected end of expression")
+	default:
+		return nil, fmt.Errorf("unexpected '%s' at position %d", t.text, t.position)
+	}
+}
+
+func (p *parser) parseCall(function token) (expression, error) {
+	p.next()
+
+	call := callExpression{function: function.text}
+	switch function.text {
+	case "exists", "size", "matches":
+	default:
+		return nil, fmt.Errorf("unknown function %s at position %d", function.text, function.position)
+	}
+
+	argument, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	call.argument = argument
+
+	if function.text == "matches" {
+		if err := p.expect(tokenComma, ","); err != nil {
+			return nil, err
+		}
+		pattern := p.next()
+		if pattern.kind != tokenString {
+			return nil, fmt.Errorf("matches expects a string pattern at position %d", pattern.position)
+		}
+		if call.pattern, err = regexp.Compile(pattern.text); err != nil {
+			return nil, fmt.Errorf("invalid pattern at position %d: %w", pattern.position, err)
+		}
+	}
+
+	return call, p.expect(tokenRightParen, ")")
+}
+
+// compile parses an expression of the rules language.
+func compile(text string) (expression, error) {
+	tokens, err := tokenize(text)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected '%s' at position %d", t.text, t.position)
+	}
+	return e, nil
+}
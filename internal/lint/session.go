@@ -0,0 +1,185 @@
+package lint
+
+import (
+	"fmt"
+	"net"
+	"slices"
+
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/bgp"
+	"github.com/criteo/data-aggregation-api/internal/report"
+)
+
+// Finding is a misconfiguration detected by a lint rule on a device.
+type Finding struct {
+	Rule     string
+	Severity report.Severity
+	Hostname string
+	Text     string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: [%s] %s", f.Hostname, f.Rule, f.Text)
+}
+
+// SessionRule checks the BGP sessions of a device.
+// Each session carries both sides, so rules can compare the local and remote configurations.
+type SessionRule interface {
+	Name() string
+	Check(hostname string, sessions []*bgp.Session) []Finding
+}
+
+var sessionRules = []SessionRule{
+	afiSafiMismatchRule{},
+	subnetMismatchRule{},
+	duplicateNeighborRule{},
+	ebgpIdenticalASNRule{},
+}
+
+// RegisterSessionRule adds a rule to the session lint pass.
+// It must be called before starting the build loop.
+func RegisterSessionRule(rule SessionRule) {
+	sessionRules = append(sessionRules, rule)
+}
+
+// CheckSessions runs every registered rule on the BGP sessions of a device.
+func CheckSessions(hostname string, sessions []*bgp.Session) []Finding {
+	var findings []Finding
+	for _, rule := range sessionRules {
+		findings = append(findings, rule.Check(hostname, sessions)...)
+	}
+	return findings
+}
+
+func newFinding(rule interface{ Name() string }, severity report.Severity, hostname string, format string, args ...any) Finding {
+	return Finding{
+		Rule:     rule.Name(),
+		Severity: severity,
+		Hostname: hostname,
+		Text:     fmt.Sprintf(format, args...),
+	}
+}
+
+// getSides returns the local and remote sides of a session from the device point of view.
+func getSides(hostname string, session *bgp.Session) (*bgp.DeviceSession, *bgp.DeviceSession) {
+	if session.PeerA.Device.Name == hostname {
+		return &session.PeerA, &session.PeerB
+	}
+	return &session.PeerB, &session.PeerA
+}
+
+func isHostPrefix(address bgp.Address) bool {
+	if address.Address.IP.To4() != nil {
+		return address.Address.Netmask == 8*net.IPv4len
+	}
+	return address.Address.Netmask == 8*net.IPv6len
+}
+
+// afiSafiMismatchRule reports sessions where both sides do not negotiate the same address families.
+type afiSafiMismatchRule struct{}
+
+func (afiSafiMismatchRule) Name() string { return "afi-safi-mismatch" }
+
+func (r afiSafiMismatchRule) Check(hostname string, sessions []*bgp.Session) []Finding {
+	var findings []Finding
+	for _, session := range sessions {
+		local, remote := getSides(hostname, session)
+
+		localSafis := make([]string, 0, len(local.AfiSafis))
+		for _, safi := range local.AfiSafis {
+			localSafis = append(localSafis, string(safi.Name))
+		}
+		remoteSafis := make([]string, 0, len(remote.AfiSafis))
+		for _, safi := range remote.AfiSafis {
+			remoteSafis = append(remoteSafis, string(safi.Name))
+		}
+		slices.Sort(localSafis)
+		slices.Sort(remoteSafis)
+
+		if !slices.Equal(localSafis, remoteSafis) {
+			findings = append(findings, newFinding(r, report.Warning, hostname,
+				"session to %s: local address families %v do not match remote ones %v", remote.Device.Name, localSafis, remoteSafis))
+		}
+	}
+	return findings
+}
+
+// subnetMismatchRule reports directly connected sessions where the remote address is outside the local subnet.
+// Sessions between host prefixes (e.g. loopbacks) and unnumbered sessions are ignored.
+type subnetMismatchRule struct{}
+
+func (subnetMismatchRule) Name() string { return "subnet-mismatch" }
+
+func (r subnetMismatchRule) Check(hostname string, sessions []*bgp.Session) []Finding {
+	var findings []Finding
+	for _, session := range sessions {
+		local, remote := getSides(hostname, session)
+		if local.LocalAddress.Address.IP == nil || remote.LocalAddress.Address.IP == nil {
+			continue
+		}
+		if isHostPrefix(local.LocalAddress) || isHostPrefix(remote.LocalAddress) {
+			continue
+		}
+
+		localAddress := local.LocalAddress.Address
+		bits := 8 * net.IPv6len
+		if localAddress.IP.To4() != nil {
+			bits = 8 * net.IPv4len
+		}
+		mask := net.CIDRMask(localAddress.Netmask, bits)
+		subnet := net.IPNet{IP: localAddress.IP.Mask(mask), Mask: mask}
+
+		if !subnet.Contains(remote.LocalAddress.Address.IP) {
+			findings = append(findings, newFinding(r, report.Warning, hostname,
+				"session to %s: remote address %s is not in local subnet %s", remote.Device.Name, remote.LocalAddress.Address.IP, subnet.String()))
+		}
+	}
+	return findings
+}
+
+// duplicateNeighborRule reports neighbors defined more than once on a device, only one of them would be configured.
+type duplicateNeighborRule struct{}
+
+func (duplicateNeighborRule) Name() string { return "duplicate-neighbor" }
+
+func (r duplicateNeighborRule) Check(hostname string, sessions []*bgp.Session) []Finding {
+	var findings []Finding
+	var neighbors = make(map[string]string)
+	for _, session := range sessions {
+		local, remote := getSides(hostname, session)
+
+		// sessions without a valid neighbor are reported by the build
+		neighbor, err := bgp.NeighborKey(local, remote)
+		if err != nil {
+			continue
+		}
+
+		if previous, ok := neighbors[neighbor]; ok {
+			findings = append(findings, newFinding(r, report.Error, hostname,
+				"neighbor %s is used by sessions to %s and %s", neighbor, previous, remote.Device.Name))
+			continue
+		}
+		neighbors[neighbor] = remote.Device.Name
+	}
+	return findings
+}
+
+// ebgpIdenticalASNRule reports sessions configured with eBGP-only options while both sides share the same ASN.
+type ebgpIdenticalASNRule struct{}
+
+func (ebgpIdenticalASNRule) Name() string { return "ebgp-identical-asn" }
+
+func (r ebgpIdenticalASNRule) Check(hostname string, sessions []*bgp.Session) []Finding {
+	var findings []Finding
+	for _, session := range sessions {
+		local, remote := getSides(hostname, session)
+		if local.LocalAsn.Number == nil || remote.LocalAsn.Number == nil || *local.LocalAsn.Number != *remote.LocalAsn.Number {
+			continue
+		}
+
+		if local.EBGPMultihopTTL > 0 || remote.EBGPMultihopTTL > 0 {
+			findings = append(findings, newFinding(r, report.Error, hostname,
+				"session to %s: eBGP multihop is set but both sides use ASN %d", remote.Device.Name, *local.LocalAsn.Number))
+		}
+	}
+	return findings
+}
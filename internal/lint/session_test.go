@@ -0,0 +1,93 @@
+package lint_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/criteo/data-aggregation-api/internal/lint"
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/bgp"
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/common"
+	"github.com/criteo/data-aggregation-api/internal/report"
+	"github.com/criteo/data-aggregation-api/internal/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCheckSessions(t *testing.T) {
+	var as65000 uint32 = 65000
+	var as65001 uint32 = 65001
+
+	newSide := func(device string, address string, netmask int, asn *uint32, safis ...bgp.AfiSafiChoice) bgp.DeviceSession {
+		side := bgp.DeviceSession{
+			LocalAddress: bgp.Address{Address: types.CIDR{IP: net.ParseIP(address), Netmask: netmask}},
+			LocalAsn:     common.ASN{Number: asn},
+		}
+		side.Device.Name = device
+		for _, safi := range safis {
+			side.AfiSafis = append(side.AfiSafis, &bgp.AfiSafi{Name: safi})
+		}
+		return side
+	}
+
+	valid := &bgp.Session{
+		PeerA: newSide("spine01-01", "192.0.2.0", 31, &as65001, bgp.IPv4Unicast),
+		PeerB: newSide("tor01-01", "192.0.2.1", 31, &as65000, bgp.IPv4Unicast),
+	}
+	afiMismatch := &bgp.Session{
+		PeerA: newSide("spine01-01", "192.0.2.2", 31, &as65001, bgp.IPv4Unicast, bgp.IPv6Unicast),
+		PeerB: newSide("tor01-02", "192.0.2.3", 31, &as65000, bgp.IPv4Unicast),
+	}
+	subnetMismatch := &bgp.Session{
+		PeerA: newSide("spine01-01", "192.0.2.4", 31, &as65001),
+		PeerB: newSide("tor01-03", "192.0.2.7", 31, &as65000),
+	}
+	duplicate := &bgp.Session{
+		PeerA: newSide("spine01-01", "192.0.2.0", 31, &as65001, bgp.IPv4Unicast),
+		PeerB: newSide("tor01-04", "192.0.2.1", 31, &as65000, bgp.IPv4Unicast),
+	}
+	ebgpIdenticalASN := &bgp.Session{
+		PeerA: newSide("spine01-01", "198.51.100.1", 32, &as65001),
+		PeerB: newSide("spine01-02", "198.51.100.2", 32, &as65001),
+	}
+	ebgpIdenticalASN.PeerA.EBGPMultihopTTL = 2
+
+	findings := lint.CheckSessions("spine01-01", []*bgp.Session{valid, afiMismatch, subnetMismatch, duplicate, ebgpIdenticalASN})
+
+	var got = make(map[string]report.Severity)
+	for _, finding := range findings {
+		got[finding.Rule] = finding.Severity
+	}
+
+	want := map[string]report.Severity{
+		"afi-safi-mismatch":  report.Warning,
+		"subnet-mismatch":    report.Warning,
+		"duplicate-neighbor": report.Error,
+		"ebgp-identical-asn": report.Error,
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("unexpected findings diff: %s\n", diff)
+	}
+
+	if findings := lint.CheckSessions("spine01-01", []*bgp.Session{valid}); len(findings) > 0 {
+		t.Errorf("unexpected findings for a valid session: %v", findings)
+	}
+
+	// the same link-local address can be used by peers behind different interfaces
+	newLinkLocal := func(remote string, iface string) *bgp.Session {
+		session := &bgp.Session{
+			PeerA: newSide("spine01-01", "fe80::", 64, &as65001, bgp.IPv6Unicast),
+			PeerB: newSide(remote, "fe80::1", 64, &as65000, bgp.IPv6Unicast),
+		}
+		session.PeerA.Interface = &bgp.InterfaceLite{Name: iface}
+		return session
+	}
+	linkLocal := []*bgp.Session{newLinkLocal("tor01-05", "Ethernet1"), newLinkLocal("tor01-06", "Ethernet2")}
+	if findings := lint.CheckSessions("spine01-01", linkLocal); len(findings) > 0 {
+		t.Errorf("unexpected findings for link-local sessions on different interfaces: %v", findings)
+	}
+
+	linkLocal = append(linkLocal, newLinkLocal("tor01-07", "Ethernet1"))
+	findings = lint.CheckSessions("spine01-01", linkLocal)
+	if len(findings) != 1 || findings[0].Rule != "duplicate-neighbor" {
+		t.Errorf("expected a duplicate-neighbor finding for link-local sessions on the same interface, got: %v", findings)
+	}
+}
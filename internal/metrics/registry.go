@@ -1,7 +1,10 @@
 package metrics
 
 import (
+	"strconv"
+
 	"github.com/criteo/data-aggregation-api/internal/app"
+	"github.com/criteo/data-aggregation-api/internal/report"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -16,6 +19,9 @@ type Registry struct {
 	buildDataFetchingDuration prometheus.Gauge
 	buildPrecomputeDuration   prometheus.Gauge
 	buildComputeDuration      prometheus.Gauge
+
+	sessionLintFindings *prometheus.GaugeVec
+	configLintFindings  *prometheus.GaugeVec
 }
 
 func NewRegistry() Registry {
@@ -74,6 +80,20 @@ func NewRegistry() Registry {
 				Help: "Duration of the compute step",
 			},
 		),
+		sessionLintFindings: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "bgp_session_lint_findings",
+				Help: "Number of BGP session lint findings during last build",
+			},
+			[]string{"rule", "severity"},
+		),
+		configLintFindings: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "config_lint_findings",
+				Help: "Number of generated configuration lint findings during last build",
+			},
+			[]string{"rule", "severity"},
+		),
 	}
 }
 
@@ -119,3 +139,120 @@ func (r *Registry) SetBuildPrecomputeDuration(duration float64) {
 func (r *Registry) SetBuildComputeDuration(duration float64) {
 	r.buildComputeDuration.Set(duration)
 }
+
+// SetSessionLintFindings updates the `bgp_session_lint_findings` gauges.
+// Rules without finding in the last build are reset.
+func (r *Registry) SetSessionLintFindings(findings map[string]map[report.Severity]uint32) {
+	r.sessionLintFindings.Reset()
+	for rule, severities := range findings {
+		for severity, count := range severities {
+			r.sessionLintFindings.WithLabelValues(rule, string(severity)).Set(float64(count))
+		}
+	}
+}
+
+// SetConfigLintFindings updates the `config_lint_findings` gauges.
+// Rules without finding in the last build are reset.
+func (r *Registry) SetConfigLintFindings(findings map[string]map[report.Severity]uint32) {
+	r.configLintFindings.Reset()
+	for rule, severities := range findings {
+		for severity, count := range severities {
+			r.configLintFindings.WithLabelValues(rule, string(severity)).Set(float64(count))
+		}
+	}
+}
+
+// DriftRegistry holds the metrics of the drift detection between generated and running configurations.
+type DriftRegistry struct {
+	differences     *prometheus.GaugeVec
+	lastCheck       *prometheus.GaugeVec
+	collectFailures *prometheus.CounterVec
+}
+
+func NewDriftRegistry() *DriftRegistry {
+	return &DriftRegistry{
+		differences: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "config_drift_differences",
+				Help: "Number of differences between the generated and the running configuration of a device",
+			},
+			[]string{"hostname"},
+		),
+		lastCheck: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "config_drift_last_check_timestamp_seconds",
+				Help: "Time of the last drift check of a device",
+			},
+			[]string{"hostname"},
+		),
+		collectFailures: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "config_drift_collect_failures_total",
+				Help: "Total number of failures to get the running configuration of a device",
+			},
+			[]string{"hostname"},
+		),
+	}
+}
+
+// SetDifferences updates the `config_drift_differences` and `config_drift_last_check_timestamp_seconds` gauges.
+func (r *DriftRegistry) SetDifferences(hostname string, count int, timestamp float64) {
+	r.differences.WithLabelValues(hostname).Set(float64(count))
+	r.lastCheck.WithLabelValues(hostname).Set(timestamp)
+}
+
+// CollectFailed increases the `config_drift_collect_failures_total` counter.
+func (r *DriftRegistry) CollectFailed(hostname string) {
+	r.collectFailures.WithLabelValues(hostname).Inc()
+}
+
+// ApplyRegistry holds the metrics of the configuration applies reported by AFK.
+type ApplyRegistry struct {
+	reports        *prometheus.CounterVec
+	lastSuccess    *prometheus.GaugeVec
+	laggingDevices *prometheus.GaugeVec
+}
+
+func NewApplyRegistry() *ApplyRegistry {
+	return &ApplyRegistry{
+		reports: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "afk_apply_reports_total",
+				Help: "Total number of configuration applies reported by AFK",
+			},
+			[]string{"success"},
+		),
+		lastSuccess: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "afk_apply_last_success_timestamp_seconds",
+				Help: "Time of the last successful configuration apply on a device",
+			},
+			[]string{"hostname"},
+		),
+		laggingDevices: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "afk_lagging_devices",
+				Help: "Number of AFK enabled devices not running the current build, by reason",
+			},
+			[]string{"reason"},
+		),
+	}
+}
+
+// ApplyReported increases the `afk_apply_reports_total` counter and, on success, updates the
+// `afk_apply_last_success_timestamp_seconds` gauge.
+func (r *ApplyRegistry) ApplyReported(hostname string, success bool, timestamp float64) {
+	r.reports.WithLabelValues(strconv.FormatBool(success)).Inc()
+	if success {
+		r.lastSuccess.WithLabelValues(hostname).Set(timestamp)
+	}
+}
+
+// SetLaggingDevices updates the `afk_lagging_devices` gauges.
+// Reasons without lagging device are reset.
+func (r *ApplyRegistry) SetLaggingDevices(counts map[string]int) {
+	r.laggingDevices.Reset()
+	for reason, count := range counts {
+		r.laggingDevices.WithLabelValues(reason).Set(float64(count))
+	}
+}
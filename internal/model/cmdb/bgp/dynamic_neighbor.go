@@ -0,0 +1,18 @@
+package bgp
+
+import (
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/common"
+	"github.com/criteo/data-aggregation-api/internal/types"
+)
+
+type DynamicNeighbor struct {
+	ID     *int `json:"id,omitempty"`
+	Device struct {
+		Name string `json:"name" validate:"required"`
+	} `json:"device" validate:"required"`
+	Prefix       types.CIDR    `json:"prefix"         validate:"required"`
+	PeerGroup    PeerGroupLite `json:"peer_group"     validate:"required"`
+	RemoteAsnMin *common.ASN   `json:"remote_asn_min" validate:"omitempty"`
+	RemoteAsnMax *common.ASN   `json:"remote_asn_max" validate:"omitempty"`
+	Description  string        `json:"description"    validate:"omitempty"`
+}
@@ -12,9 +12,12 @@ type GlobalAfiSafi struct {
 	Name                  AfiSafiChoice `json:"afi_safi_name"             validate:"required"`
 	Aggregates            []Network     `json:"aggregates"                validate:"required"`
 	RedistributedNetworks []Network     `json:"redistributed_networks"    validate:"required"`
+	EcmpEnabled           *bool         `json:"ecmp"                      validate:"omitempty"`
+	EcmpMaximumPaths      *uint32       `json:"ecmp_maximum_paths"        validate:"omitempty"`
 }
 
 type BGPGlobal struct {
+	ID     *int `json:"id,omitempty"`
 	Device struct {
 		Name string `json:"name" validate:"required"`
 	} `json:"device" validate:"required"`
@@ -27,5 +30,17 @@ type BGPGlobal struct {
 	EcmpEnabled                *bool            `json:"ecmp"                         validate:"required"`
 	EcmpMaximumPaths           *uint32          `json:"ecmp_maximum_paths"           validate:"omitempty"`
 	RouterID                   string           `json:"router_id"                    validate:"omitempty"`
+	ClusterID                  string           `json:"cluster_id"                   validate:"omitempty"`
 	AfiSafis                   []*GlobalAfiSafi `json:"afi_safis"                    validate:"omitempty"`
+
+	ConfederationID      *common.ASN  `json:"confederation_id"      validate:"omitempty"`
+	ConfederationMembers []common.ASN `json:"confederation_members" validate:"omitempty"`
+
+	AlwaysCompareMed        bool `json:"always_compare_med"         validate:"omitempty"`
+	IgnoreAsPathLength      bool `json:"ignore_as_path_length"      validate:"omitempty"`
+	ExternalCompareRouterID bool `json:"external_compare_router_id" validate:"omitempty"`
+	EcmpAllowMultipleAs     bool `json:"ecmp_allow_multiple_as"     validate:"omitempty"`
+
+	GracefulRestartStaleRoutesTime *uint16 `json:"graceful_restart_stale_routes_time" validate:"omitempty"`
+	GracefulRestartHelperOnly      bool    `json:"graceful_restart_helper_only"       validate:"omitempty"`
 }
@@ -0,0 +1,25 @@
+package bgp
+
+type RemovePrivateAsChoice string
+
+const (
+	RemovePrivateAsRemoveAll  RemovePrivateAsChoice = "remove-all"
+	RemovePrivateAsReplaceAll RemovePrivateAsChoice = "replace-all"
+	RemovePrivateAsNone       RemovePrivateAsChoice = ""
+)
+
+type SendCommunityChoice string
+
+const (
+	SendCommunityStandard SendCommunityChoice = "standard"
+	SendCommunityExtended SendCommunityChoice = "extended"
+	SendCommunityBoth     SendCommunityChoice = "both"
+	SendCommunityNone     SendCommunityChoice = "none"
+	SendCommunityUnset    SendCommunityChoice = ""
+)
+
+type AddPaths struct {
+	Send    bool  `json:"send"     validate:"omitempty"`
+	Receive bool  `json:"receive"  validate:"omitempty"`
+	SendMax uint8 `json:"send_max" validate:"omitempty"`
+}
@@ -10,6 +10,7 @@ type PeerGroupLite struct {
 }
 
 type PeerGroup struct {
+	ID     *int `json:"id,omitempty"`
 	Device struct {
 		Name string `json:"name" validate:"required"`
 	} `json:"device" validate:"required"`
@@ -20,4 +21,11 @@ type PeerGroup struct {
 	RoutePolicyOut *routingpolicy.RoutePolicyLite `json:"route_policy_out" validate:"omitempty"`
 	Description    string                         `json:"description"      validate:"omitempty"`
 	EnforceFirstAs bool                           `json:"enforce_first_as" validate:"omitempty"`
+
+	RouteReflectorClient bool                  `json:"route_reflector_client" validate:"omitempty"`
+	AddPaths             *AddPaths             `json:"add_paths"              validate:"omitempty"`
+	AllowOwnAs           uint8                 `json:"allow_own_as"           validate:"omitempty"`
+	RemovePrivateAs      RemovePrivateAsChoice `json:"remove_private_as"      validate:"omitempty,oneof=remove-all replace-all"`
+	SendCommunity        SendCommunityChoice   `json:"send_community"         validate:"omitempty,oneof=standard extended both none"`
+	NextHopSelf          bool                  `json:"next_hop_self"          validate:"omitempty"`
 }
@@ -1,6 +1,9 @@
 package bgp
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/criteo/data-aggregation-api/internal/model/cmdb/common"
 	"github.com/criteo/data-aggregation-api/internal/model/cmdb/routingpolicy"
 	"github.com/criteo/data-aggregation-api/internal/types"
@@ -11,26 +14,91 @@ type Address struct {
 	Family  int        `json:"family"  validate:"required"`
 }
 
+type InterfaceLite struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type BFD struct {
+	Enabled bool `json:"enabled" validate:"omitempty"`
+}
+
 type DeviceSession struct {
 	Device struct {
 		Name string `json:"name" validate:"required"`
 	} `json:"device" validate:"required"`
 
-	LocalAsn        common.ASN                     `json:"local_asn"        validate:"required"`
-	PeerGroup       *PeerGroupLite                 `json:"peer_group"       validate:"omitempty"`
-	RoutePolicyIn   *routingpolicy.RoutePolicyLite `json:"route_policy_in"  validate:"omitempty"`
-	RoutePolicyOut  *routingpolicy.RoutePolicyLite `json:"route_policy_out" validate:"omitempty"`
-	AfiSafis        []*AfiSafi                     `json:"afi_safis"        validate:"required"`
-	Enabled         *bool                          `json:"enabled"          validate:"required"`
-	Description     string                         `json:"description"      validate:"omitempty"`
-	LocalAddress    Address                        `json:"local_address"    validate:"required"`
-	MaximumPrefixes uint32                         `json:"maximum_prefixes" validate:"omitempty"`
-	DelayOpenTimer  uint16                         `json:"delay_open_timer" validate:"omitempty"`
-	EnforceFirstAs  bool                           `json:"enforce_first_as" validate:"omitempty"`
+	LocalAsn          common.ASN                     `json:"local_asn"          validate:"required"`
+	PeerGroup         *PeerGroupLite                 `json:"peer_group"         validate:"omitempty"`
+	RoutePolicyIn     *routingpolicy.RoutePolicyLite `json:"route_policy_in"    validate:"omitempty"`
+	RoutePolicyOut    *routingpolicy.RoutePolicyLite `json:"route_policy_out"   validate:"omitempty"`
+	AfiSafis          []*AfiSafi                     `json:"afi_safis"          validate:"required"`
+	Enabled           *bool                          `json:"enabled"            validate:"required"`
+	Description       string                         `json:"description"        validate:"omitempty"`
+	LocalAddress      Address                        `json:"local_address"      validate:"omitempty"`
+	Interface         *InterfaceLite                 `json:"interface"          validate:"omitempty"`
+	MaximumPrefixes   uint32                         `json:"maximum_prefixes"   validate:"omitempty"`
+	DelayOpenTimer    uint16                         `json:"delay_open_timer"   validate:"omitempty"`
+	EnforceFirstAs    bool                           `json:"enforce_first_as"   validate:"omitempty"`
+	HoldTime          uint16                         `json:"hold_time"          validate:"omitempty"`
+	KeepaliveInterval uint16                         `json:"keepalive_interval" validate:"omitempty"`
+	ConnectRetry      uint16                         `json:"connect_retry"      validate:"omitempty"`
+	EBGPMultihopTTL   uint8                          `json:"ebgp_multihop_ttl"  validate:"omitempty"`
+	UpdateSource      string                         `json:"update_source"      validate:"omitempty"`
+	PassiveMode       bool                           `json:"passive_mode"       validate:"omitempty"`
+	BFD               *BFD                           `json:"bfd"                validate:"omitempty"`
+
+	RouteReflectorClient bool                  `json:"route_reflector_client" validate:"omitempty"`
+	AddPaths             *AddPaths             `json:"add_paths"              validate:"omitempty"`
+	AllowOwnAs           uint8                 `json:"allow_own_as"           validate:"omitempty"`
+	RemovePrivateAs      RemovePrivateAsChoice `json:"remove_private_as"      validate:"omitempty,oneof=remove-all replace-all"`
+	SendCommunity        SendCommunityChoice   `json:"send_community"         validate:"omitempty,oneof=standard extended both none"`
+	NextHopSelf          bool                  `json:"next_hop_self"          validate:"omitempty"`
 }
 
 type Session struct {
+	ID       *int          `json:"id,omitempty"`
 	Password string        `json:"password" validate:"omitempty"`
 	PeerA    DeviceSession `json:"peer_a"   validate:"required"`
 	PeerB    DeviceSession `json:"peer_b"   validate:"required"`
 }
+
+// InterfaceName returns the name of the interface the session is bound to, if any.
+func (d *DeviceSession) InterfaceName() string {
+	if d.Interface == nil {
+		return ""
+	}
+	return d.Interface.Name
+}
+
+// IsInterfaceBased tells if the session is established over the local interface without any peer address (unnumbered).
+func IsInterfaceBased(local *DeviceSession, remote *DeviceSession) bool {
+	return remote.LocalAddress.Address.IP == nil && local.InterfaceName() != ""
+}
+
+// NeighborKey returns the neighbor of the remote side as configured on the local device.
+//
+// Link-local addresses are scoped to the local interface: "fe80::1%Ethernet1".
+// Interface-based sessions without peer address are keyed by the local interface: "Ethernet1".
+func NeighborKey(local *DeviceSession, remote *DeviceSession) (string, error) {
+	remoteAddress := remote.LocalAddress.Address
+	if remoteAddress.IP == nil {
+		if IsInterfaceBased(local, remote) {
+			return local.InterfaceName(), nil
+		}
+		return "", errors.New("the session has neither a peer address nor an interface")
+	}
+
+	if !remoteAddress.IsLinkLocal() {
+		return remoteAddress.IP.String(), nil
+	}
+
+	zone := local.InterfaceName()
+	if zone == "" {
+		zone = local.LocalAddress.Address.Zone
+	}
+	if zone == "" {
+		return "", fmt.Errorf("link-local neighbor %s requires a scope interface", remoteAddress.IP)
+	}
+
+	return remoteAddress.IP.String() + "%" + zone, nil
+}
@@ -0,0 +1,14 @@
+package routingpolicy
+
+type ASPathListTerm struct {
+	Regex string `json:"regex" validate:"required"`
+}
+
+type ASPathList struct {
+	ID     *int `json:"id,omitempty"`
+	Device struct {
+		Name string `json:"name" validate:"required"`
+	} `json:"device" validate:"required"`
+	Name  string            `json:"name"  validate:"required"`
+	Terms []*ASPathListTerm `json:"terms" validate:"required"`
+}
@@ -3,6 +3,7 @@ package routingpolicy
 type Decision string
 
 const (
-	Permit Decision = "permit"
-	Deny   Decision = "deny"
+	Permit        Decision = "permit"
+	Deny          Decision = "deny"
+	NextStatement Decision = "next-statement"
 )
@@ -1,13 +1,25 @@
 package routingpolicy
 
+type CommunityType string
+
+const (
+	StandardCommunity CommunityType = "standard"
+	ExtendedCommunity CommunityType = "extended"
+	LargeCommunity    CommunityType = "large"
+	// An unset type is handled as a standard community list.
+	UnsetCommunity CommunityType = ""
+)
+
 type CommunityListTerm struct {
 	Community string `json:"community" validate:"required"`
 }
 
 type CommunityList struct {
+	ID     *int `json:"id,omitempty"`
 	Device struct {
 		Name string `json:"name" validate:"required"`
 	} `json:"device" validate:"required"`
-	Name  string               `json:"name"  validate:"required"`
-	Terms []*CommunityListTerm `json:"terms" validate:"required"`
+	Name  string               `json:"name"           validate:"required"`
+	Type  CommunityType        `json:"community_type" validate:"omitempty,oneof=standard extended large"`
+	Terms []*CommunityListTerm `json:"terms"          validate:"required"`
 }
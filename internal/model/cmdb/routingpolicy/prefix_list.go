@@ -18,6 +18,7 @@ type PrefixListTerm struct {
 }
 
 type PrefixList struct {
+	ID     *int   `json:"id,omitempty"`
 	Name   string `json:"name" validate:"required"`
 	Device struct {
 		Name string `json:"name" validate:"required"`
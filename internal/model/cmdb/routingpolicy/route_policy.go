@@ -34,11 +34,25 @@ const (
 	OriginNone       RouteProtocolOrigin = ""
 )
 
+type CommunitySetOption string
+
+const (
+	CommunityAdd     CommunitySetOption = "add"
+	CommunityRemove  CommunitySetOption = "remove"
+	CommunityReplace CommunitySetOption = "replace"
+	// An unset option replaces the communities, as it was the only supported behavior.
+	CommunityUnset CommunitySetOption = ""
+)
+
 type RoutePolicyTerm struct {
 	Sequence    int      `json:"sequence"    validate:"required"`
-	Decision    Decision `json:"decision"    validate:"required"`
+	Decision    Decision `json:"decision"    validate:"required,oneof=permit deny next-statement"`
 	Description string   `json:"description" validate:"omitempty"`
 
+	CallPolicy *struct {
+		Name string `json:"name" validate:"required"`
+	} `json:"call_policy" validate:"omitempty"`
+
 	FromBGPCommunityList *struct {
 		Name string `json:"name" validate:"required"`
 	} `json:"from_bgp_community_list" validate:"omitempty"`
@@ -49,22 +63,47 @@ type RoutePolicyTerm struct {
 	FromRouteType      BGPRouteType     `json:"from_route_type"      validate:"required"`
 	FromLocalPref      uint32           `json:"from_local_pref"      validate:"omitempty"`
 
+	FromBGPExtCommunityList *struct {
+		Name string `json:"name" validate:"required"`
+	} `json:"from_bgp_ext_community_list" validate:"omitempty"`
+	FromBGPLargeCommunityList *struct {
+		Name string `json:"name" validate:"required"`
+	} `json:"from_bgp_large_community_list" validate:"omitempty"`
+	FromASPathList *struct {
+		Name string `json:"name" validate:"required"`
+	} `json:"from_as_path_list" validate:"omitempty"`
+	FromMed             *uint32             `json:"from_med"               validate:"omitempty"`
+	FromOrigin          RouteProtocolOrigin `json:"from_origin"            validate:"omitempty,oneof=igp egp incomplete"`
+	FromNextHops        []net.IP            `json:"from_next_hops"         validate:"omitempty"`
+	FromAfiSafis        []string            `json:"from_afi_safis"         validate:"omitempty,dive,oneof=ipv4-unicast ipv6-unicast l2vpn-evpn"`
+	FromTag             *uint32             `json:"from_tag"               validate:"omitempty"`
+	FromPrefixLengthMin uint8               `json:"from_prefix_length_min" validate:"omitempty,max=128"`
+	FromPrefixLengthMax uint8               `json:"from_prefix_length_max" validate:"omitempty,max=128"`
+
 	SetOrigin              RouteProtocolOrigin `json:"set_origin"                 validate:"omitempty"`
 	SetASPathPrependASN    *common.ASN         `json:"set_as_path_prepend_asn"    validate:"omitempty"`
 	SetASPathPrependRepeat uint8               `json:"set_as_path_prepend_repeat" validate:"omitempty"`
 	SetCommunity           string              `json:"set_community"              validate:"omitempty"`
+	SetCommunityOption     CommunitySetOption  `json:"set_community_option"       validate:"omitempty,oneof=add remove replace"`
 	SetLargeCommunity      string              `json:"set_large_community"        validate:"omitempty"`
+	SetExtCommunity        string              `json:"set_ext_community"          validate:"omitempty"`
 	SetNextHop             *net.IP             `json:"set_next_hop"               validate:"omitempty"`
 	SetLocalPref           uint32              `json:"set_local_pref"             validate:"omitempty"`
 	SetMetric              uint32              `json:"set_metric"                 validate:"omitempty"`
+
+	SetCommunityList *struct {
+		Name string `json:"name" validate:"required"`
+	} `json:"set_community_list" validate:"omitempty"`
 }
 
 type RoutePolicy struct {
+	ID     *int   `json:"id,omitempty"`
 	Name   string `json:"name" validate:"required"`
 	Device struct {
 		Name string `json:"name" validate:"required"`
 	} `json:"device"`
-	Terms []*RoutePolicyTerm `json:"terms" validate:"required"`
+	Terms         []*RoutePolicyTerm `json:"terms"          validate:"required"`
+	DefaultResult Decision           `json:"default_result" validate:"omitempty,oneof=permit deny"`
 }
 
 type RoutePolicyLite struct {
@@ -7,6 +7,7 @@ type Community struct {
 }
 
 type SNMP struct {
+	ID     *int `json:"id,omitempty"`
 	Device struct {
 		Name string `json:"name" validate:"required"`
 	} `json:"device" validate:"required"`
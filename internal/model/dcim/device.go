@@ -6,4 +6,8 @@ type NetworkDevice struct {
 	Tags         []struct {
 		Name string `json:"name" validate:"required"`
 	} `json:"tags" validate:"omitempty"`
+	Role *struct {
+		Name string `json:"name" validate:"required"`
+		Slug string `json:"slug" validate:"required"`
+	} `json:"role" validate:"omitempty"`
 }
@@ -19,7 +19,9 @@ const (
 	GlobalMessage     MessageType = "global"
 	IngestorMessage   MessageType = "ingestor"
 	PrecomputeMessage MessageType = "precompute"
+	ValidationMessage MessageType = "validation"
 	ComputeMessage    MessageType = "compute"
+	PublishMessage    MessageType = "publish"
 )
 
 type Message struct {
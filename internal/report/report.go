@@ -10,6 +10,7 @@ import (
 )
 
 type Report struct {
+	BuildID   string    `json:"build_id"`
 	StartTime time.Time `json:"start_time"`
 	EndTime   time.Time `json:"end_time"`
 
@@ -17,16 +18,19 @@ type Report struct {
 
 	Status jobStatus `json:"status"`
 	Stats  Stats     `json:"stats"`
+	// Pins are the build IDs served regardless of this build, by hostname ("*" is the whole fleet).
+	Pins map[string]string `json:"pins,omitempty"`
 
 	Logs map[MessageType][]Message `json:"logs"`
 }
 
-// NewReport creates and initializes a new Report.
-func NewReport() *Report {
+// NewReport creates and initializes a new Report for the given build.
+func NewReport(buildID string) *Report {
 	return &Report{
-		mutex:  &sync.Mutex{},
-		Status: Pending,
-		Logs:   make(map[MessageType][]Message),
+		BuildID: buildID,
+		mutex:   &sync.Mutex{},
+		Status:  Pending,
+		Logs:    make(map[MessageType][]Message),
 	}
 }
 
@@ -14,10 +14,10 @@ func NewRepository() Repository {
 	return Repository{mutex: &sync.Mutex{}}
 }
 
-func (r *Repository) StartNewReport() {
+func (r *Repository) StartNewReport(buildID string) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
-	r.last = NewReport()
+	r.last = NewReport(buildID)
 }
 
 func (r *Repository) Watch(messageChan <-chan Message) {
@@ -61,6 +61,12 @@ func (r *Repository) UpdateStats(stats Stats) {
 	r.last.Stats = stats
 }
 
+func (r *Repository) UpdatePins(pins map[string]string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.last.Pins = pins
+}
+
 func (r *Repository) MarkAsComplete() {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
@@ -72,3 +78,26 @@ func (r *Repository) MarkAsSuccessful() {
 	defer r.mutex.Unlock()
 	r.lastSuccessful = r.last
 }
+
+// SetBuildStatus updates the status of the last, or last complete, report of a build.
+// It is used when the status changes after the build, e.g. when a held build is approved.
+func (r *Repository) SetBuildStatus(buildID string, status jobStatus) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, rep := range []*Report{r.last, r.lastComplete} {
+		if rep != nil && rep.BuildID == buildID {
+			rep.mutex.Lock()
+			rep.Status = status
+			rep.mutex.Unlock()
+		}
+	}
+}
+
+// MarkBuildAsSuccessful marks the last complete report as successful, if it is the report of the build.
+func (r *Repository) MarkBuildAsSuccessful(buildID string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.lastComplete != nil && r.lastComplete.BuildID == buildID {
+		r.lastSuccessful = r.lastComplete
+	}
+}
@@ -11,6 +11,12 @@ import (
 type Stats struct {
 	BuiltDevicesCount uint32           `json:"built_devices"`
 	Performance       PerformanceStats `json:"performance"`
+	// SessionLintFindings counts the BGP session lint findings per rule and severity.
+	SessionLintFindings map[string]map[Severity]uint32 `json:"session_lint_findings"`
+	// ConfigLintFindings counts the generated configuration lint findings per rule and severity.
+	ConfigLintFindings map[string]map[Severity]uint32 `json:"config_lint_findings"`
+	// PrefixListEntriesRemoved counts the prefix-list entries removed by the optimizer.
+	PrefixListEntriesRemoved uint32 `json:"prefix_list_entries_removed"`
 }
 
 func (s Stats) Log() {
@@ -7,4 +7,9 @@ const (
 	InProgress jobStatus = "build in progress"
 	Success    jobStatus = "build successful"
 	Failed     jobStatus = "build failed"
+	// Held builds are successful but not published, their blast radius needs an approval.
+	Held     jobStatus = "build held"
+	Rejected jobStatus = "build rejected"
+	// Superseded builds were held, then replaced by a newer build.
+	Superseded jobStatus = "build superseded"
 )
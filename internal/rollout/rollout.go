@@ -0,0 +1,211 @@
+// Package rollout publishes the new builds to a canary set of devices first, then to the whole fleet once promoted.
+package rollout
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/criteo/data-aggregation-api/internal/config"
+	"github.com/criteo/data-aggregation-api/internal/convertor/device"
+)
+
+const soakCheckInterval = 30 * time.Second
+
+var ErrNoRollout = errors.New("no rollout in progress for this build ID")
+
+// Repository serves the generations of the devices configuration.
+type Repository interface {
+	Set(buildID string, devices map[string]*device.Device)
+	SetCanary(buildID string, devices map[string]*device.Device, canaryDevices []string)
+	PromoteCanary() error
+	AbortCanary() error
+}
+
+// ApplyFailures tells if a device failed to apply its configuration.
+type ApplyFailures interface {
+	FailedSince(hostname string, since time.Time) bool
+}
+
+// Status is the state of the ongoing rollout.
+type Status struct {
+	InProgress    bool      `json:"in_progress"`
+	BuildID       string    `json:"build_id,omitempty"`
+	CanaryDevices []string  `json:"canary_devices,omitempty"`
+	StartedAt     time.Time `json:"started_at,omitzero"`
+	// PromoteAt is the end of the soak time, when the rollout is promoted automatically.
+	PromoteAt time.Time `json:"promote_at,omitzero"`
+	// FailedDevices are the canary devices which reported an apply failure, preventing the automatic promotion.
+	FailedDevices []string `json:"failed_devices,omitempty"`
+}
+
+// Manager publishes the builds in two stages: the canary devices, then the whole fleet.
+// Its methods are concurrent-safe.
+type Manager struct {
+	repo     Repository
+	applies  ApplyFailures
+	settings config.RolloutConfig
+
+	mutex     *sync.Mutex
+	published bool
+	current   *Status
+}
+
+func NewManager(repo Repository, applies ApplyFailures, settings config.RolloutConfig) *Manager {
+	return &Manager{
+		repo:     repo,
+		applies:  applies,
+		settings: settings,
+		mutex:    &sync.Mutex{},
+		current:  &Status{},
+	}
+}
+
+// inPercentage tells if a hostname is part of the given percentage of the fleet.
+// The hostname hash is used so the same devices are chosen at each build.
+func inPercentage(hostname string, percentage float64) bool {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(hostname))
+	return float64(hash.Sum32()%10000)/100 < percentage
+}
+
+func (m *Manager) isCanary(dev *device.Device) bool {
+	if dev == nil || dev.Dcim == nil {
+		return false
+	}
+	for _, tag := range dev.Dcim.Tags {
+		if slices.Contains(m.settings.CanaryTags, tag.Name) {
+			return true
+		}
+	}
+	if role := dev.Dcim.Role; role != nil && (slices.Contains(m.settings.CanaryRoles, role.Name) || slices.Contains(m.settings.CanaryRoles, role.Slug)) {
+		return true
+	}
+	return inPercentage(dev.Dcim.Hostname, m.settings.CanaryPercentage)
+}
+
+// Set publishes a build to the canary devices, or to all devices when the rollout is disabled.
+// The first build is published to all devices, as there is no previous build to keep.
+// A new build replaces the ongoing canary build and restarts the soak time.
+func (m *Manager) Set(buildID string, devices map[string]*device.Device) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var canaryDevices []string
+	for hostname, dev := range devices {
+		if m.isCanary(dev) {
+			canaryDevices = append(canaryDevices, hostname)
+		}
+	}
+	slices.Sort(canaryDevices)
+
+	if !m.settings.Enabled || !m.published || len(canaryDevices) == 0 {
+		if m.settings.Enabled && m.published {
+			log.Warn().Str("build_id", buildID).Msg("no canary device, build published to all devices")
+		}
+		m.repo.Set(buildID, devices)
+		m.published = true
+		m.current = &Status{}
+		return
+	}
+
+	m.repo.SetCanary(buildID, devices, canaryDevices)
+	m.current = &Status{InProgress: true, BuildID: buildID, CanaryDevices: canaryDevices, StartedAt: time.Now()}
+	if m.settings.AutoPromote {
+		m.current.PromoteAt = m.current.StartedAt.Add(m.settings.SoakTime)
+	}
+	log.Info().Str("build_id", buildID).Int("canary_devices", len(canaryDevices)).Msg("canary rollout started")
+}
+
+// failedDevices must be called with the mutex locked.
+func (m *Manager) failedDevices() []string {
+	var failed []string
+	for _, hostname := range m.current.CanaryDevices {
+		if m.applies.FailedSince(hostname, m.current.StartedAt) {
+			failed = append(failed, hostname)
+		}
+	}
+	return failed
+}
+
+// Promote publishes the canary build to all devices.
+func (m *Manager) Promote(buildID string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.current.InProgress || m.current.BuildID != buildID {
+		return ErrNoRollout
+	}
+	if err := m.repo.PromoteCanary(); err != nil {
+		return err
+	}
+	m.current = &Status{}
+
+	log.Info().Str("build_id", buildID).Msg("canary rollout promoted")
+	return nil
+}
+
+// Abort serves the previous build to the canary devices again.
+func (m *Manager) Abort(buildID string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.current.InProgress || m.current.BuildID != buildID {
+		return ErrNoRollout
+	}
+	if err := m.repo.AbortCanary(); err != nil {
+		return err
+	}
+	m.current = &Status{}
+
+	log.Warn().Str("build_id", buildID).Msg("canary rollout aborted")
+	return nil
+}
+
+// CheckSoak promotes the ongoing rollout when its soak time is over without apply failure on the canary devices.
+func (m *Manager) CheckSoak(now time.Time) {
+	m.mutex.Lock()
+	current := m.current
+	eligible := current.InProgress && !current.PromoteAt.IsZero() && !now.Before(current.PromoteAt) && len(m.failedDevices()) == 0
+	m.mutex.Unlock()
+
+	if !eligible {
+		return
+	}
+	if err := m.Promote(current.BuildID); err != nil && !errors.Is(err, ErrNoRollout) {
+		log.Error().Err(err).Str("build_id", current.BuildID).Msg("failed to promote the canary rollout")
+	}
+}
+
+// Run checks the soak time of the ongoing rollout periodically, until the context is done.
+func (m *Manager) Run(ctx context.Context) {
+	ticker := time.NewTicker(soakCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.CheckSoak(now)
+		}
+	}
+}
+
+// GetStatusJSON returns the state of the ongoing rollout.
+func (m *Manager) GetStatusJSON() ([]byte, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	status := *m.current
+	if status.InProgress {
+		status.FailedDevices = m.failedDevices()
+	}
+	return json.Marshal(status)
+}
@@ -0,0 +1,111 @@
+package rollout_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/criteo/data-aggregation-api/internal/config"
+	"github.com/criteo/data-aggregation-api/internal/convertor/device"
+	"github.com/criteo/data-aggregation-api/internal/model/dcim"
+	"github.com/criteo/data-aggregation-api/internal/rollout"
+)
+
+// applyFailures records the devices which failed to apply their configuration.
+type applyFailures map[string]time.Time
+
+func (a applyFailures) FailedSince(hostname string, since time.Time) bool {
+	failedAt, ok := a[hostname]
+	return ok && !failedAt.Before(since)
+}
+
+func newDevice(hostname string, hash string, tags ...string) *device.Device {
+	dev := &device.Device{
+		Dcim:   &dcim.NetworkDevice{Hostname: hostname},
+		Config: &device.GeneratedConfig{Hash: hash},
+	}
+	for _, tag := range tags {
+		dev.Dcim.Tags = append(dev.Dcim.Tags, struct {
+			Name string `json:"name" validate:"required"`
+		}{Name: tag})
+	}
+	return dev
+}
+
+func newBuild(hash string) map[string]*device.Device {
+	return map[string]*device.Device{
+		"tor01-01": newDevice("tor01-01", hash, "canary"),
+		"tor01-02": newDevice("tor01-02", hash),
+		"tor01-03": newDevice("tor01-03", hash),
+	}
+}
+
+func generations(t *testing.T, repo *device.SafeRepository) map[string]device.GenerationResponse {
+	t.Helper()
+
+	out, err := repo.GetAllDevicesGenerationJSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var r map[string]device.GenerationResponse
+	if err := json.Unmarshal(out, &r); err != nil {
+		t.Fatalf("invalid JSON: %s", err)
+	}
+	return r
+}
+
+func TestRollout(t *testing.T) {
+	repo := device.NewSafeRepository(10)
+	failures := applyFailures{}
+	settings := config.RolloutConfig{Enabled: true, CanaryTags: []string{"canary"}, AutoPromote: true, SoakTime: time.Hour}
+	manager := rollout.NewManager(&repo, failures, settings)
+
+	// the first build is served to all devices, the second one to the canary only
+	manager.Set("build-1", newBuild("a"))
+	manager.Set("build-2", newBuild("b"))
+
+	want := map[string]device.GenerationResponse{
+		"tor01-01": {BuildID: "build-2", Generation: device.CanaryGeneration},
+		"tor01-02": {BuildID: "build-1", Generation: device.StableGeneration},
+		"tor01-03": {BuildID: "build-1", Generation: device.StableGeneration},
+	}
+	if diff := cmp.Diff(generations(t, &repo), want); diff != "" {
+		t.Errorf("unexpected diff for canary generations: %s\n", diff)
+	}
+	if _, hash, _ := repo.GetBuildInfo("tor01-02"); hash != "a" {
+		t.Errorf("expected tor01-02 to keep the previous configuration, got hash %s", hash)
+	}
+
+	// an apply failure on the canary prevents the automatic promotion
+	failures["tor01-01"] = time.Now()
+	manager.CheckSoak(time.Now().Add(2 * time.Hour))
+	if generations(t, &repo)["tor01-02"].BuildID != "build-1" {
+		t.Error("expected the rollout not to be promoted after an apply failure")
+	}
+
+	delete(failures, "tor01-01")
+	manager.CheckSoak(time.Now())
+	if generations(t, &repo)["tor01-02"].BuildID != "build-1" {
+		t.Error("expected the rollout not to be promoted before the end of the soak time")
+	}
+	manager.CheckSoak(time.Now().Add(2 * time.Hour))
+	for hostname, generation := range generations(t, &repo) {
+		if generation.BuildID != "build-2" || generation.Generation != device.StableGeneration {
+			t.Errorf("expected %s to be served the promoted build, got %+v", hostname, generation)
+		}
+	}
+
+	// an aborted rollout serves the previous build again
+	manager.Set("build-3", newBuild("c"))
+	if err := manager.Abort("build-2"); err == nil {
+		t.Error("expected an error aborting a build not in rollout")
+	}
+	if err := manager.Abort("build-3"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, hash, _ := repo.GetBuildInfo("tor01-01"); hash != "b" {
+		t.Errorf("expected tor01-01 to be served the previous configuration, got hash %s", hash)
+	}
+}
@@ -0,0 +1,364 @@
+// Package search indexes the generated configuration to find which devices reference a value.
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	PrefixKind    = "prefix"
+	CommunityKind = "community"
+	ASNKind       = "asn"
+	PolicyKind    = "policy"
+)
+
+// listKeys are the leaves used to identify a list entry in a path, in the order they are printed.
+var listKeys = []string{"identifier", "name", "neighbor-address", "peer-group-name", "afi-safi-name", "prefix", "ip-prefix", "masklength-range"}
+
+var communityLeaves = map[string]bool{
+	"community-member":     true,
+	"ext-community-member": true,
+	"communities":          true,
+}
+
+var asnLeaves = map[string]bool{
+	"as":        true,
+	"asn":       true,
+	"local-as":  true,
+	"peer-as":   true,
+	"member-as": true,
+}
+
+var policyLeaves = map[string]bool{
+	"import-policy": true,
+	"export-policy": true,
+	"call-policy":   true,
+}
+
+var asnPattern = regexp.MustCompile(`[0-9]+`)
+
+var extCommunityTypes = map[string]string{
+	"rt:":  "route-target:",
+	"soo:": "route-origin:",
+}
+
+// Match is a value found in the configuration of a device.
+type Match struct {
+	Hostname string `json:"hostname"`
+	Kind     string `json:"kind"`
+	Path     string `json:"path"`
+	Value    string `json:"value"`
+}
+
+type prefixMatch struct {
+	network *net.IPNet
+	match   *Match
+}
+
+// Index maps prefixes, communities, ASNs and policy names to the paths referencing them.
+type Index struct {
+	prefixes    []*prefixMatch
+	communities map[string][]*Match
+	asns        map[uint32][]*Match
+	policies    map[string][]*Match
+}
+
+func NewIndex() *Index {
+	return &Index{
+		communities: make(map[string][]*Match),
+		asns:        make(map[uint32][]*Match),
+		policies:    make(map[string][]*Match),
+	}
+}
+
+// stripModule removes the RFC7951 module prefix of a member name ("openconfig-bgp:bgp" to "bgp").
+func stripModule(name string) string {
+	if _, after, found := strings.Cut(name, ":"); found {
+		return after
+	}
+	return name
+}
+
+func formatScalar(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// entryKey formats the keys of a list entry ("[name=SERVERS]"), or its index if no key is known.
+func entryKey(entry map[string]any, index int) string {
+	var keys []string
+	for _, key := range listKeys {
+		for member, value := range entry {
+			if stripModule(member) != key {
+				continue
+			}
+			if _, isObject := value.(map[string]any); !isObject {
+				keys = append(keys, fmt.Sprintf("[%s=%s]", key, formatScalar(value)))
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return "[" + strconv.Itoa(index) + "]"
+	}
+	return strings.Join(keys, "")
+}
+
+// normalizePrefix parses an address or a prefix, an address being indexed as a host prefix.
+func normalizePrefix(value string) (*net.IPNet, bool) {
+	// drop the scope interface of IPv6 link-local addresses, it may contain "/" (e.g. fe80::1%Ethernet1/1)
+	value, _, _ = strings.Cut(value, "%")
+
+	if _, network, err := net.ParseCIDR(value); err == nil {
+		return network, true
+	}
+
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, false
+	}
+	bits := net.IPv6len * 8
+	if ip.To4() != nil {
+		ip, bits = ip.To4(), net.IPv4len*8
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, true
+}
+
+// normalizeCommunity formats a numeric community as "asn:value" and extended communities in the OpenConfig format.
+func normalizeCommunity(value any) string {
+	if number, ok := value.(json.Number); ok {
+		if n, err := strconv.ParseUint(number.String(), 10, 32); err == nil {
+			return fmt.Sprintf("%d:%d", n>>16, n&0xffff)
+		}
+	}
+
+	community := formatScalar(value)
+	for prefix, ocPrefix := range extCommunityTypes {
+		if strings.HasPrefix(community, prefix) {
+			return ocPrefix + strings.TrimPrefix(community, prefix)
+		}
+	}
+	return community
+}
+
+// asPathBoundaries are the characters around an ASN in an AS-path regular expression.
+const asPathBoundaries = "^$_ ()|"
+
+// asPathASNs returns the ASNs explicitly written in an AS-path regular expression.
+// Digits being part of a range or a character class (e.g. 6500[0-9]) are not ASNs.
+func asPathASNs(regex string) []uint32 {
+	var asns []uint32
+	for _, bounds := range asnPattern.FindAllStringIndex(regex, -1) {
+		start, end := bounds[0], bounds[1]
+		if start > 0 && !strings.ContainsRune(asPathBoundaries, rune(regex[start-1])) {
+			continue
+		}
+		if end < len(regex) && !strings.ContainsRune(asPathBoundaries, rune(regex[end])) {
+			continue
+		}
+		if asn, err := parseASN(regex[start:end]); err == nil {
+			asns = append(asns, asn)
+		}
+	}
+	return asns
+}
+
+// parseASN parses an ASN, with or without the "AS" prefix.
+func parseASN(value string) (uint32, error) {
+	value = strings.TrimPrefix(strings.ToUpper(value), "AS")
+	asn, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ASN: %s", value)
+	}
+	return uint32(asn), nil
+}
+
+// isPolicyDefinitionName tells if the path is the name of a policy definition, not of one of its statements.
+func isPolicyDefinitionName(path string) bool {
+	entry, found := strings.CutSuffix(path, "/config/name")
+	if !found {
+		return false
+	}
+	return strings.HasPrefix(entry[strings.LastIndex(entry, "/")+1:], "policy-definition[")
+}
+
+func (i *Index) indexLeaf(hostname string, path string, leaf string, value any) {
+	newMatch := func(kind string, value string) *Match {
+		return &Match{Hostname: hostname, Kind: kind, Path: path, Value: value}
+	}
+
+	switch {
+	case communityLeaves[leaf]:
+		community := normalizeCommunity(value)
+		i.communities[community] = append(i.communities[community], newMatch(CommunityKind, community))
+		return
+	case leaf == "as-path-set-member":
+		for _, asn := range asPathASNs(formatScalar(value)) {
+			i.asns[asn] = append(i.asns[asn], newMatch(ASNKind, strconv.FormatUint(uint64(asn), 10)))
+		}
+		return
+	case asnLeaves[leaf]:
+		if asn, err := parseASN(formatScalar(value)); err == nil {
+			i.asns[asn] = append(i.asns[asn], newMatch(ASNKind, strconv.FormatUint(uint64(asn), 10)))
+		}
+		return
+	case policyLeaves[leaf], leaf == "name" && isPolicyDefinitionName(path):
+		policy := formatScalar(value)
+		i.policies[policy] = append(i.policies[policy], newMatch(PolicyKind, policy))
+		return
+	}
+
+	if text, ok := value.(string); ok {
+		if network, ok := normalizePrefix(text); ok {
+			i.prefixes = append(i.prefixes, &prefixMatch{network: network, match: newMatch(PrefixKind, network.String())})
+		}
+	}
+}
+
+// walk visits every leaf of a RFC7951 JSON tree.
+// Leaves directly under a list entry are its keys, they are skipped as they are repeated in its config container.
+func (i *Index) walk(hostname string, path string, node map[string]any, isEntry bool) {
+	members := make([]string, 0, len(node))
+	for member := range node {
+		members = append(members, member)
+	}
+	sort.Strings(members)
+
+	for _, member := range members {
+		name := stripModule(member)
+		childPath := path + "/" + name
+
+		switch value := node[member].(type) {
+		case map[string]any:
+			i.walk(hostname, childPath, value, false)
+		case []any:
+			for index, item := range value {
+				if entry, ok := item.(map[string]any); ok {
+					i.walk(hostname, childPath+entryKey(entry, index), entry, true)
+				} else {
+					i.indexLeaf(hostname, childPath, name, item)
+				}
+			}
+		default:
+			if !isEntry {
+				i.indexLeaf(hostname, childPath, name, value)
+			}
+		}
+	}
+}
+
+// Add indexes the RFC7951 OpenConfig JSON of a device.
+func (i *Index) Add(hostname string, configJSON string) error {
+	decoder := json.NewDecoder(strings.NewReader(configJSON))
+	decoder.UseNumber()
+
+	var config map[string]any
+	if err := decoder.Decode(&config); err != nil {
+		return fmt.Errorf("failed to index %s: %w", hostname, err)
+	}
+
+	i.walk(hostname, "", config, false)
+	return nil
+}
+
+// Query holds the searched values, unset values are ignored.
+type Query struct {
+	Prefix string
+	// Covering also returns the indexed prefixes containing the searched prefix.
+	Covering  bool
+	Community string
+	ASN       string
+	Policy    string
+}
+
+func (i *Index) searchPrefix(prefix string, covering bool) ([]*Match, error) {
+	network, ok := normalizePrefix(prefix)
+	if !ok {
+		return nil, fmt.Errorf("invalid prefix: %s", prefix)
+	}
+	length, bits := network.Mask.Size()
+
+	var matches []*Match
+	for _, indexed := range i.prefixes {
+		indexedLength, indexedBits := indexed.network.Mask.Size()
+		if indexedBits != bits {
+			continue
+		}
+
+		exact := indexedLength == length && indexed.network.IP.Equal(network.IP)
+		covers := covering && indexedLength <= length && indexed.network.Contains(network.IP)
+		if exact || covers {
+			matches = append(matches, indexed.match)
+		}
+	}
+	return matches, nil
+}
+
+// Search returns the matches of every searched value.
+// When several values are searched, only the devices referencing all of them are returned.
+func (i *Index) Search(query Query) ([]*Match, error) {
+	var results [][]*Match
+
+	if query.Prefix != "" {
+		matches, err := i.searchPrefix(query.Prefix, query.Covering)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, matches)
+	}
+	if query.Community != "" {
+		results = append(results, i.communities[normalizeCommunity(query.Community)])
+	}
+	if query.ASN != "" {
+		asn, err := parseASN(query.ASN)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, i.asns[asn])
+	}
+	if query.Policy != "" {
+		results = append(results, i.policies[query.Policy])
+	}
+
+	if len(results) == 0 {
+		return nil, fmt.Errorf("at least one of %s, %s, %s or %s must be searched", PrefixKind, CommunityKind, ASNKind, PolicyKind)
+	}
+
+	// Keep the devices found by every searched value
+	devices := make(map[string]int)
+	for _, matches := range results {
+		found := make(map[string]bool)
+		for _, match := range matches {
+			found[match.Hostname] = true
+		}
+		for hostname := range found {
+			devices[hostname]++
+		}
+	}
+
+	out := []*Match{}
+	for _, matches := range results {
+		for _, match := range matches {
+			if devices[match.Hostname] == len(results) {
+				out = append(out, match)
+			}
+		}
+	}
+
+	slices.SortStableFunc(out, func(a, b *Match) int {
+		return strings.Compare(a.Hostname, b.Hostname)
+	})
+	return out, nil
+}
@@ -0,0 +1,223 @@
+package search_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/criteo/data-aggregation-api/internal/search"
+)
+
+const tor01 = `
+{
+	"openconfig-network-instance:network-instances": {
+		"network-instance": [
+			{
+				"name": "default",
+				"protocols": {
+					"protocol": [
+						{
+							"identifier": "openconfig-policy-types:BGP",
+							"name": "bgp",
+							"bgp": {
+								"global": {"config": {"as": 65000}},
+								"neighbors": {
+									"neighbor": [
+										{
+											"neighbor-address": "192.0.2.1",
+											"config": {"neighbor-address": "192.0.2.1", "peer-as": 65001},
+											"apply-policy": {"config": {"import-policy": ["SERVERS:IN"]}}
+										}
+									]
+								}
+							}
+						}
+					]
+				}
+			}
+		]
+	},
+	"openconfig-routing-policy:routing-policy": {
+		"defined-sets": {
+			"prefix-sets": {
+				"prefix-set": [
+					{
+						"name": "SERVERS",
+						"prefixes": {
+							"prefix": [
+								{
+									"ip-prefix": "198.51.100.0/24",
+									"masklength-range": "24..32",
+									"config": {"ip-prefix": "198.51.100.0/24", "masklength-range": "24..32"}
+								}
+							]
+						}
+					}
+				]
+			},
+			"openconfig-bgp-policy:bgp-defined-sets": {
+				"community-sets": {
+					"community-set": [
+						{"community-set-name": "BLACKHOLE", "config": {"community-member": ["65535:666"]}}
+					]
+				}
+			}
+		},
+		"policy-definitions": {
+			"policy-definition": [
+				{
+					"name": "SERVERS:IN",
+					"config": {"name": "SERVERS:IN"},
+					"statements": {"statement": [{"name": "10", "config": {"name": "10"}}]}
+				}
+			]
+		}
+	}
+}`
+
+const tor02 = `
+{
+	"openconfig-network-instance:network-instances": {
+		"network-instance": [
+			{
+				"name": "default",
+				"protocols": {
+					"protocol": [
+						{
+							"identifier": "openconfig-policy-types:BGP",
+							"name": "bgp",
+							"bgp": {
+								"global": {"config": {"as": 65001}},
+								"neighbors": {
+									"neighbor": [
+										{
+											"neighbor-address": "fe80::1%Ethernet1/1",
+											"config": {"neighbor-address": "fe80::1%Ethernet1/1"}
+										}
+									]
+								}
+							}
+						}
+					]
+				}
+			}
+		]
+	},
+	"openconfig-routing-policy:routing-policy": {
+		"defined-sets": {
+			"openconfig-bgp-policy:bgp-defined-sets": {
+				"as-path-sets": {
+					"as-path-set": [
+						{"as-path-set-name": "TRANSIT", "config": {"as-path-set-member": ["^65010_6500[0-9]_", "_(65020|65030)$"]}}
+					]
+				}
+			}
+		}
+	}
+}`
+
+func newIndex(t *testing.T) *search.Index {
+	t.Helper()
+	index := search.NewIndex()
+	for hostname, config := range map[string]string{"tor01-01": tor01, "tor01-02": tor02} {
+		if err := index.Add(hostname, config); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	return index
+}
+
+func TestSearch(t *testing.T) {
+	index := newIndex(t)
+	bgpPath := "/network-instances/network-instance[name=default]/protocols/protocol[identifier=openconfig-policy-types:BGP][name=bgp]/bgp"
+
+	tests := []struct {
+		name  string
+		query search.Query
+		want  []*search.Match
+	}{
+		{
+			name:  "ASN",
+			query: search.Query{ASN: "AS65001"},
+			want: []*search.Match{
+				{Hostname: "tor01-01", Kind: search.ASNKind, Path: bgpPath + "/neighbors/neighbor[neighbor-address=192.0.2.1]/config/peer-as", Value: "65001"},
+				{Hostname: "tor01-02", Kind: search.ASNKind, Path: bgpPath + "/global/config/as", Value: "65001"},
+			},
+		},
+		{
+			name:  "ASN in an AS-path regular expression",
+			query: search.Query{ASN: "65030"},
+			want: []*search.Match{
+				{Hostname: "tor01-02", Kind: search.ASNKind, Path: "/routing-policy/defined-sets/bgp-defined-sets/as-path-sets/as-path-set[0]/config/as-path-set-member", Value: "65030"},
+			},
+		},
+		{
+			name:  "ASN range in an AS-path regular expression is not indexed",
+			query: search.Query{ASN: "6500"},
+			want:  []*search.Match{},
+		},
+		{
+			name:  "link-local address with a scope interface",
+			query: search.Query{Prefix: "fe80::1"},
+			want: []*search.Match{
+				{Hostname: "tor01-02", Kind: search.PrefixKind, Path: bgpPath + "/neighbors/neighbor[neighbor-address=fe80::1%Ethernet1/1]/config/neighbor-address", Value: "fe80::1/128"},
+			},
+		},
+		{
+			name:  "exact prefix does not match a more specific one",
+			query: search.Query{Prefix: "198.51.100.128/25"},
+			want:  []*search.Match{},
+		},
+		{
+			name:  "covering prefix",
+			query: search.Query{Prefix: "198.51.100.128/25", Covering: true},
+			want: []*search.Match{
+				{
+					Hostname: "tor01-01",
+					Kind:     search.PrefixKind,
+					Path:     "/routing-policy/defined-sets/prefix-sets/prefix-set[name=SERVERS]/prefixes/prefix[ip-prefix=198.51.100.0/24][masklength-range=24..32]/config/ip-prefix",
+					Value:    "198.51.100.0/24",
+				},
+			},
+		},
+		{
+			name:  "policy name and community on the same device",
+			query: search.Query{Policy: "SERVERS:IN", Community: "65535:666"},
+			want: []*search.Match{
+				{Hostname: "tor01-01", Kind: search.CommunityKind, Path: "/routing-policy/defined-sets/bgp-defined-sets/community-sets/community-set[0]/config/community-member", Value: "65535:666"},
+				{Hostname: "tor01-01", Kind: search.PolicyKind, Path: bgpPath + "/neighbors/neighbor[neighbor-address=192.0.2.1]/apply-policy/config/import-policy", Value: "SERVERS:IN"},
+				{Hostname: "tor01-01", Kind: search.PolicyKind, Path: "/routing-policy/policy-definitions/policy-definition[name=SERVERS:IN]/config/name", Value: "SERVERS:IN"},
+			},
+		},
+		{
+			name:  "values not referenced by the same device",
+			query: search.Query{ASN: "65000", Community: "65535:666", Prefix: "192.0.2.1"},
+			want: []*search.Match{
+				{Hostname: "tor01-01", Kind: search.PrefixKind, Path: bgpPath + "/neighbors/neighbor[neighbor-address=192.0.2.1]/config/neighbor-address", Value: "192.0.2.1/32"},
+				{Hostname: "tor01-01", Kind: search.CommunityKind, Path: "/routing-policy/defined-sets/bgp-defined-sets/community-sets/community-set[0]/config/community-member", Value: "65535:666"},
+				{Hostname: "tor01-01", Kind: search.ASNKind, Path: bgpPath + "/global/config/as", Value: "65000"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		out, err := index.Search(test.query)
+		if err != nil {
+			t.Errorf("unexpected error for '%s': %s", test.name, err)
+			continue
+		}
+		if diff := cmp.Diff(out, test.want); diff != "" {
+			t.Errorf("unexpected diff for '%s': %s\n", test.name, diff)
+		}
+	}
+}
+
+func TestSearchInvalidQuery(t *testing.T) {
+	index := newIndex(t)
+
+	for _, query := range []search.Query{{}, {Prefix: "not-a-prefix"}, {ASN: "AS-ONE"}} {
+		if _, err := index.Search(query); err == nil {
+			t.Errorf("expected an error for %+v", query)
+		}
+	}
+}
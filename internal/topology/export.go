@@ -0,0 +1,154 @@
+package topology
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const graphMLNamespace = "http://graphml.graphdrawing.org/xmlns"
+
+func (e *Edge) label() string {
+	names := e.Source.addressFamilyNames()
+	families := make([]string, 0, len(names))
+	for _, name := range names {
+		families = append(families, string(name))
+	}
+	return strings.Join(families, ",")
+}
+
+// dotQuote quotes an identifier for the Graphviz DOT language.
+func dotQuote(id string) string {
+	return `"` + strings.ReplaceAll(id, `"`, `\"`) + `"`
+}
+
+// MarshalDOT renders the topology as an undirected Graphviz graph.
+// External peers are drawn as ellipses, disabled sessions are dashed and asymmetric sessions are red.
+func (t *Topology) MarshalDOT() []byte {
+	var out bytes.Buffer
+
+	out.WriteString("graph bgp {\n")
+	for _, node := range t.Nodes {
+		shape := "box"
+		if node.Type == ExternalNode {
+			shape = "ellipse"
+		}
+		label := fmt.Sprintf("%s\\nAS%d", node.ID, node.ASN)
+		if node.Type == ExternalNode {
+			label = node.ID
+		}
+		fmt.Fprintf(&out, "  %s [shape=%s, label=%s];\n", dotQuote(node.ID), shape, dotQuote(label))
+	}
+
+	for _, edge := range t.Edges {
+		attributes := []string{"label=" + dotQuote(edge.label())}
+		if !edge.Enabled {
+			attributes = append(attributes, "style=dashed")
+		}
+		if edge.Asymmetric {
+			attributes = append(attributes, "color=red")
+		}
+		fmt.Fprintf(&out, "  %s -- %s [%s];\n", dotQuote(edge.Source.Node), dotQuote(edge.Target.Node), strings.Join(attributes, ", "))
+	}
+	out.WriteString("}\n")
+
+	return out.Bytes()
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	Name     string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+var graphMLKeys = []graphMLKey{
+	{ID: "type", For: "node", Name: "type", AttrType: "string"},
+	{ID: "asn", For: "node", Name: "asn", AttrType: "long"},
+	{ID: "source_address", For: "edge", Name: "source_address", AttrType: "string"},
+	{ID: "target_address", For: "edge", Name: "target_address", AttrType: "string"},
+	{ID: "address_families", For: "edge", Name: "address_families", AttrType: "string"},
+	{ID: "source_import_policy", For: "edge", Name: "source_import_policy", AttrType: "string"},
+	{ID: "source_export_policy", For: "edge", Name: "source_export_policy", AttrType: "string"},
+	{ID: "target_import_policy", For: "edge", Name: "target_import_policy", AttrType: "string"},
+	{ID: "target_export_policy", For: "edge", Name: "target_export_policy", AttrType: "string"},
+	{ID: "enabled", For: "edge", Name: "enabled", AttrType: "boolean"},
+	{ID: "asymmetric", For: "edge", Name: "asymmetric", AttrType: "boolean"},
+}
+
+// MarshalGraphML renders the topology as a GraphML document.
+func (t *Topology) MarshalGraphML() ([]byte, error) {
+	document := graphMLDocument{
+		XMLNS: graphMLNamespace,
+		Keys:  graphMLKeys,
+		Graph: graphMLGraph{ID: "bgp", EdgeDefault: "undirected"},
+	}
+
+	for _, node := range t.Nodes {
+		document.Graph.Nodes = append(document.Graph.Nodes, graphMLNode{
+			ID: node.ID,
+			Data: []graphMLData{
+				{Key: "type", Value: string(node.Type)},
+				{Key: "asn", Value: strconv.FormatUint(uint64(node.ASN), 10)},
+			},
+		})
+	}
+
+	for i, edge := range t.Edges {
+		document.Graph.Edges = append(document.Graph.Edges, graphMLEdge{
+			ID:     "e" + strconv.Itoa(i),
+			Source: edge.Source.Node,
+			Target: edge.Target.Node,
+			Data: []graphMLData{
+				{Key: "source_address", Value: edge.Source.Address},
+				{Key: "target_address", Value: edge.Target.Address},
+				{Key: "address_families", Value: edge.label()},
+				{Key: "source_import_policy", Value: edge.Source.ImportPolicy},
+				{Key: "source_export_policy", Value: edge.Source.ExportPolicy},
+				{Key: "target_import_policy", Value: edge.Target.ImportPolicy},
+				{Key: "target_export_policy", Value: edge.Target.ExportPolicy},
+				{Key: "enabled", Value: strconv.FormatBool(edge.Enabled)},
+				{Key: "asymmetric", Value: strconv.FormatBool(edge.Asymmetric)},
+			},
+		})
+	}
+
+	out, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
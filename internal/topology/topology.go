@@ -0,0 +1,210 @@
+// Package topology builds the BGP peering graph of the fleet from the CMDB sessions.
+package topology
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/bgp"
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/routingpolicy"
+)
+
+type NodeType string
+
+const (
+	// DeviceNode is a device built by the API.
+	DeviceNode NodeType = "device"
+	// ExternalNode groups the peers which are not built by the API by ASN.
+	ExternalNode NodeType = "external"
+)
+
+type Node struct {
+	ID   string   `json:"id"`
+	Type NodeType `json:"type"`
+	ASN  uint32   `json:"asn"`
+}
+
+type AddressFamily struct {
+	Name         bgp.AfiSafiChoice `json:"name"`
+	ImportPolicy string            `json:"import_policy,omitempty"`
+	ExportPolicy string            `json:"export_policy,omitempty"`
+}
+
+// Endpoint is one end of a BGP session, as configured on its device.
+type Endpoint struct {
+	Node            string           `json:"node"`
+	Device          string           `json:"device"`
+	ASN             uint32           `json:"asn"`
+	Address         string           `json:"address,omitempty"`
+	Enabled         bool             `json:"enabled"`
+	ImportPolicy    string           `json:"import_policy,omitempty"`
+	ExportPolicy    string           `json:"export_policy,omitempty"`
+	AddressFamilies []*AddressFamily `json:"address_families"`
+}
+
+// Edge is a BGP session between two nodes.
+type Edge struct {
+	Source *Endpoint `json:"source"`
+	Target *Endpoint `json:"target"`
+	// Enabled is true when both ends of the session are enabled.
+	Enabled bool `json:"enabled"`
+	// Asymmetric is true when both ends are built by the API and disagree on the state or the address families.
+	Asymmetric bool `json:"asymmetric"`
+}
+
+type Topology struct {
+	Nodes []*Node `json:"nodes"`
+	Edges []*Edge `json:"edges"`
+}
+
+// Filter restricts the topology to the sessions matching every set criterion.
+type Filter struct {
+	Devices       []string
+	ASN           *uint32
+	AddressFamily bgp.AfiSafiChoice
+}
+
+func externalNodeID(asn uint32) string {
+	return fmt.Sprintf("AS%d", asn)
+}
+
+func policyName(policy *routingpolicy.RoutePolicyLite) string {
+	if policy == nil {
+		return ""
+	}
+	return policy.Name
+}
+
+func newEndpoint(session *bgp.DeviceSession, managed map[string]bool) *Endpoint {
+	endpoint := &Endpoint{
+		Node:            session.Device.Name,
+		Device:          session.Device.Name,
+		Enabled:         session.Enabled != nil && *session.Enabled,
+		ImportPolicy:    policyName(session.RoutePolicyIn),
+		ExportPolicy:    policyName(session.RoutePolicyOut),
+		AddressFamilies: make([]*AddressFamily, 0, len(session.AfiSafis)),
+	}
+	if session.LocalAsn.Number != nil {
+		endpoint.ASN = *session.LocalAsn.Number
+	}
+	if session.LocalAddress.Address.IP != nil {
+		endpoint.Address = session.LocalAddress.Address.IP.String()
+	}
+	if !managed[endpoint.Device] {
+		endpoint.Node = externalNodeID(endpoint.ASN)
+	}
+
+	for _, afiSafi := range session.AfiSafis {
+		endpoint.AddressFamilies = append(endpoint.AddressFamilies, &AddressFamily{
+			Name:         afiSafi.Name,
+			ImportPolicy: policyName(afiSafi.RoutePolicyIn),
+			ExportPolicy: policyName(afiSafi.RoutePolicyOut),
+		})
+	}
+	slices.SortFunc(endpoint.AddressFamilies, func(a, b *AddressFamily) int {
+		return strings.Compare(string(a.Name), string(b.Name))
+	})
+	return endpoint
+}
+
+func (e *Endpoint) addressFamilyNames() []bgp.AfiSafiChoice {
+	names := make([]bgp.AfiSafiChoice, 0, len(e.AddressFamilies))
+	for _, family := range e.AddressFamilies {
+		names = append(names, family.Name)
+	}
+	return names
+}
+
+func (e *Endpoint) hasAddressFamily(name bgp.AfiSafiChoice) bool {
+	return slices.Contains(e.addressFamilyNames(), name)
+}
+
+func newEdge(session *bgp.Session, managed map[string]bool) *Edge {
+	source := newEndpoint(&session.PeerA, managed)
+	target := newEndpoint(&session.PeerB, managed)
+	if !managed[source.Device] && managed[target.Device] {
+		source, target = target, source
+	}
+
+	edge := &Edge{
+		Source:  source,
+		Target:  target,
+		Enabled: source.Enabled && target.Enabled,
+	}
+	if managed[source.Device] && managed[target.Device] {
+		edge.Asymmetric = source.Enabled != target.Enabled || !slices.Equal(source.addressFamilyNames(), target.addressFamilyNames())
+	}
+	return edge
+}
+
+func (f *Filter) match(edge *Edge) bool {
+	ends := []*Endpoint{edge.Source, edge.Target}
+
+	if len(f.Devices) > 0 && !slices.ContainsFunc(ends, func(e *Endpoint) bool { return slices.Contains(f.Devices, e.Device) }) {
+		return false
+	}
+	if f.ASN != nil && !slices.ContainsFunc(ends, func(e *Endpoint) bool { return e.ASN == *f.ASN }) {
+		return false
+	}
+	if f.AddressFamily != "" && !slices.ContainsFunc(ends, func(e *Endpoint) bool { return e.hasAddressFamily(f.AddressFamily) }) {
+		return false
+	}
+	return true
+}
+
+// Build creates the topology from the sessions of the built devices.
+// A session is listed by both of its devices, it appears once in the topology.
+func Build(sessionsPerDevice map[string][]*bgp.Session, filter Filter) *Topology {
+	managed := make(map[string]bool, len(sessionsPerDevice))
+	for hostname := range sessionsPerDevice {
+		managed[hostname] = true
+	}
+
+	nodes := make(map[string]*Node)
+	seen := make(map[*bgp.Session]bool)
+	edges := []*Edge{}
+
+	for _, sessions := range sessionsPerDevice {
+		for _, session := range sessions {
+			if seen[session] {
+				continue
+			}
+			seen[session] = true
+
+			edge := newEdge(session, managed)
+			if !filter.match(edge) {
+				continue
+			}
+			edges = append(edges, edge)
+			for _, end := range []*Endpoint{edge.Source, edge.Target} {
+				nodes[end.Node] = newNode(end, managed)
+			}
+		}
+	}
+
+	slices.SortFunc(edges, func(a, b *Edge) int {
+		return strings.Compare(edgeSortKey(a), edgeSortKey(b))
+	})
+
+	out := &Topology{Nodes: make([]*Node, 0, len(nodes)), Edges: edges}
+	for _, node := range nodes {
+		out.Nodes = append(out.Nodes, node)
+	}
+	slices.SortFunc(out.Nodes, func(a, b *Node) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return out
+}
+
+func newNode(end *Endpoint, managed map[string]bool) *Node {
+	node := &Node{ID: end.Node, Type: ExternalNode, ASN: end.ASN}
+	if managed[end.Device] {
+		node.Type = DeviceNode
+	}
+	return node
+}
+
+func edgeSortKey(edge *Edge) string {
+	return strings.Join([]string{edge.Source.Node, edge.Target.Node, edge.Source.Address, edge.Target.Address}, "|")
+}
@@ -0,0 +1,173 @@
+package topology_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/criteo/data-aggregation-api/internal/model/cmdb/bgp"
+	"github.com/criteo/data-aggregation-api/internal/topology"
+)
+
+const sessions = `
+[
+	{
+		"peer_a": {
+			"device": {"name": "tor01-01"},
+			"local_asn": {"number": 65000, "organization_name": "Criteo"},
+			"local_address": {"address": "192.0.2.0/31", "family": 4},
+			"enabled": true,
+			"route_policy_out": {"name": "TOR:OUT"},
+			"afi_safis": [{"afi_safi_name": "ipv4-unicast"}]
+		},
+		"peer_b": {
+			"device": {"name": "spine01"},
+			"local_asn": {"number": 65100, "organization_name": "Criteo"},
+			"local_address": {"address": "192.0.2.1/31", "family": 4},
+			"enabled": true,
+			"afi_safis": [{"afi_safi_name": "ipv4-unicast", "route_policy_in": {"name": "TOR:IN"}}]
+		}
+	},
+	{
+		"peer_a": {
+			"device": {"name": "spine01"},
+			"local_asn": {"number": 65100, "organization_name": "Criteo"},
+			"local_address": {"address": "198.51.100.1/31", "family": 4},
+			"enabled": true,
+			"afi_safis": [{"afi_safi_name": "ipv4-unicast"}]
+		},
+		"peer_b": {
+			"device": {"name": "isp-router"},
+			"local_asn": {"number": 174, "organization_name": "ISP"},
+			"local_address": {"address": "198.51.100.0/31", "family": 4},
+			"enabled": true,
+			"afi_safis": [{"afi_safi_name": "ipv4-unicast"}]
+		}
+	},
+	{
+		"peer_a": {
+			"device": {"name": "tor01-01"},
+			"local_asn": {"number": 65000, "organization_name": "Criteo"},
+			"local_address": {"address": "2001:db8::/127", "family": 6},
+			"enabled": true,
+			"afi_safis": [{"afi_safi_name": "ipv6-unicast"}]
+		},
+		"peer_b": {
+			"device": {"name": "spine01"},
+			"local_asn": {"number": 65100, "organization_name": "Criteo"},
+			"local_address": {"address": "2001:db8::1/127", "family": 6},
+			"enabled": false,
+			"afi_safis": [{"afi_safi_name": "ipv6-unicast"}, {"afi_safi_name": "l2vpn-evpn"}]
+		}
+	}
+]`
+
+func loadSessions(t *testing.T) map[string][]*bgp.Session {
+	t.Helper()
+
+	var data []*bgp.Session
+	if err := json.Unmarshal([]byte(sessions), &data); err != nil {
+		t.Fatalf("unable to load test data: %s", err)
+	}
+
+	return map[string][]*bgp.Session{
+		"tor01-01": {data[0], data[2]},
+		"spine01":  data,
+	}
+}
+
+func TestBuild(t *testing.T) {
+	sessionsPerDevice := loadSessions(t)
+	var as174 uint32 = 174
+
+	torSpine := &topology.Edge{
+		Source: &topology.Endpoint{
+			Node: "tor01-01", Device: "tor01-01", ASN: 65000, Address: "192.0.2.0", Enabled: true, ExportPolicy: "TOR:OUT",
+			AddressFamilies: []*topology.AddressFamily{{Name: bgp.IPv4Unicast}},
+		},
+		Target: &topology.Endpoint{
+			Node: "spine01", Device: "spine01", ASN: 65100, Address: "192.0.2.1", Enabled: true,
+			AddressFamilies: []*topology.AddressFamily{{Name: bgp.IPv4Unicast, ImportPolicy: "TOR:IN"}},
+		},
+		Enabled: true,
+	}
+	spineISP := &topology.Edge{
+		Source: &topology.Endpoint{
+			Node: "spine01", Device: "spine01", ASN: 65100, Address: "198.51.100.1", Enabled: true,
+			AddressFamilies: []*topology.AddressFamily{{Name: bgp.IPv4Unicast}},
+		},
+		Target: &topology.Endpoint{
+			Node: "AS174", Device: "isp-router", ASN: 174, Address: "198.51.100.0", Enabled: true,
+			AddressFamilies: []*topology.AddressFamily{{Name: bgp.IPv4Unicast}},
+		},
+		Enabled: true,
+	}
+	torSpineV6 := &topology.Edge{
+		Source: &topology.Endpoint{
+			Node: "tor01-01", Device: "tor01-01", ASN: 65000, Address: "2001:db8::", Enabled: true,
+			AddressFamilies: []*topology.AddressFamily{{Name: bgp.IPv6Unicast}},
+		},
+		Target: &topology.Endpoint{
+			Node: "spine01", Device: "spine01", ASN: 65100, Address: "2001:db8::1",
+			AddressFamilies: []*topology.AddressFamily{{Name: bgp.IPv6Unicast}, {Name: bgp.L2vpnEvpn}},
+		},
+		Asymmetric: true,
+	}
+
+	spineNode := &topology.Node{ID: "spine01", Type: topology.DeviceNode, ASN: 65100}
+	torNode := &topology.Node{ID: "tor01-01", Type: topology.DeviceNode, ASN: 65000}
+	ispNode := &topology.Node{ID: "AS174", Type: topology.ExternalNode, ASN: 174}
+
+	tests := []struct {
+		name   string
+		filter topology.Filter
+		want   *topology.Topology
+	}{
+		{
+			name: "whole fleet",
+			want: &topology.Topology{
+				Nodes: []*topology.Node{ispNode, spineNode, torNode},
+				Edges: []*topology.Edge{spineISP, torSpine, torSpineV6},
+			},
+		},
+		{
+			name:   "external ASN",
+			filter: topology.Filter{ASN: &as174},
+			want: &topology.Topology{
+				Nodes: []*topology.Node{ispNode, spineNode},
+				Edges: []*topology.Edge{spineISP},
+			},
+		},
+		{
+			name:   "device and address family",
+			filter: topology.Filter{Devices: []string{"tor01-01"}, AddressFamily: bgp.L2vpnEvpn},
+			want: &topology.Topology{
+				Nodes: []*topology.Node{spineNode, torNode},
+				Edges: []*topology.Edge{torSpineV6},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		out := topology.Build(sessionsPerDevice, test.filter)
+		if diff := cmp.Diff(out, test.want); diff != "" {
+			t.Errorf("unexpected diff for '%s': %s\n", test.name, diff)
+		}
+	}
+}
+
+func TestMarshalDOT(t *testing.T) {
+	var as174 uint32 = 174
+	graph := topology.Build(loadSessions(t), topology.Filter{ASN: &as174})
+
+	want := `graph bgp {
+  "AS174" [shape=ellipse, label="AS174"];
+  "spine01" [shape=box, label="spine01\nAS65100"];
+  "spine01" -- "AS174" [label="ipv4-unicast"];
+}
+`
+	if diff := cmp.Diff(string(graph.MarshalDOT()), want); diff != "" {
+		t.Errorf("unexpected diff: %s\n", diff)
+	}
+}
@@ -4,30 +4,75 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"strings"
 )
 
+const zoneSeparator = "%"
+
 type CIDR struct {
 	IP      net.IP
 	Netmask int
+	// Zone is the scope interface of an IPv6 link-local address (RFC 4007), empty otherwise.
+	Zone string
 }
 
 func (c *CIDR) String() string {
+	if c.Zone != "" {
+		return fmt.Sprintf("%s%s%s/%d", c.IP.String(), zoneSeparator, c.Zone, c.Netmask)
+	}
 	return fmt.Sprintf("%s/%d", c.IP.String(), c.Netmask)
 }
 
+// IsLinkLocal tells if the address is an IPv6 link-local unicast address.
+func (c *CIDR) IsLinkLocal() bool {
+	return c.IP != nil && c.IP.To4() == nil && c.IP.IsLinkLocalUnicast()
+}
+
+// ParseCIDR parses an address in CIDR notation.
+// IPv6 link-local addresses can be scoped to an interface: "fe80::1%Ethernet1/64".
+// The interface name can contain slashes ("fe80::1%Ethernet1/1/64"), so the mask follows the last one.
+func ParseCIDR(cidr string) (CIDR, error) {
+	var zone string
+	if before, after, found := strings.Cut(cidr, zoneSeparator); found {
+		var mask string
+		zone = after
+		if i := strings.LastIndex(after, "/"); i >= 0 {
+			zone, mask = after[:i], after[i+1:]
+		}
+		if zone == "" {
+			return CIDR{}, fmt.Errorf("invalid CIDR address: %s: empty scope interface", cidr)
+		}
+		cidr = before + "/" + mask
+	}
+
+	ip, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return CIDR{}, err
+	}
+
+	parsed := CIDR{IP: ip, Zone: zone}
+	parsed.Netmask, _ = network.Mask.Size()
+
+	if zone != "" && !parsed.IsLinkLocal() {
+		return CIDR{}, fmt.Errorf("invalid CIDR address: %s: only IPv6 link-local addresses can have a scope interface", cidr)
+	}
+
+	return parsed, nil
+}
+
 func (c *CIDR) UnmarshalJSON(cidr []byte) error {
 	var cidrStr string
 	if err := json.Unmarshal(cidr, &cidrStr); err != nil {
 		return err
 	}
 
-	if ip, network, err := net.ParseCIDR(cidrStr); err != nil {
+	parsed, err := ParseCIDR(cidrStr)
+	if err != nil {
 		return err
-	} else {
-		c.IP = ip
-		c.Netmask, _ = network.Mask.Size()
-		return nil
 	}
+	*c = parsed
+
+	return nil
 }
 
 func (c *CIDR) MarshalJSON() ([]byte, error) {
@@ -0,0 +1,79 @@
+package types_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/criteo/data-aggregation-api/internal/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseCIDR(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    string
+		want    types.CIDR
+		wantErr bool
+	}{
+		{
+			name: "IPv4",
+			args: "192.0.2.0/31",
+			want: types.CIDR{IP: net.ParseIP("192.0.2.0"), Netmask: 31},
+		},
+		{
+			name: "IPv6",
+			args: "2001:db8::100/127",
+			want: types.CIDR{IP: net.ParseIP("2001:db8::100"), Netmask: 127},
+		},
+		{
+			name: "IPv6 link-local with scope interface",
+			args: "fe80::1%Ethernet1/64",
+			want: types.CIDR{IP: net.ParseIP("fe80::1"), Netmask: 64, Zone: "Ethernet1"},
+		},
+		{
+			name: "IPv6 link-local with a slash in the scope interface",
+			args: "fe80::1%Ethernet1/1/64",
+			want: types.CIDR{IP: net.ParseIP("fe80::1"), Netmask: 64, Zone: "Ethernet1/1"},
+		},
+		{
+			name: "IPv6 link-local without scope interface",
+			args: "fe80::1/64",
+			want: types.CIDR{IP: net.ParseIP("fe80::1"), Netmask: 64},
+		},
+		{
+			name:    "scope interface on a global address",
+			args:    "2001:db8::1%Ethernet1/64",
+			wantErr: true,
+		},
+		{
+			name:    "empty scope interface",
+			args:    "fe80::1%/64",
+			wantErr: true,
+		},
+		{
+			name:    "not an address",
+			args:    "unnumbered",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		out, err := types.ParseCIDR(test.args)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("expected an error for '%s'", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for '%s': %s", test.name, err)
+			continue
+		}
+		if diff := cmp.Diff(out, test.want); diff != "" {
+			t.Errorf("unexpected diff for '%s': %s\n", test.name, diff)
+		}
+		if out.String() != test.args {
+			t.Errorf("unexpected string for '%s': %s", test.name, out.String())
+		}
+	}
+}